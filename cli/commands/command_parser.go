@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -17,13 +18,65 @@ type CommandParser interface {
 	ParseFlags() error
 	// GetParsedInput возвращает сохраненные данные: флаг (асинхронность) и список ссылок.
 	GetParsedInput() (bool, []string)
+	// GetStatsInput возвращает параметры отчёта о статистике кэша: включен ли он, формат и размер топа видео.
+	GetStatsInput() (bool, string, int)
+	// GetArchiveInput возвращает параметры выгрузки и загрузки архива кэша: пути архивов и политику конфликтов.
+	GetArchiveInput() (string, string, string)
+	// GetJobInput возвращает параметры работы с фоновыми заданиями.
+	GetJobInput() JobInput
+	// GetPriority возвращает приоритет загрузок на сервере (пусто — по умолчанию сервера).
+	GetPriority() string
+	// GetDeadlineInput возвращает срок запроса на загрузку и нужно ли догружать необработанные к нему ссылки в фоне.
+	GetDeadlineInput() (time.Duration, bool)
 }
 
+// JobInput содержит параметры работы с фоновыми заданиями.
+type JobInput struct {
+	Warm     bool   // Поставить ссылки в очередь предварительной загрузки (--warm).
+	WarmFile string // Файл со ссылками для предварительной загрузки (--warm-file).
+	Callback string // Адрес уведомления о завершении задания предварительной загрузки (--callback).
+	JobID    string // Идентификатор задания, состояние которого нужно показать (--job).
+	Watch    bool   // Следить за прогрессом задания до его завершения (--watch).
+	List     bool   // Вывести список заданий (--jobs).
+	CancelID string // Идентификатор задания, которое нужно отменить (--cancel).
+}
+
+// Форматы вывода статистики кэша.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Приоритеты загрузок на сервере.
+const (
+	PriorityInteractive = "interactive"
+	PriorityBulk        = "bulk"
+)
+
+// Политики конфликтов при загрузке архива кэша.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+)
+
 // ParserConsole реализует интерфейс CommandParser и обрабатывает консольные данные.
 type ParserConsole struct {
-	isAsync bool         // Указывает, включен ли асинхронный режим (--async).
-	links   []string     // Список ссылок, переданных через консоль.
-	logger  utils.Logger // Логгер для записи событий.
+	isAsync bool     // Указывает, включен ли асинхронный режим (--async).
+	links   []string // Список ссылок, переданных через консоль.
+	stats   bool     // Запрошен ли отчёт о статистике кэша (--stats).
+	format  string   // Формат отчёта: table или json (--format).
+	topN    int      // Количество самых запрашиваемых видео в отчёте (--top).
+	export  string   // Путь архива для выгрузки кэша (--export).
+	load    string   // Путь архива для загрузки в кэш (--import).
+	policy  string   // Политика конфликтов при загрузке: skip или overwrite (--conflict).
+	jobs    JobInput // Параметры работы с фоновыми заданиями (--warm, --warm-file, --job, --watch, --jobs, --cancel).
+	// priority — приоритет загрузок для обычного режима и предварительной загрузки (--priority).
+	priority string
+	// timeout — срок запроса на загрузку обложек (--timeout), 0 — без срока.
+	timeout time.Duration
+	// background — догружать в кэш сервера ссылки, не обработанные к сроку (--background).
+	background bool
+	logger     utils.Logger // Логгер для записи событий.
 }
 
 // NewParserConsole создает новый экземпляр ParserConsole с предоставленным логгером.
@@ -36,17 +89,59 @@ func NewParserConsole(logger utils.Logger) *ParserConsole {
 // Флаг --async включает асинхронный режим.
 // Флаг --links позволяет передать список ссылок, разделенных запятой.
 // Если ссылки не переданы через --links, они извлекаются из оставшихся аргументов.
-// Возвращает ошибку, если список ссылок пуст.
+// Флаг --stats вместо загрузки обложек запрашивает статистику кэша; --format и --top задают вид отчёта.
+// Флаги --export и --import выгружают кэш в архив .tar/.zip и загружают его обратно; --conflict задает политику.
+// Флаги --warm и --warm-file ставят ссылки в очередь предварительной загрузки (--callback задает адрес уведомления
+// о завершении), --job показывает прогресс задания
+// (с --watch — до его завершения), --jobs выводит список заданий, --cancel отменяет задание.
+// Флаг --priority задает приоритет загрузок на сервере: interactive или bulk.
+// Флаг --timeout задает срок запроса на загрузку: по его истечении сервер возвращает готовые картинки,
+// а с --background продолжает загружать остальные в кэш.
+// Возвращает ошибку, если список ссылок пуст (кроме режима статистики).
 func (pc *ParserConsole) ParseFlags() error {
 	// Определение флагов
 	asyncFlag := flag.Bool("async", false, "Enable asynchronous mode for downloads")
 	linksFlag := flag.String("links", "", "Comma-separated list of video URLs")
+	statsFlag := flag.Bool("stats", false, "Print cache statistics instead of downloading thumbnails")
+	formatFlag := flag.String("format", FormatTable, "Statistics output format: table or json")
+	topFlag := flag.Int("top", 10, "Number of most requested videos in statistics (1-100)")
+	exportFlag := flag.String("export", "", "Export the cache to a .tar or .zip archive (admin token required)")
+	importFlag := flag.String("import", "", "Import a .tar or .zip cache archive (admin token required)")
+	conflictFlag := flag.String("conflict", ConflictSkip, "What to do with already cached links on import: skip or overwrite")
+	warmFlag := flag.Bool("warm", false, "Queue the links for background cache warm-up instead of downloading them")
+	warmFileFlag := flag.String("warm-file", "", "Queue links from a file (one per line) for background cache warm-up")
+	callbackFlag := flag.String("callback", "", "With -warm: URL that receives a signed POST when the job finishes")
+	priorityFlag := flag.String("priority", "", "Server fetch priority: interactive (default for downloads) or bulk (default for -warm)")
+	timeoutFlag := flag.Duration("timeout", 0, "Deadline for downloads (e.g. 5s); the server returns the thumbnails ready by then")
+	backgroundFlag := flag.Bool("background", false, "With -timeout: let the server keep caching links not processed before the deadline")
+	jobFlag := flag.String("job", "", "Show the progress of a background job")
+	watchFlag := flag.Bool("watch", false, "With -job: follow the job progress until it finishes")
+	jobsFlag := flag.Bool("jobs", false, "List background jobs, newest first")
+	cancelFlag := flag.String("cancel", "", "Cancel a queued or running background job")
 
 	// Парсинг флагов
 	flag.Parse()
 
 	// Сохранение результатов в структуру
 	pc.isAsync = *asyncFlag
+	pc.stats = *statsFlag
+	pc.format = *formatFlag
+	pc.topN = *topFlag
+	pc.export = *exportFlag
+	pc.load = *importFlag
+	pc.policy = *conflictFlag
+	pc.priority = *priorityFlag
+	pc.timeout = *timeoutFlag
+	pc.background = *backgroundFlag
+	pc.jobs = JobInput{
+		Warm:     *warmFlag || *warmFileFlag != "",
+		WarmFile: *warmFileFlag,
+		Callback: *callbackFlag,
+		JobID:    *jobFlag,
+		Watch:    *watchFlag,
+		List:     *jobsFlag,
+		CancelID: *cancelFlag,
+	}
 
 	if *linksFlag != "" {
 		pc.links = strings.Split(*linksFlag, ",")
@@ -54,6 +149,100 @@ func (pc *ParserConsole) ParseFlags() error {
 		pc.links = flag.Args()
 	}
 
+	if err := pc.validate(); err != nil {
+		return err
+	}
+
+	pc.logger.Info("Successfully parsed command line flags and arguments")
+	return nil
+}
+
+// validate проверяет сочетание разобранных флагов: в режиме статистики — формат и размер топа,
+// в режимах архива — расширение файла и политику конфликтов, иначе — наличие и корректность ссылок.
+func (pc *ParserConsole) validate() error {
+	modes := 0
+	for _, enabled := range []bool{pc.stats, pc.export != "", pc.load != "", pc.jobs.Warm, pc.jobs.JobID != "", pc.jobs.List, pc.jobs.CancelID != ""} {
+		if enabled {
+			modes++
+		}
+	}
+	if modes > 1 {
+		pc.logger.Error("Conflicting modes requested")
+		return errors.New("--stats, --export, --import, --warm, --job, --jobs and --cancel are mutually exclusive")
+	}
+	if pc.jobs.Watch && pc.jobs.JobID == "" {
+		pc.logger.Error("Watch requested without a job")
+		return errors.New("--watch requires --job")
+	}
+	if pc.priority != "" && pc.priority != PriorityInteractive && pc.priority != PriorityBulk {
+		pc.logger.Error("Invalid priority", zap.String("priority", pc.priority))
+		return fmt.Errorf("invalid priority %q: use interactive or bulk", pc.priority)
+	}
+	if pc.timeout < 0 {
+		pc.logger.Error("Invalid timeout", zap.Duration("timeout", pc.timeout))
+		return fmt.Errorf("timeout must not be negative, got %s", pc.timeout)
+	}
+	if pc.background && pc.timeout == 0 {
+		pc.logger.Error("Background requested without a timeout")
+		return errors.New("--background requires --timeout")
+	}
+	if pc.jobs.Callback != "" {
+		if !pc.jobs.Warm {
+			pc.logger.Error("Callback requested without warm-up")
+			return errors.New("--callback requires --warm or --warm-file")
+		}
+		if parsed, err := url.Parse(pc.jobs.Callback); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			pc.logger.Error("Invalid callback URL", zap.String("url", pc.jobs.Callback))
+			return fmt.Errorf("invalid callback URL %q", pc.jobs.Callback)
+		}
+	}
+
+	switch {
+	case pc.jobs.JobID != "", pc.jobs.List, pc.jobs.CancelID != "":
+		return nil
+	case pc.jobs.Warm:
+		if len(pc.links) == 0 && pc.jobs.WarmFile == "" {
+			pc.logger.Error("No video links provided for warm-up")
+			return errors.New("no video links provided")
+		}
+		if err := pc.validateLinks(pc.links); err != nil {
+			return fmt.Errorf("failed to validate links: %w", err)
+		}
+		return nil
+	case pc.export != "":
+		if _, err := utils.ArchiveFormat(pc.export); err != nil {
+			pc.logger.Error("Invalid export archive", zap.String("path", pc.export), zap.Error(err))
+			return fmt.Errorf("invalid export archive: %w", err)
+		}
+		// Ссылки необязательны: без них выгружается весь кэш
+		if err := pc.validateLinks(pc.links); err != nil {
+			return fmt.Errorf("failed to validate links: %w", err)
+		}
+		return nil
+	case pc.load != "":
+		if _, err := utils.ArchiveFormat(pc.load); err != nil {
+			pc.logger.Error("Invalid import archive", zap.String("path", pc.load), zap.Error(err))
+			return fmt.Errorf("invalid import archive: %w", err)
+		}
+		if pc.policy != ConflictSkip && pc.policy != ConflictOverwrite {
+			pc.logger.Error("Unsupported conflict policy", zap.String("conflict", pc.policy))
+			return fmt.Errorf("unsupported conflict policy: %s", pc.policy)
+		}
+		return nil
+	}
+
+	if pc.stats {
+		if pc.format != FormatTable && pc.format != FormatJSON {
+			pc.logger.Error("Unsupported stats format", zap.String("format", pc.format))
+			return fmt.Errorf("unsupported format: %s", pc.format)
+		}
+		if pc.topN < 1 || pc.topN > 100 {
+			pc.logger.Error("Invalid top value", zap.Int("top", pc.topN))
+			return fmt.Errorf("top must be between 1 and 100, got %d", pc.topN)
+		}
+		return nil
+	}
+
 	// Проверка корректности ссылок
 	if err := pc.validateLinks(pc.links); err != nil {
 		pc.logger.Error("Failed to validate links", zap.Error(err))
@@ -65,8 +254,6 @@ func (pc *ParserConsole) ParseFlags() error {
 		pc.logger.Error("No video links provided")
 		return errors.New("no video links provided")
 	}
-
-	pc.logger.Info("Successfully parsed command line flags and arguments")
 	return nil
 }
 
@@ -77,6 +264,39 @@ func (pc *ParserConsole) GetParsedInput() (bool, []string) {
 	return pc.isAsync, pc.links
 }
 
+// GetStatsInput возвращает параметры отчёта о статистике кэша:
+// - Запрошен ли отчёт (stats).
+// - Формат вывода (format).
+// - Количество самых запрашиваемых видео (topN).
+func (pc *ParserConsole) GetStatsInput() (bool, string, int) {
+	return pc.stats, pc.format, pc.topN
+}
+
+// GetArchiveInput возвращает параметры работы с архивом кэша:
+// - Путь архива для выгрузки (export).
+// - Путь архива для загрузки (load).
+// - Политику конфликтов при загрузке (policy).
+func (pc *ParserConsole) GetArchiveInput() (string, string, string) {
+	return pc.export, pc.load, pc.policy
+}
+
+// GetJobInput возвращает параметры работы с фоновыми заданиями.
+func (pc *ParserConsole) GetJobInput() JobInput {
+	return pc.jobs
+}
+
+// GetPriority возвращает приоритет загрузок на сервере (--priority).
+func (pc *ParserConsole) GetPriority() string {
+	return pc.priority
+}
+
+// GetDeadlineInput возвращает параметры срока запроса на загрузку:
+// - Срок запроса (timeout).
+// - Догружать ли необработанные к сроку ссылки в фоне (background).
+func (pc *ParserConsole) GetDeadlineInput() (time.Duration, bool) {
+	return pc.timeout, pc.background
+}
+
 // validateLinks проверяет корректность URL-адресов.
 // Возвращает ошибку, если хотя бы одна ссылка некорректна.
 func (pc *ParserConsole) validateLinks(links []string) error {
@@ -98,12 +318,39 @@ ParseFlags парсит флаги и аргументы из консоли, з
 Флаг --async включает асинхронный режим.
 Флаг --links позволяет передать список ссылок, разделенных запятой.
 Если ссылки не переданы через --links, они извлекаются из оставшихся аргументов.
-Возвращает ошибку, если список ссылок пуст.
+Флаг --stats запрашивает статистику кэша; --format и --top задают вид отчёта.
+Флаги --export и --import выгружают кэш в архив и загружают его обратно; --conflict задает политику.
+Флаги --warm и --warm-file ставят ссылки в очередь предварительной загрузки (--callback задает адрес уведомления
+о завершении), --job показывает прогресс задания
+(с --watch — до его завершения), --jobs выводит список заданий, --cancel отменяет задание.
+Флаг --priority задает приоритет загрузок на сервере.
+Флаг --timeout задает срок запроса на загрузку, --background — догрузку остальных ссылок в фоне.
+Возвращает ошибку, если список ссылок пуст (кроме режима статистики).
+
+validate проверяет сочетание разобранных флагов.
 
 GetParsedInput возвращает результаты парсинга:
 - Флаг асинхронности (isAsync).
 - Список ссылок (links).
 
+GetStatsInput возвращает параметры отчёта о статистике кэша:
+- Запрошен ли отчёт (stats).
+- Формат вывода (format).
+- Количество самых запрашиваемых видео (topN).
+
+GetArchiveInput возвращает параметры работы с архивом кэша:
+- Путь архива для выгрузки (export).
+- Путь архива для загрузки (load).
+- Политику конфликтов при загрузке (policy).
+
+GetJobInput возвращает параметры работы с фоновыми заданиями.
+
+GetPriority возвращает приоритет загрузок на сервере.
+
+GetDeadlineInput возвращает параметры срока запроса на загрузку:
+- Срок запроса (timeout).
+- Догружать ли необработанные к сроку ссылки в фоне (background).
+
 validateLinks проверяет корректность URL-адресов.
 Возвращает ошибку, если хотя бы одна ссылка некорректна.
 */
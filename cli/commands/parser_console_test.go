@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 type MockLogger struct{}
@@ -106,3 +107,125 @@ func TestValidateLinks_Invalid(t *testing.T) {
 		t.Errorf("Expected error 'invalid link', got %v", err)
 	}
 }
+
+// TestValidate_Stats проверяет, что в режиме статистики ссылки не требуются, а формат проверяется.
+func TestValidate_Stats(t *testing.T) {
+	parser := NewParserConsole(&MockLogger{})
+	parser.stats, parser.format, parser.topN = true, FormatJSON, 10
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected stats mode without links to be valid, got %v", err)
+	}
+
+	parser.format = "xml"
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("Expected error 'unsupported format', got %v", err)
+	}
+
+	parser.format, parser.topN = FormatTable, 0
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "top must be between") {
+		t.Errorf("Expected error for invalid top, got %v", err)
+	}
+}
+
+// TestValidate_Archive проверяет флаги выгрузки и загрузки архива кэша.
+func TestValidate_Archive(t *testing.T) {
+	parser := NewParserConsole(&MockLogger{})
+	parser.export = "cache.zip"
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected export without links to be valid, got %v", err)
+	}
+
+	parser.export, parser.load, parser.policy = "", "cache.rar", ConflictSkip
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "invalid import archive") {
+		t.Errorf("Expected error for unsupported archive, got %v", err)
+	}
+
+	parser.load, parser.policy = "cache.tar", "merge"
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "unsupported conflict policy") {
+		t.Errorf("Expected error for unsupported policy, got %v", err)
+	}
+
+	parser.policy, parser.stats = ConflictOverwrite, true
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Expected error for conflicting modes, got %v", err)
+	}
+}
+
+// TestValidate_Warm проверяет флаги предварительной загрузки и работы с заданиями.
+func TestValidate_Warm(t *testing.T) {
+	parser := NewParserConsole(&MockLogger{})
+	parser.jobs.Warm = true
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "no video links") {
+		t.Errorf("Expected error for warm-up without links, got %v", err)
+	}
+
+	parser.jobs.WarmFile = "links.txt"
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected warm-up from file to be valid, got %v", err)
+	}
+
+	parser.links = []string{"not a link"}
+	if err := parser.validate(); err == nil {
+		t.Errorf("Expected error for invalid warm-up link")
+	}
+
+	parser.links, parser.jobs = nil, JobInput{JobID: "abc", Watch: true}
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected job lookup to be valid, got %v", err)
+	}
+
+	parser.jobs.Warm = true
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Expected error for conflicting modes, got %v", err)
+	}
+
+	parser.jobs = JobInput{List: true, Watch: true}
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "--watch requires --job") {
+		t.Errorf("Expected error for watch without job, got %v", err)
+	}
+
+	parser.jobs = JobInput{List: true, Callback: "http://localhost/hook"}
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "--callback requires") {
+		t.Errorf("Expected error for callback without warm-up, got %v", err)
+	}
+
+	parser.links, parser.jobs = []string{"https://www.youtube.com/watch?v=dQw4w9WgXcQ"}, JobInput{Warm: true, Callback: "localhost/hook"}
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "invalid callback URL") {
+		t.Errorf("Expected error for invalid callback URL, got %v", err)
+	}
+
+	parser.jobs.Callback = "https://example.com/hook"
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected warm-up with callback to be valid, got %v", err)
+	}
+
+	parser.jobs, parser.priority = JobInput{Warm: true}, "urgent"
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "invalid priority") {
+		t.Errorf("Expected error for unknown priority, got %v", err)
+	}
+
+	parser.priority = PriorityInteractive
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected interactive warm-up to be valid, got %v", err)
+	}
+
+	parser.jobs, parser.background = JobInput{}, true
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "--background requires --timeout") {
+		t.Errorf("Expected error for background without timeout, got %v", err)
+	}
+
+	parser.timeout = -time.Second
+	if err := parser.validate(); err == nil || !strings.Contains(err.Error(), "timeout must not be negative") {
+		t.Errorf("Expected error for negative timeout, got %v", err)
+	}
+
+	parser.timeout = 5 * time.Second
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected download with deadline to be valid, got %v", err)
+	}
+
+	parser.links, parser.jobs, parser.priority, parser.timeout, parser.background = nil, JobInput{CancelID: "abc"}, "", 0, false
+	if err := parser.validate(); err != nil {
+		t.Errorf("Expected job cancellation to be valid, got %v", err)
+	}
+}
@@ -2,14 +2,15 @@ package main
 
 import (
 	"echelon_cli/commands"
+	"echelon_cli/transport"
 	"echelon_cli/utils"
 	"fmt"
 	"log"
 	"os"
 )
 
-// ParseCLIInput обрабатывает ввод из командной строки и возвращает флаг асинхронности и список ссылок.
-func ParseCLIInput(logger utils.Logger) (bool, []string) {
+// ParseCLIInput обрабатывает ввод из командной строки и возвращает разобранные параметры.
+func ParseCLIInput(logger utils.Logger) commands.CommandParser {
 	parserCLI := commands.NewParserConsole(logger)
 	logger.Info("Command line parser initialized")
 
@@ -20,7 +21,7 @@ func ParseCLIInput(logger utils.Logger) (bool, []string) {
 		os.Exit(1)
 	}
 
-	return parserCLI.GetParsedInput()
+	return parserCLI
 }
 
 func main() {
@@ -37,19 +38,35 @@ func main() {
 	}
 
 	// Парсинг ввода из командной строки
-	async, links := ParseCLIInput(logger)
+	parserCLI := ParseCLIInput(logger)
+	async, links := parserCLI.GetParsedInput()
+	stats, format, topN := parserCLI.GetStatsInput()
+	exportPath, importPath, policy := parserCLI.GetArchiveInput()
+	jobs := parserCLI.GetJobInput()
 	logger.Info("Command line parsing completed successfully")
 
-	// Тестовый вывод
-	fmt.Printf("Async: %t\n", async)
-	fmt.Printf("Links: %v\n", links)
+	// Тестовый вывод (в режиме статистики stdout занят отчётом)
+	if !stats && exportPath == "" && importPath == "" && !jobs.Warm && jobs.JobID == "" && !jobs.List && jobs.CancelID == "" {
+		fmt.Printf("Async: %t\n", async)
+		fmt.Printf("Links: %v\n", links)
+	}
 
 	// Адрес gRPC-сервера
 	serverAddress := config.GrpcServerAddress
 
+	// Параметры TLS-подключения
+	tlsConfig, err := utils.NewClientTLSConfig(config.TLS)
+	if err != nil {
+		logger.Error("Failed to configure TLS", err)
+		return
+	}
+
 	// Создание клиента
 	client := &utils.GRPCTransportSender{
-		Logger: logger,
+		Logger:     logger,
+		AdminToken: config.AdminToken,
+		Token:      config.Token,
+		TLS:        tlsConfig,
 	}
 	logger.Info("Client created successfully")
 
@@ -66,9 +83,115 @@ func main() {
 		}
 	}()
 
+	// Вывод статистики кэша вместо загрузки обложек
+	if stats {
+		resp, err := client.GetCacheStats(topN)
+		if err != nil {
+			logger.Error("Failed to get cache stats", err)
+			return
+		}
+		if err := utils.PrintCacheStats(os.Stdout, resp, format); err != nil {
+			logger.Error("Failed to print cache stats", err)
+		}
+		return
+	}
+
+	// Выгрузка кэша в архив
+	if exportPath != "" {
+		size, err := client.ExportCache(exportPath, links)
+		if err != nil {
+			logger.Error("Failed to export cache", err)
+			fmt.Printf("Ошибка выгрузки кэша: %v\n", err)
+			return
+		}
+		fmt.Printf("Кэш выгружен в %s (%d байт)\n", exportPath, size)
+		return
+	}
+
+	// Загрузка архива в кэш
+	if importPath != "" {
+		resp, err := client.ImportCache(importPath, policy)
+		if err != nil {
+			logger.Error("Failed to import cache", err)
+			fmt.Printf("Ошибка загрузки архива: %v\n", err)
+			return
+		}
+		for _, result := range resp.Results {
+			if result.Error != "" {
+				fmt.Printf("%s: %s\n", result.Link, result.Error)
+			}
+		}
+		fmt.Printf("Загружено: %d, пропущено: %d, ошибок: %d\n", resp.Imported, resp.Skipped, resp.Failed)
+		return
+	}
+
+	// Постановка ссылок в очередь предварительной загрузки
+	if jobs.Warm {
+		resp, err := client.WarmCache(links, jobs.WarmFile, jobs.Callback, parserCLI.GetPriority())
+		if err != nil {
+			logger.Error("Failed to queue warm-up job", err)
+			fmt.Printf("Ошибка постановки задания: %v\n", err)
+			return
+		}
+		fmt.Printf("Задание %s поставлено в очередь (%d ссылок)\n", resp.JobId, resp.Total)
+		fmt.Printf("Прогресс: --job %s\n", resp.JobId)
+		return
+	}
+
+	// Вывод прогресса фонового задания
+	if jobs.JobID != "" && jobs.Watch {
+		err := client.WatchJob(jobs.JobID, func(job *transport.Job) {
+			fmt.Printf("%s: %d/%d (загружено: %d, уже в кэше: %d, ошибок: %d)\n", job.State,
+				job.Fetched+job.Cached+job.Failed, job.Total, job.Fetched, job.Cached, job.Failed)
+		})
+		if err != nil {
+			logger.Error("Failed to watch job", err)
+			fmt.Printf("Ошибка наблюдения за заданием: %v\n", err)
+		}
+		return
+	}
+	if jobs.JobID != "" {
+		job, err := client.GetJob(jobs.JobID)
+		if err != nil {
+			logger.Error("Failed to get job", err)
+			fmt.Printf("Ошибка получения задания: %v\n", err)
+			return
+		}
+		utils.PrintJob(os.Stdout, job)
+		return
+	}
+
+	// Список фоновых заданий
+	if jobs.List {
+		list, err := client.ListJobs()
+		if err != nil {
+			logger.Error("Failed to list jobs", err)
+			fmt.Printf("Ошибка получения списка заданий: %v\n", err)
+			return
+		}
+		if err := utils.PrintJobs(os.Stdout, list); err != nil {
+			logger.Error("Failed to print jobs", err)
+		}
+		return
+	}
+
+	// Отмена фонового задания
+	if jobs.CancelID != "" {
+		job, err := client.CancelJob(jobs.CancelID)
+		if err != nil {
+			logger.Error("Failed to cancel job", err)
+			fmt.Printf("Ошибка отмены задания: %v\n", err)
+			return
+		}
+		utils.PrintJob(os.Stdout, job)
+		return
+	}
+
 	// Отправка данных
 	logger.Info("Starting data transmission to server")
-	if err := client.SendData(async, links); err != nil {
+	timeout, background := parserCLI.GetDeadlineInput()
+	options := utils.SendOptions{Priority: parserCLI.GetPriority(), Timeout: timeout, Background: background}
+	if err := client.SendData(async, links, options); err != nil {
 		logger.Error("Failed to send data", err)
 		return
 	}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"echelon_cli/transport"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// archiveChunkSize — размер части архива при загрузке на сервер
+const archiveChunkSize = 64 << 10
+
+// ArchiveFormat определяет формат архива кэша по расширению файла: tar или zip
+func ArchiveFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tar":
+		return "tar", nil
+	case ".zip":
+		return "zip", nil
+	default:
+		return "", fmt.Errorf("неподдерживаемое расширение архива: %s (ожидается .tar или .zip)", path)
+	}
+}
+
+// adminContext добавляет административный токен в метаданные запроса;
+// без него вызов передает токен клиента, если у того есть область доступа admin
+func (gc *GRPCTransportSender) adminContext() context.Context {
+	if gc.AdminToken == "" {
+		return context.Background()
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+gc.AdminToken)
+}
+
+// ExportCache выгружает кэш сервера в архив path (формат по расширению). Если ссылки переданы,
+// выгружаются только они. Файл записывается атомарно: сначала во временный файл рядом, затем переименовывается.
+// Возвращает размер архива в байтах
+func (gc *GRPCTransportSender) ExportCache(path string, links []string) (int64, error) {
+	format, err := ArchiveFormat(path)
+	if err != nil {
+		return 0, err
+	}
+	client := transport.NewAdminServiceClient(gc.conn)
+	stream, err := client.ExportCache(gc.adminContext(), &transport.ExportCacheRequest{Format: format, Links: links})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при запросе выгрузки: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var size int64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("ошибка при получении архива: %w", err)
+		}
+		if _, err := tmp.Write(chunk.Data); err != nil {
+			return 0, fmt.Errorf("не удалось записать архив: %w", err)
+		}
+		size += int64(len(chunk.Data))
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("не удалось записать архив: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("не удалось сохранить архив %s: %w", path, err)
+	}
+	gc.Logger.Info("Архив кэша сохранён: %s (%d байт)", path, size)
+	return size, nil
+}
+
+// ImportCache загружает архив path на сервер. policy определяет, что делать с уже закэшированными
+// ссылками: skip или overwrite
+func (gc *GRPCTransportSender) ImportCache(path string, policy string) (*transport.ImportCacheResponse, error) {
+	format, err := ArchiveFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть архив: %w", err)
+	}
+	defer file.Close()
+
+	client := transport.NewAdminServiceClient(gc.conn)
+	stream, err := client.ImportCache(gc.adminContext())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запросе загрузки: %w", err)
+	}
+
+	// Формат и политика передаются в первой части
+	req := &transport.ImportCacheRequest{Format: format, ConflictPolicy: policy}
+	buf := make([]byte, archiveChunkSize)
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			req.Data = buf[:n]
+			if err := stream.Send(req); err == io.EOF {
+				// Сервер завершил вызов досрочно: причина будет получена в CloseAndRecv
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("ошибка при отправке архива: %w", err)
+			}
+			req = &transport.ImportCacheRequest{}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать архив: %w", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при загрузке архива: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenEnvVar — переменная окружения с API-ключом или токеном JWT; имеет приоритет над конфигурацией
+const TokenEnvVar = "THUMBNAIL_API_TOKEN"
+
+// withToken добавляет токен клиента в метаданные запроса, если токен задан
+// и вызов еще не несет своих учетных данных (например, административного токена)
+func (gc *GRPCTransportSender) withToken(ctx context.Context) context.Context {
+	if gc.Token == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+gc.Token)
+}
+
+// tokenUnaryInterceptor передает токен клиента с каждым вызовом
+func (gc *GRPCTransportSender) tokenUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(gc.withToken(ctx), method, req, reply, cc, opts...)
+}
+
+// tokenStreamInterceptor передает токен клиента с каждым потоковым вызовом
+func (gc *GRPCTransportSender) tokenStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(gc.withToken(ctx), desc, cc, method, opts...)
+}
@@ -2,16 +2,21 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"echelon_cli/transport"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -19,22 +24,58 @@ import (
 type TransportSender interface {
 	// Connect устанавливает соединение с сервером
 	Connect(address string) error
-	// SendData отправляет флаг и ссылки на сервер
-	SendData(flag bool, links []string) error
+	// SendData отправляет флаг, ссылки и параметры запроса на сервер
+	SendData(flag bool, links []string, options SendOptions) error
+	// GetCacheStats запрашивает статистику кэша и topN самых запрашиваемых видео
+	GetCacheStats(topN int) (*transport.CacheStatsResponse, error)
+	// ExportCache выгружает кэш сервера в архив
+	ExportCache(path string, links []string) (int64, error)
+	// ImportCache загружает архив в кэш сервера
+	ImportCache(path string, policy string) (*transport.ImportCacheResponse, error)
+	// WarmCache ставит ссылки и файл со ссылками в очередь предварительной загрузки;
+	// callbackURL (необязательно) получит уведомление о завершении задания
+	WarmCache(links []string, filePath, callbackURL, priority string) (*transport.WarmCacheResponse, error)
+	// GetJob запрашивает состояние фонового задания
+	GetJob(id string) (*transport.Job, error)
+	// ListJobs запрашивает список всех заданий
+	ListJobs() ([]*transport.Job, error)
+	// CancelJob отменяет задание
+	CancelJob(id string) (*transport.Job, error)
+	// WatchJob передает состояние задания в fn при каждом изменении прогресса до его завершения
+	WatchJob(id string, fn func(*transport.Job)) error
 	// Close закрывает соединение с сервером
 	Close() error
 }
 
+// SendOptions содержит параметры запроса на загрузку обложек
+type SendOptions struct {
+	Priority   string        // Приоритет загрузок на сервере (пусто — по умолчанию сервера)
+	Timeout    time.Duration // Срок запроса; по его истечении сервер вернет уже готовые картинки (0 — без срока)
+	Background bool          // Догружать в кэш сервера ссылки, не обработанные к сроку
+}
+
 // GRPCTransportSender структура для работы с gRPC транспортом
 type GRPCTransportSender struct {
-	Logger Logger
-	conn   *grpc.ClientConn
+	Logger     Logger
+	AdminToken string      // Токен для вызовов AdminService
+	Token      string      // API-ключ или токен JWT для всех вызовов (пусто — без учетных данных)
+	TLS        *tls.Config // Конфигурация TLS (nil — соединение без шифрования)
+	conn       *grpc.ClientConn
 }
 
 // Connect устанавливает соединение с gRPC-сервером
 func (gc *GRPCTransportSender) Connect(address string) error {
+	creds := insecure.NewCredentials()
+	if gc.TLS != nil {
+		creds = credentials.NewTLS(gc.TLS)
+	}
 	// Установление соединения
-	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	conn, err := grpc.Dial(address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(gc.tokenUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(gc.tokenStreamInterceptor),
+		grpc.WithBlock(),
+	)
 	if err != nil {
 		return fmt.Errorf("не удалось подключиться к серверу: %w", err)
 	}
@@ -49,31 +90,61 @@ func (gc *GRPCTransportSender) Connect(address string) error {
 }
 
 // SendData отправляет данные на сервер
-func (gc *GRPCTransportSender) SendData(flag bool, links []string) error {
+func (gc *GRPCTransportSender) SendData(flag bool, links []string, options SendOptions) error {
+	// Директория для сохранения файлов
+	saveDir := "./thumbnails"
+
 	// Создание gRPC клиента
 	client := transport.NewTransportServiceClient(gc.conn)
 	// Формирование запроса
 	req := &transport.SendDataRequest{
-		Flag:  flag,
-		Links: links,
+		Flag:                 flag,
+		Links:                links,
+		KnownHashes:          knownHashes(saveDir, links),
+		Priority:             options.Priority,
+		ContinueInBackground: options.Background,
+	}
+	ctx := context.Background()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
 	}
 	// Отправка запроса
-	resp, err := client.SendData(context.Background(), req)
+	resp, err := client.SendData(ctx, req)
 	if err != nil {
 		return fmt.Errorf("ошибка при отправке данных: %w", err)
 	}
 	// Логирование ответа
 	log.Printf("Ответ от сервера: %s", resp.Status)
 
-	// Директория для сохранения файлов
-	saveDir := "./thumbnails"
 	err = os.MkdirAll(saveDir, os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("не удалось создать директорию для сохранения файлов: %w", err)
 	}
 
-	for i, img := range resp.Images {
-		videoID := extractVideoID(links[i])
+	// Картинки в ответе идут в порядке успешных результатов
+	imageIndex := 0
+	for _, result := range resp.Results {
+		if result.Status == "not_modified" {
+			log.Printf("Картинка для %s не изменилась, повторная загрузка не требуется", result.Link)
+			continue
+		}
+		if result.Status == "deadline_exceeded" {
+			log.Printf("Ссылка %s не обработана к сроку запроса: %s", result.Link, result.Error)
+			continue
+		}
+		if result.Status != "ok" {
+			log.Printf("Ошибка обработки ссылки %s: %s", result.Link, result.Error)
+			continue
+		}
+		if imageIndex >= len(resp.Images) {
+			break
+		}
+		img := resp.Images[imageIndex]
+		imageIndex++
+
+		videoID := extractVideoID(result.Link)
 		fileName := fmt.Sprintf("%s.jpeg", videoID)
 		filePath := filepath.Join(saveDir, fileName)
 		err := os.WriteFile(filePath, img, 0644)
@@ -81,7 +152,7 @@ func (gc *GRPCTransportSender) SendData(flag bool, links []string) error {
 			log.Printf("Ошибка сохранения картинки %s: %v", filePath, err)
 			continue
 		}
-		log.Printf("Картинка сохранена: %s", filePath)
+		log.Printf("Картинка сохранена: %s (phash %016x, цвет %s)", filePath, result.Phash, result.DominantColor)
 	}
 	return nil
 }
@@ -98,6 +169,23 @@ func (gc *GRPCTransportSender) Close() error {
 	return nil
 }
 
+// knownHashes вычисляет SHA-256 уже сохранённых картинок для переданных ссылок,
+// чтобы сервер не передавал их повторно. Хеш сопоставляется своей ссылке: сервер
+// сравнивает его только с картинкой этой же ссылки.
+func knownHashes(saveDir string, links []string) map[string]string {
+	hashes := make(map[string]string)
+	for _, link := range links {
+		filePath := filepath.Join(saveDir, fmt.Sprintf("%s.jpeg", extractVideoID(link)))
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[link] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
 // extractVideoID извлекает идентификатор видео из стандартной ссылки YouTube.
 func extractVideoID(videoURL string) string {
 	// Парсим URL
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"echelon_cli/transport"
+)
+
+// WarmCache ставит на сервере задание предварительной загрузки обложек. Ссылки передаются
+// в первой части запроса вместе с адресом уведомления и приоритетом, файл со ссылками (если задан) — следующими частями.
+// Возвращает идентификатор задания и число ссылок в нём
+func (gc *GRPCTransportSender) WarmCache(links []string, filePath, callbackURL, priority string) (*transport.WarmCacheResponse, error) {
+	client := transport.NewJobServiceClient(gc.conn)
+	stream, err := client.WarmCache(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запросе предварительной загрузки: %w", err)
+	}
+	if err := stream.Send(&transport.WarmCacheRequest{Links: links, CallbackUrl: callbackURL, Priority: priority}); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("ошибка при отправке ссылок: %w", err)
+	}
+
+	if filePath != "" {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось открыть файл со ссылками: %w", err)
+		}
+		defer file.Close()
+
+		buf := make([]byte, archiveChunkSize)
+		for {
+			n, err := file.Read(buf)
+			if n > 0 {
+				if err := stream.Send(&transport.WarmCacheRequest{FileChunk: buf[:n]}); err == io.EOF {
+					// Сервер завершил вызов досрочно: причина будет получена в CloseAndRecv
+					break
+				} else if err != nil {
+					return nil, fmt.Errorf("ошибка при отправке файла: %w", err)
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("не удалось прочитать файл со ссылками: %w", err)
+			}
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при постановке задания: %w", err)
+	}
+	gc.Logger.Info("Задание %s поставлено в очередь (%d ссылок)", resp.JobId, resp.Total)
+	return resp, nil
+}
+
+// GetJob запрашивает у сервера состояние фонового задания
+func (gc *GRPCTransportSender) GetJob(id string) (*transport.Job, error) {
+	client := transport.NewJobServiceClient(gc.conn)
+	resp, err := client.GetJob(context.Background(), &transport.GetJobRequest{JobId: id})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении задания: %w", err)
+	}
+	return resp.Job, nil
+}
+
+// ListJobs запрашивает у сервера все задания, начиная с самых новых, проходя по страницам
+func (gc *GRPCTransportSender) ListJobs() ([]*transport.Job, error) {
+	client := transport.NewJobServiceClient(gc.conn)
+	var jobs []*transport.Job
+	req := &transport.ListJobsRequest{}
+	for {
+		resp, err := client.ListJobs(context.Background(), req)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении списка заданий: %w", err)
+		}
+		jobs = append(jobs, resp.Jobs...)
+		if resp.NextPageToken == "" {
+			return jobs, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+// CancelJob отменяет задание на сервере и возвращает его состояние
+func (gc *GRPCTransportSender) CancelJob(id string) (*transport.Job, error) {
+	client := transport.NewJobServiceClient(gc.conn)
+	resp, err := client.CancelJob(context.Background(), &transport.CancelJobRequest{JobId: id})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отмене задания: %w", err)
+	}
+	gc.Logger.Info("Задание %s отменено", id)
+	return resp.Job, nil
+}
+
+// WatchJob получает состояние задания при каждом изменении прогресса и передает его в fn,
+// пока задание не завершится
+func (gc *GRPCTransportSender) WatchJob(id string, fn func(*transport.Job)) error {
+	client := transport.NewJobServiceClient(gc.conn)
+	stream, err := client.WatchJob(context.Background(), &transport.WatchJobRequest{JobId: id})
+	if err != nil {
+		return fmt.Errorf("ошибка при наблюдении за заданием: %w", err)
+	}
+	for {
+		job, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при наблюдении за заданием: %w", err)
+		}
+		fn(job)
+	}
+}
+
+// PrintJobs выводит список заданий таблицей
+func PrintJobs(w io.Writer, jobs []*transport.Job) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tСОСТОЯНИЕ\tПРОГРЕСС\tЗАГРУЖЕНО\tВ КЭШЕ\tОШИБОК\tСОЗДАНО")
+	for _, job := range jobs {
+		fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%d\t%d\t%d\t%s\n",
+			job.JobId, job.State, job.Fetched+job.Cached+job.Failed, job.Total,
+			job.Fetched, job.Cached, job.Failed, time.Unix(job.CreatedAt, 0).Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// PrintJob выводит состояние и прогресс фонового задания, а также ссылки, которые не удалось загрузить
+func PrintJob(w io.Writer, job *transport.Job) {
+	done := job.Fetched + job.Cached + job.Failed
+	fmt.Fprintf(w, "Задание %s (%s, %s): %s\n", job.JobId, job.Kind, job.Priority, job.State)
+	fmt.Fprintf(w, "Прогресс: %d/%d (загружено: %d, уже в кэше: %d, ошибок: %d)\n",
+		done, job.Total, job.Fetched, job.Cached, job.Failed)
+	fmt.Fprintf(w, "Создано: %s\n", time.Unix(job.CreatedAt, 0).Format(time.RFC3339))
+	if job.Owner != "" {
+		fmt.Fprintf(w, "Владелец: %s\n", job.Owner)
+	}
+	if job.StartedAt != 0 {
+		fmt.Fprintf(w, "Запущено: %s\n", time.Unix(job.StartedAt, 0).Format(time.RFC3339))
+	}
+	if job.FinishedAt != 0 {
+		fmt.Fprintf(w, "Завершено: %s\n", time.Unix(job.FinishedAt, 0).Format(time.RFC3339))
+	}
+	for _, failure := range job.Failures {
+		fmt.Fprintf(w, "%s: %s\n", failure.Link, failure.Error)
+	}
+	if job.CallbackUrl != "" {
+		fmt.Fprintf(w, "Уведомление: %s\n", job.CallbackUrl)
+		for _, delivery := range job.Deliveries {
+			result := "доставлено"
+			if delivery.Error != "" {
+				result = delivery.Error
+			}
+			fmt.Fprintf(w, "  попытка %d, %s: %s\n", delivery.Attempt,
+				time.Unix(delivery.AttemptedAt, 0).Format(time.RFC3339), result)
+		}
+	}
+}
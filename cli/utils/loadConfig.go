@@ -6,8 +6,11 @@ import (
 )
 
 type Config struct {
-	LogFilePath       string `json:"logFilePath"`
-	GrpcServerAddress string `json:"grpcServerAddress"`
+	LogFilePath       string    `json:"logFilePath"`
+	GrpcServerAddress string    `json:"grpcServerAddress"`
+	AdminToken        string    `json:"adminToken"` // Токен AdminService для выгрузки и загрузки архивов кэша
+	Token             string    `json:"token"`      // API-ключ или токен JWT; переменная окружения THUMBNAIL_API_TOKEN имеет приоритет
+	TLS               TLSConfig `json:"tls"`        // Параметры TLS-подключения к серверу
 }
 
 func LoadConfig(filePath string) (*Config, error) {
@@ -20,5 +23,8 @@ func LoadConfig(filePath string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if token := os.Getenv(TokenEnvVar); token != "" {
+		config.Token = token
+	}
 	return &config, nil
 }
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"echelon_cli/transport"
+)
+
+// GetCacheStats запрашивает у сервера статистику кэша и topN самых запрашиваемых видео
+func (gc *GRPCTransportSender) GetCacheStats(topN int) (*transport.CacheStatsResponse, error) {
+	client := transport.NewTransportServiceClient(gc.conn)
+	resp, err := client.GetCacheStats(context.Background(), &transport.CacheStatsRequest{TopN: int32(topN)})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении статистики: %w", err)
+	}
+	return resp, nil
+}
+
+// cacheStatsReport — представление статистики кэша для вывода в JSON
+type cacheStatsReport struct {
+	Entries         int32           `json:"entries"`
+	TotalBytes      int64           `json:"total_bytes"`
+	OriginalBytes   int64           `json:"original_bytes"`
+	VariantBytes    int64           `json:"variant_bytes"`
+	OldestLink      string          `json:"oldest_link,omitempty"`
+	OldestFetchedAt string          `json:"oldest_fetched_at,omitempty"`
+	NewestLink      string          `json:"newest_link,omitempty"`
+	NewestFetchedAt string          `json:"newest_fetched_at,omitempty"`
+	Hits            uint64          `json:"hits"`
+	Misses          uint64          `json:"misses"`
+	Errors          uint64          `json:"errors"`
+	Tiers           []tierStats     `json:"tiers,omitempty"`
+	TopVideos       []videoRequests `json:"top_videos"`
+}
+
+// tierStats — попадания и промахи одного уровня кэша в отчёте
+type tierStats struct {
+	Tier   string `json:"tier"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// videoRequests — количество обращений к видео в отчёте
+type videoRequests struct {
+	VideoID  string `json:"video_id"`
+	Requests uint64 `json:"requests"`
+}
+
+// PrintCacheStats выводит статистику кэша в виде таблицы ("table") или JSON ("json")
+func PrintCacheStats(w io.Writer, stats *transport.CacheStatsResponse, format string) error {
+	report := cacheStatsReport{
+		Entries:         stats.Entries,
+		TotalBytes:      stats.TotalBytes,
+		OriginalBytes:   stats.OriginalBytes,
+		VariantBytes:    stats.VariantBytes,
+		OldestLink:      stats.OldestLink,
+		OldestFetchedAt: formatUnix(stats.OldestFetchedAt),
+		NewestLink:      stats.NewestLink,
+		NewestFetchedAt: formatUnix(stats.NewestFetchedAt),
+		Hits:            stats.Hits,
+		Misses:          stats.Misses,
+		Errors:          stats.Errors,
+		TopVideos:       make([]videoRequests, 0, len(stats.TopVideos)),
+	}
+	for _, tier := range stats.Tiers {
+		report.Tiers = append(report.Tiers, tierStats{Tier: tier.Tier, Hits: tier.Hits, Misses: tier.Misses})
+	}
+	for _, video := range stats.TopVideos {
+		report.TopVideos = append(report.TopVideos, videoRequests{VideoID: video.VideoId, Requests: video.Requests})
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "table":
+		return printStatsTable(w, report)
+	default:
+		return fmt.Errorf("неподдерживаемый формат: %s", format)
+	}
+}
+
+// printStatsTable выводит отчёт о статистике кэша выровненной таблицей
+func printStatsTable(w io.Writer, report cacheStatsReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Записей в кэше\t%d\n", report.Entries)
+	fmt.Fprintf(tw, "Общий объём, байт\t%d\n", report.TotalBytes)
+	fmt.Fprintf(tw, "  исходные изображения, байт\t%d\n", report.OriginalBytes)
+	fmt.Fprintf(tw, "  производные варианты, байт\t%d\n", report.VariantBytes)
+
+	if report.OldestLink != "" {
+		fmt.Fprintf(tw, "Самая старая запись\t%s\t%s\n", report.OldestLink, report.OldestFetchedAt)
+		fmt.Fprintf(tw, "Самая свежая запись\t%s\t%s\n", report.NewestLink, report.NewestFetchedAt)
+	}
+	fmt.Fprintf(tw, "Попадания\t%d\n", report.Hits)
+	fmt.Fprintf(tw, "Промахи\t%d\n", report.Misses)
+	fmt.Fprintf(tw, "Ошибки\t%d\n", report.Errors)
+
+	if len(report.Tiers) > 0 {
+		fmt.Fprintln(tw, "\nУровень кэша\tПопадания\tПромахи")
+		for _, tier := range report.Tiers {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", tier.Tier, tier.Hits, tier.Misses)
+		}
+	}
+
+	if len(report.TopVideos) > 0 {
+		fmt.Fprintln(tw, "\nВидео\tОбращений")
+		for _, video := range report.TopVideos {
+			fmt.Fprintf(tw, "%s\t%d\n", video.VideoID, video.Requests)
+		}
+	}
+	return tw.Flush()
+}
+
+// formatUnix форматирует время Unix в RFC 3339; нулевое значение возвращается пустой строкой
+func formatUnix(seconds int64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return time.Unix(seconds, 0).Format(time.RFC3339)
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"echelon_cli/transport"
+)
+
+// testCacheStats возвращает статистику кэша с заполненными полями.
+func testCacheStats() *transport.CacheStatsResponse {
+	return &transport.CacheStatsResponse{
+		Entries:         2,
+		TotalBytes:      1500,
+		OriginalBytes:   1200,
+		VariantBytes:    300,
+		OldestLink:      "https://youtu.be/old",
+		OldestFetchedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Unix(),
+		NewestLink:      "https://youtu.be/new",
+		NewestFetchedAt: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC).Unix(),
+		Hits:            7,
+		Misses:          2,
+		Errors:          1,
+		Tiers: []*transport.CacheTierStats{
+			{Tier: "memory", Hits: 5, Misses: 4},
+			{Tier: "database", Hits: 2, Misses: 2},
+		},
+		TopVideos: []*transport.VideoRequests{
+			{VideoId: "popular", Requests: 5},
+			{VideoId: "rare", Requests: 1},
+		},
+	}
+}
+
+// TestPrintCacheStatsJSON проверяет JSON-отчёт о статистике кэша.
+func TestPrintCacheStatsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PrintCacheStats(&buf, testCacheStats(), "json"); err != nil {
+		t.Fatalf("Failed to print stats: %v", err)
+	}
+	var report cacheStatsReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v\n%s", err, buf.String())
+	}
+	if report.Entries != 2 || report.OriginalBytes != 1200 || report.VariantBytes != 300 || report.Hits != 7 || report.Misses != 2 || report.Errors != 1 {
+		t.Errorf("Unexpected report counters: %+v", report)
+	}
+	if report.OldestFetchedAt != time.Unix(testCacheStats().OldestFetchedAt, 0).Format(time.RFC3339) {
+		t.Errorf("Expected RFC 3339 fetch time, got %q", report.OldestFetchedAt)
+	}
+	if len(report.TopVideos) != 2 || report.TopVideos[0] != (videoRequests{VideoID: "popular", Requests: 5}) {
+		t.Errorf("Expected top videos in server order, got %+v", report.TopVideos)
+	}
+	if len(report.Tiers) != 2 || report.Tiers[0] != (tierStats{Tier: "memory", Hits: 5, Misses: 4}) || report.Tiers[1].Tier != "database" {
+		t.Errorf("Expected cache tiers in server order, got %+v", report.Tiers)
+	}
+}
+
+// TestPrintCacheStatsTable проверяет табличный отчёт и отказ от неизвестного формата.
+func TestPrintCacheStatsTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PrintCacheStats(&buf, testCacheStats(), "table"); err != nil {
+		t.Fatalf("Failed to print stats: %v", err)
+	}
+	output := buf.String()
+	rows := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if fields := regexp.MustCompile(`\s{2,}`).Split(strings.TrimSpace(line), 2); len(fields) == 2 {
+			rows[fields[0]] = fields[1]
+		}
+	}
+	expected := map[string]string{
+		"Записей в кэше":             "2",
+		"исходные изображения, байт": "1200",
+		"производные варианты, байт": "300",
+		"Попадания":                  "7",
+		"Самая старая запись":        "https://youtu.be/old  " + time.Unix(testCacheStats().OldestFetchedAt, 0).Format(time.RFC3339),
+	}
+	for label, value := range expected {
+		if rows[label] != value {
+			t.Errorf("Expected %q for %q, got %q:\n%s", value, label, rows[label], output)
+		}
+	}
+	if !regexp.MustCompile(`(?m)^memory\s+5\s+4$`).MatchString(output) || !regexp.MustCompile(`(?m)^database\s+2\s+2$`).MatchString(output) {
+		t.Errorf("Expected per-tier hits and misses:\n%s", output)
+	}
+	if strings.Index(output, "popular") > strings.Index(output, "rare") {
+		t.Errorf("Expected top videos in server order:\n%s", output)
+	}
+
+	// Без записей строки о самой старой и свежей записи не выводятся
+	buf.Reset()
+	if err := PrintCacheStats(&buf, &transport.CacheStatsResponse{}, "table"); err != nil {
+		t.Fatalf("Failed to print empty stats: %v", err)
+	}
+	if strings.Contains(buf.String(), "Самая старая запись") || strings.Contains(buf.String(), "Видео") || strings.Contains(buf.String(), "Уровень кэша") {
+		t.Errorf("Expected no entry rows for an empty cache:\n%s", buf.String())
+	}
+
+	if err := PrintCacheStats(&buf, testCacheStats(), "xml"); err == nil {
+		t.Error("Expected unsupported format to be rejected")
+	}
+}
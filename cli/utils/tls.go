@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig содержит параметры TLS-подключения к серверу
+type TLSConfig struct {
+	Enabled    bool   `json:"enabled"`    // Подключаться по TLS
+	CAFile     string `json:"caFile"`     // Сертификаты УЦ сервера (пусто — системные)
+	CertFile   string `json:"certFile"`   // Сертификат клиента для mTLS (пусто — без сертификата)
+	KeyFile    string `json:"keyFile"`    // Закрытый ключ клиента для mTLS
+	ServerName string `json:"serverName"` // Имя сервера для проверки сертификата (пусто — из адреса)
+}
+
+// NewClientTLSConfig формирует конфигурацию TLS клиента; для выключенного TLS возвращает nil
+func NewClientTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.ServerName}
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать сертификаты УЦ: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("в файле %s нет сертификатов УЦ", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить сертификат клиента: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,296 @@
+// Package archive реализует переносимый формат архива кэша: изображения и JSON-манифест
+// в контейнере tar или zip. Архив не зависит от бэкенда хранения и может быть импортирован
+// в любую конфигурацию сервиса.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые форматы контейнера.
+const (
+	FormatTar = "tar"
+	FormatZip = "zip"
+)
+
+// ManifestName — имя файла манифеста внутри архива.
+const ManifestName = "manifest.json"
+
+// ManifestVersion — версия формата манифеста.
+const ManifestVersion = 1
+
+// imagesDir — каталог изображений внутри архива.
+const imagesDir = "images"
+
+// MaxFileSize ограничивает размер одного файла архива при чтении.
+const MaxFileSize = 32 << 20
+
+// Manifest описывает содержимое архива.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Entry описывает одну запись кэша в манифесте. Несколько записей с одинаковым
+// содержимым ссылаются на один файл.
+type Entry struct {
+	Link        string    `json:"link"`         // Ссылка на видео (ключ кэша).
+	VideoID     string    `json:"video_id"`     // Идентификатор видео.
+	Quality     string    `json:"quality"`      // Качество обложки (например, maxresdefault).
+	FetchedAt   time.Time `json:"fetched_at"`   // Время загрузки обложки.
+	ContentHash string    `json:"content_hash"` // SHA-256 изображения.
+	ETag        string    `json:"etag"`         // Сильный ETag изображения.
+	Size        int64     `json:"size"`         // Размер изображения в байтах.
+	File        string    `json:"file"`         // Путь файла изображения внутри архива.
+}
+
+// ContentHash вычисляет SHA-256 содержимого в шестнадцатеричном виде.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ETag возвращает сильный ETag изображения, производный от хеша его содержимого.
+func ETag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// Verify проверяет, что содержимое соответствует размеру и хешу, указанным в манифесте.
+func Verify(entry Entry, data []byte) error {
+	if int64(len(data)) != entry.Size {
+		return fmt.Errorf("size mismatch for %s: manifest %d, archive %d", entry.Link, entry.Size, len(data))
+	}
+	if hash := ContentHash(data); hash != entry.ContentHash {
+		return fmt.Errorf("content hash mismatch for %s: manifest %s, archive %s", entry.Link, entry.ContentHash, hash)
+	}
+	return nil
+}
+
+// Writer последовательно записывает архив: изображения по мере добавления, манифест — при закрытии.
+type Writer struct {
+	tw       *tar.Writer
+	zw       *zip.Writer
+	manifest Manifest
+	files    map[string]bool
+}
+
+// NewWriter создает Writer, записывающий архив указанного формата в w.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	aw := &Writer{
+		manifest: Manifest{Version: ManifestVersion, CreatedAt: time.Now().UTC().Truncate(time.Second)},
+		files:    make(map[string]bool),
+	}
+	switch format {
+	case FormatTar:
+		aw.tw = tar.NewWriter(w)
+	case FormatZip:
+		aw.zw = zip.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+	return aw, nil
+}
+
+// Add добавляет запись и её изображение. Хеш, размер, ETag и путь файла заполняются по содержимому;
+// одинаковое содержимое записывается в архив один раз.
+func (aw *Writer) Add(entry Entry, data []byte) error {
+	entry.ContentHash = ContentHash(data)
+	entry.Size = int64(len(data))
+	entry.ETag = ETag(entry.ContentHash)
+	entry.File = path.Join(imagesDir, entry.ContentHash+".jpg")
+	if !aw.files[entry.File] {
+		if err := aw.writeFile(entry.File, data, entry.FetchedAt); err != nil {
+			return err
+		}
+		aw.files[entry.File] = true
+	}
+	aw.manifest.Entries = append(aw.manifest.Entries, entry)
+	return nil
+}
+
+// Len возвращает количество добавленных записей.
+func (aw *Writer) Len() int {
+	return len(aw.manifest.Entries)
+}
+
+// Close записывает манифест и завершает архив. Нижележащий io.Writer не закрывается.
+func (aw *Writer) Close() error {
+	if aw.manifest.Entries == nil {
+		aw.manifest.Entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(aw.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := aw.writeFile(ManifestName, data, aw.manifest.CreatedAt); err != nil {
+		return err
+	}
+	if aw.tw != nil {
+		return aw.tw.Close()
+	}
+	return aw.zw.Close()
+}
+
+// writeFile записывает один файл в контейнер.
+func (aw *Writer) writeFile(name string, data []byte, modTime time.Time) error {
+	if aw.tw != nil {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+		if err := aw.tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		_, err := aw.tw.Write(data)
+		return err
+	}
+	header := &zip.FileHeader{Name: name, Method: zip.Store, Modified: modTime}
+	if name == ManifestName {
+		header.Method = zip.Deflate
+	}
+	fw, err := aw.zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// Reader читает архив с произвольным доступом (например, из временного файла).
+type Reader struct {
+	r        io.ReaderAt
+	size     int64
+	zr       *zip.Reader
+	manifest Manifest
+}
+
+// NewReader открывает архив указанного формата и читает его манифест.
+func NewReader(r io.ReaderAt, size int64, format string) (*Reader, error) {
+	ar := &Reader{r: r, size: size}
+	var manifest []byte
+	switch format {
+	case FormatTar:
+		err := ar.walkTar(func(name string, data []byte) error {
+			if name == ManifestName {
+				manifest = data
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case FormatZip:
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip archive: %w", err)
+		}
+		ar.zr = zr
+		for _, file := range zr.File {
+			if file.Name == ManifestName {
+				if manifest, err = readZipFile(file); err != nil {
+					return nil, err
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+
+	if manifest == nil {
+		return nil, errors.New("archive has no " + ManifestName)
+	}
+	if err := json.Unmarshal(manifest, &ar.manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if ar.manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", ar.manifest.Version)
+	}
+	return ar, nil
+}
+
+// Manifest возвращает манифест архива.
+func (ar *Reader) Manifest() Manifest {
+	return ar.manifest
+}
+
+// Walk вызывает fn для каждого файла изображения в архиве. Манифест и файлы вне каталога
+// изображений пропускаются.
+func (ar *Reader) Walk(fn func(name string, data []byte) error) error {
+	images := func(name string, data []byte) error {
+		if !strings.HasPrefix(name, imagesDir+"/") {
+			return nil
+		}
+		return fn(name, data)
+	}
+	if ar.zr == nil {
+		return ar.walkTar(images)
+	}
+	for _, file := range ar.zr.File {
+		if !strings.HasPrefix(file.Name, imagesDir+"/") {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		if err := images(file.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTar последовательно читает все обычные файлы tar-архива.
+func (ar *Reader) walkTar(fn func(name string, data []byte) error) error {
+	tr := tar.NewReader(io.NewSectionReader(ar.r, 0, ar.size))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > MaxFileSize {
+			return fmt.Errorf("file %s exceeds %d bytes", header.Name, MaxFileSize)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		if err := fn(header.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// readZipFile читает файл zip-архива с ограничением размера.
+func readZipFile(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > MaxFileSize {
+		return nil, fmt.Errorf("file %s exceeds %d bytes", file.Name, MaxFileSize)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("file %s exceeds %d bytes", file.Name, MaxFileSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRoundTrip проверяет запись и чтение архива в обоих форматах.
+func TestRoundTrip(t *testing.T) {
+	fetchedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	first, second := []byte("first image"), []byte("second image")
+
+	for _, format := range []string{FormatTar, FormatZip} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, format)
+			if err != nil {
+				t.Fatalf("Failed to create writer: %v", err)
+			}
+			entries := []struct {
+				link string
+				data []byte
+			}{
+				{"https://youtu.be/aaa", first},
+				{"https://www.youtube.com/watch?v=aaa", first},
+				{"https://youtu.be/bbb", second},
+			}
+			for _, e := range entries {
+				if err := writer.Add(Entry{Link: e.link, Quality: "maxresdefault", FetchedAt: fetchedAt}, e.data); err != nil {
+					t.Fatalf("Failed to add entry: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Failed to close writer: %v", err)
+			}
+
+			reader, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), format)
+			if err != nil {
+				t.Fatalf("Failed to open archive: %v", err)
+			}
+			manifest := reader.Manifest()
+			if len(manifest.Entries) != 3 {
+				t.Fatalf("Expected 3 manifest entries, got %d", len(manifest.Entries))
+			}
+			entry := manifest.Entries[0]
+			if entry.ContentHash != ContentHash(first) || entry.ETag != ETag(entry.ContentHash) || entry.Size != int64(len(first)) {
+				t.Errorf("Unexpected manifest entry: %+v", entry)
+			}
+			if !entry.FetchedAt.Equal(fetchedAt) || entry.Quality != "maxresdefault" {
+				t.Errorf("Expected metadata to survive the round trip, got %+v", entry)
+			}
+			if manifest.Entries[1].File != entry.File {
+				t.Errorf("Expected identical content to share a file")
+			}
+
+			files := map[string][]byte{}
+			if err := reader.Walk(func(name string, data []byte) error {
+				files[name] = data
+				return nil
+			}); err != nil {
+				t.Fatalf("Failed to walk archive: %v", err)
+			}
+			if len(files) != 2 {
+				t.Fatalf("Expected 2 image files, got %d", len(files))
+			}
+			for _, entry := range manifest.Entries {
+				if err := Verify(entry, files[entry.File]); err != nil {
+					t.Errorf("Expected entry to verify: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// TestVerifyMismatch проверяет обнаружение повреждённого содержимого.
+func TestVerifyMismatch(t *testing.T) {
+	data := []byte("image")
+	entry := Entry{Link: "https://youtu.be/aaa", ContentHash: ContentHash(data), Size: int64(len(data))}
+	if err := Verify(entry, []byte("imagf")); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("Expected hash mismatch, got %v", err)
+	}
+	if err := Verify(entry, []byte("img")); err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Errorf("Expected size mismatch, got %v", err)
+	}
+}
+
+// TestReaderRejectsInvalidArchives проверяет отказ при отсутствии манифеста и неизвестном формате.
+func TestReaderRejectsInvalidArchives(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, "rar"); err == nil {
+		t.Errorf("Expected unsupported format to be rejected")
+	}
+	if _, err := NewReader(bytes.NewReader(nil), 0, FormatTar); err == nil || !strings.Contains(err.Error(), ManifestName) {
+		t.Errorf("Expected missing manifest error, got %v", err)
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("not a zip")), 9, FormatZip); err == nil {
+		t.Errorf("Expected invalid zip to be rejected")
+	}
+}
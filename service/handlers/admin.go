@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"shelon_server/archive"
+	database "shelon_server/integrations/SQLLite"
+	pb "shelon_server/proto"
+	"shelon_server/usecase"
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ограничения административных запросов.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	maxAdminLinks   = 500
+
+	archiveChunkSize = 64 << 10 // Размер части архива в потоке ExportCache
+	maxImportBytes   = 2 << 30  // Максимальный размер загружаемого архива
+)
+
+// AdminHandler структура для обработки запросов администрирования кэша.
+type AdminHandler struct {
+	logger logger.Logger
+	Admin  usecase.AdminUsecase
+	// SignedURLs задает выдачу подписанных ссылок HTTP-шлюза (nil — выдача отключена).
+	SignedURLs *SignedURLs
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler с предоставленными зависимостями.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// admin: экземпляр бизнес-логики администрирования.
+func NewAdminHandler(logger logger.Logger, admin usecase.AdminUsecase) *AdminHandler {
+	return &AdminHandler{
+		logger: logger,
+		Admin:  admin,
+	}
+}
+
+// HandleListEntries обрабатывает запрос на постраничный просмотр записей кэша.
+// ctx: контекст выполнения.
+// req: запрос на просмотр записей в формате proto.
+// Возвращает страницу записей и токен следующей страницы.
+func (ah *AdminHandler) HandleListEntries(ctx context.Context, req *pb.ListEntriesRequest) (*pb.ListEntriesResponse, error) {
+	ah.logger.Info("Received ListEntries request", zap.String("videoIDPrefix", req.VideoIdPrefix), zap.Int32("pageSize", req.PageSize))
+	if req.PageSize < 0 || req.PageSize > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", maxPageSize)
+	}
+	if req.OlderThanSeconds < 0 || req.NewerThanSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "age filters must not be negative")
+	}
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+
+	filter := database.EntryFilter{VideoIDPrefix: req.VideoIdPrefix}
+	now := time.Now()
+	if req.OlderThanSeconds > 0 {
+		filter.FetchedBefore = now.Add(-time.Duration(req.OlderThanSeconds) * time.Second)
+	}
+	if req.NewerThanSeconds > 0 {
+		filter.FetchedAfter = now.Add(-time.Duration(req.NewerThanSeconds) * time.Second)
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	entries, err := ah.Admin.ListEntries(filter, after, pageSize+1)
+	if err != nil {
+		ah.logger.Error("Failed to list cache entries", zap.Error(err))
+		return nil, fmt.Errorf("failed to list cache entries: %w", err)
+	}
+	resp := &pb.ListEntriesResponse{}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		resp.NextPageToken = encodePageToken(entries[pageSize-1].URL)
+	}
+	resp.Entries = make([]*pb.CacheEntry, 0, len(entries))
+	for i := range entries {
+		resp.Entries = append(resp.Entries, toCacheEntry(&entries[i]))
+	}
+	return resp, nil
+}
+
+// HandleGetEntry обрабатывает запрос на получение метаданных записи кэша.
+// ctx: контекст выполнения.
+// req: запрос записи в формате proto.
+// Возвращает метаданные записи или ошибку NotFound.
+func (ah *AdminHandler) HandleGetEntry(ctx context.Context, req *pb.GetEntryRequest) (*pb.GetEntryResponse, error) {
+	ah.logger.Info("Received GetEntry request", zap.String("link", req.Link))
+	if req.Link == "" {
+		return nil, status.Error(codes.InvalidArgument, "link is required")
+	}
+	entry, err := ah.Admin.GetEntry(req.Link)
+	if err != nil {
+		ah.logger.Error("Failed to get cache entry", zap.Error(err))
+		return nil, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+	if entry == nil {
+		return nil, status.Errorf(codes.NotFound, "entry %q is not cached", req.Link)
+	}
+	return &pb.GetEntryResponse{Entry: toCacheEntry(entry)}, nil
+}
+
+// HandlePurgeEntries обрабатывает запрос на удаление записей кэша.
+// Запрос без условий отклоняется, если не указан флаг all.
+// ctx: контекст выполнения.
+// req: запрос на удаление в формате proto.
+// Возвращает количество и ссылки удалённых записей.
+func (ah *AdminHandler) HandlePurgeEntries(ctx context.Context, req *pb.PurgeEntriesRequest) (*pb.PurgeEntriesResponse, error) {
+	ah.logger.Info("Received PurgeEntries request", zap.Int("links", len(req.Links)), zap.Bool("all", req.All))
+	filter, err := adminFilter(req.Links, req.OlderThanSeconds, req.VideoIdPrefix)
+	if err != nil {
+		return nil, err
+	}
+	hasConditions := len(filter.URLs) > 0 || filter.VideoIDPrefix != "" || !filter.FetchedBefore.IsZero()
+	if hasConditions == req.All {
+		return nil, status.Error(codes.InvalidArgument, "specify either purge conditions or all, but not both")
+	}
+
+	purged, err := ah.Admin.PurgeEntries(filter)
+	if err != nil {
+		ah.logger.Error("Failed to purge cache entries", zap.Int("purged", len(purged)), zap.Error(err))
+		return nil, fmt.Errorf("failed to purge cache entries after %d entries: %w", len(purged), err)
+	}
+	return &pb.PurgeEntriesResponse{Purged: int32(len(purged)), Links: purged}, nil
+}
+
+// HandleRefreshEntries обрабатывает запрос на повторную загрузку обложек.
+// ctx: контекст выполнения.
+// req: запрос на обновление в формате proto.
+// Возвращает результаты по каждой ссылке и токен следующей порции записей.
+func (ah *AdminHandler) HandleRefreshEntries(ctx context.Context, req *pb.RefreshEntriesRequest) (*pb.RefreshEntriesResponse, error) {
+	ah.logger.Info("Received RefreshEntries request", zap.Int("links", len(req.Links)))
+	filter, err := adminFilter(req.Links, req.OlderThanSeconds, req.VideoIdPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(filter.URLs) == 0 && filter.VideoIDPrefix == "" && filter.FetchedBefore.IsZero() {
+		return nil, status.Error(codes.InvalidArgument, "links, older_than_seconds or video_id_prefix is required")
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+
+	results, next, err := ah.Admin.RefreshEntries(ctx, filter, after)
+	if errors.Is(err, usecase.ErrTooManyLinks) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		ah.logger.Error("Failed to refresh cache entries", zap.Error(err))
+		return nil, fmt.Errorf("failed to refresh cache entries: %w", err)
+	}
+	linkResults := make([]*pb.LinkResult, 0, len(results))
+	for _, result := range results {
+		linkResult := &pb.LinkResult{Link: result.Link, Status: "ok"}
+		if result.Err != nil {
+			linkResult.Status = "error"
+			linkResult.Error = result.Err.Error()
+		} else {
+			linkResult.Phash = result.PHash
+			linkResult.DominantColor = result.DominantColor
+			linkResult.Blurhash = result.BlurHash
+			linkResult.ContentHash = result.ContentHash
+		}
+		linkResults = append(linkResults, linkResult)
+	}
+	resp := &pb.RefreshEntriesResponse{Results: linkResults}
+	if next != "" {
+		resp.NextPageToken = encodePageToken(next)
+	}
+	return resp, nil
+}
+
+// HandleExportCache обрабатывает запрос на выгрузку кэша в архив. Архив формируется на лету
+// и отправляется клиенту частями по archiveChunkSize байт.
+// req: запрос на выгрузку в формате proto.
+// stream: поток ответа.
+func (ah *AdminHandler) HandleExportCache(req *pb.ExportCacheRequest, stream pb.AdminService_ExportCacheServer) error {
+	ah.logger.Info("Received ExportCache request", zap.String("format", req.Format), zap.Int("links", len(req.Links)))
+	format := req.Format
+	if format == "" {
+		format = archive.FormatTar
+	}
+	if format != archive.FormatTar && format != archive.FormatZip {
+		return status.Errorf(codes.InvalidArgument, "unsupported format %q", req.Format)
+	}
+	filter, err := adminFilter(req.Links, req.OlderThanSeconds, req.VideoIdPrefix)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriterSize(chunkWriter{stream: stream}, archiveChunkSize)
+	exported, err := ah.Admin.ExportCache(out, format, filter)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		ah.logger.Error("Failed to export cache", zap.Int("exported", exported), zap.Error(err))
+		return fmt.Errorf("failed to export cache: %w", err)
+	}
+	ah.logger.Info("Cache export sent", zap.Int("entries", exported))
+	return nil
+}
+
+// HandleImportCache обрабатывает загрузку архива в кэш. Части архива сохраняются во временный файл,
+// после чего архив проверяется и импортируется целиком.
+// stream: поток частей архива.
+func (ah *AdminHandler) HandleImportCache(stream pb.AdminService_ImportCacheServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "archive is empty")
+	} else if err != nil {
+		return err
+	}
+	ah.logger.Info("Received ImportCache request", zap.String("format", first.Format), zap.String("conflictPolicy", first.ConflictPolicy))
+	if first.Format != archive.FormatTar && first.Format != archive.FormatZip {
+		return status.Errorf(codes.InvalidArgument, "unsupported format %q", first.Format)
+	}
+	policy := first.ConflictPolicy
+	if policy == "" {
+		policy = usecase.ConflictSkip
+	}
+	if policy != usecase.ConflictSkip && policy != usecase.ConflictOverwrite {
+		return status.Errorf(codes.InvalidArgument, "unsupported conflict_policy %q", first.ConflictPolicy)
+	}
+
+	file, err := os.CreateTemp("", "cache-import-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var size int64
+	req := first
+	for {
+		size += int64(len(req.Data))
+		if size > maxImportBytes {
+			return status.Errorf(codes.ResourceExhausted, "archive exceeds %d bytes", int64(maxImportBytes))
+		}
+		if _, err := file.Write(req.Data); err != nil {
+			return fmt.Errorf("failed to buffer archive: %w", err)
+		}
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	results, err := ah.Admin.ImportCache(file, size, first.Format, policy)
+	if err != nil {
+		ah.logger.Error("Failed to import cache", zap.Error(err))
+		return fmt.Errorf("failed to import cache: %w", err)
+	}
+	resp := &pb.ImportCacheResponse{Results: make([]*pb.LinkResult, 0, len(results))}
+	for _, result := range results {
+		linkResult := &pb.LinkResult{Link: result.Link, Status: result.Status}
+		switch result.Status {
+		case usecase.ImportStatusImported:
+			resp.Imported++
+		case usecase.ImportStatusSkipped:
+			resp.Skipped++
+		default:
+			resp.Failed++
+			linkResult.Error = result.Err.Error()
+		}
+		resp.Results = append(resp.Results, linkResult)
+	}
+	ah.logger.Info("Cache imported", zap.Int32("imported", resp.Imported), zap.Int32("skipped", resp.Skipped), zap.Int32("failed", resp.Failed))
+	return stream.SendAndClose(resp)
+}
+
+// chunkWriter отправляет записанные данные клиенту частями архива.
+type chunkWriter struct {
+	stream pb.AdminService_ExportCacheServer
+}
+
+// Write отправляет p одной частью архива.
+func (cw chunkWriter) Write(p []byte) (int, error) {
+	// Буфер переиспользуется вызывающей стороной, поэтому данные копируются перед отправкой
+	data := append([]byte(nil), p...)
+	if err := cw.stream.Send(&pb.ArchiveChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// adminFilter проверяет общие условия запросов PurgeEntries и RefreshEntries и собирает фильтр.
+func adminFilter(links []string, olderThanSeconds int64, videoIDPrefix string) (database.EntryFilter, error) {
+	if len(links) > maxAdminLinks {
+		return database.EntryFilter{}, status.Errorf(codes.InvalidArgument, "at most %d links are allowed", maxAdminLinks)
+	}
+	if olderThanSeconds < 0 {
+		return database.EntryFilter{}, status.Error(codes.InvalidArgument, "older_than_seconds must not be negative")
+	}
+	filter := database.EntryFilter{URLs: links, VideoIDPrefix: videoIDPrefix}
+	if olderThanSeconds > 0 {
+		filter.FetchedBefore = time.Now().Add(-time.Duration(olderThanSeconds) * time.Second)
+	}
+	return filter, nil
+}
+
+// toCacheEntry преобразует запись кэша в формат proto.
+func toCacheEntry(entry *database.Entry) *pb.CacheEntry {
+	cacheEntry := &pb.CacheEntry{
+		Link:          entry.URL,
+		VideoId:       entry.VideoID,
+		ContentHash:   entry.ContentHash,
+		Size:          entry.Size,
+		Phash:         entry.PHash,
+		DominantColor: entry.DominantColor,
+		Blurhash:      entry.BlurHash,
+		Variants:      int32(entry.Variants),
+	}
+	if !entry.FetchedAt.IsZero() {
+		cacheEntry.FetchedAt = entry.FetchedAt.Unix()
+	}
+	return cacheEntry
+}
+
+// encodePageToken кодирует ссылку последней записи страницы в непрозрачный токен.
+func encodePageToken(lastLink string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastLink))
+}
+
+// decodePageToken восстанавливает ссылку последней записи предыдущей страницы из токена.
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+/*
+NewAdminHandler создает новый экземпляр AdminHandler с предоставленными зависимостями.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+admin: экземпляр бизнес-логики администрирования.
+
+HandleListEntries обрабатывает запрос на постраничный просмотр записей кэша.
+ctx: контекст выполнения.
+req: запрос на просмотр записей в формате proto.
+Возвращает страницу записей и токен следующей страницы.
+
+HandleGetEntry обрабатывает запрос на получение метаданных записи кэша.
+ctx: контекст выполнения.
+req: запрос записи в формате proto.
+Возвращает метаданные записи или ошибку NotFound.
+
+HandlePurgeEntries обрабатывает запрос на удаление записей кэша.
+ctx: контекст выполнения.
+req: запрос на удаление в формате proto.
+Возвращает количество и ссылки удалённых записей.
+
+HandleRefreshEntries обрабатывает запрос на повторную загрузку обложек.
+ctx: контекст выполнения.
+req: запрос на обновление в формате proto.
+Возвращает результаты по каждой ссылке и токен следующей порции записей.
+
+HandleExportCache обрабатывает запрос на выгрузку кэша в архив.
+req: запрос на выгрузку в формате proto.
+stream: поток ответа, в который архив отправляется частями.
+
+HandleImportCache обрабатывает загрузку архива в кэш.
+stream: поток частей архива.
+
+chunkWriter отправляет записанные данные клиенту частями архива.
+
+adminFilter проверяет общие условия запросов PurgeEntries и RefreshEntries и собирает фильтр.
+
+toCacheEntry преобразует запись кэша в формат proto.
+
+encodePageToken кодирует ссылку последней записи страницы в непрозрачный токен.
+
+decodePageToken восстанавливает ссылку последней записи предыдущей страницы из токена.
+*/
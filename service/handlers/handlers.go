@@ -2,15 +2,26 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"shelon_server/imaging"
+	database "shelon_server/integrations/SQLLite"
 	pb "shelon_server/proto"
 	"shelon_server/usecase"
 	"shelon_server/utilss/logger"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// maxDeadlineReserve ограничивает время, которое HandleSendData оставляет до срока вызова
+// на формирование и передачу ответа: ответ, отправленный после срока, клиент уже не получит.
+const maxDeadlineReserve = 200 * time.Millisecond
+
 // DataHandler структура для обработки данных.
 type DataHandler struct {
 	logger        logger.Logger
@@ -30,6 +41,8 @@ func NewDataHandler(logger logger.Logger, businessLogic usecase.DataProcessorUse
 // HandleSendData обрабатывает запрос на отправку данных.
 // ctx: контекст выполнения.
 // req: запрос на отправку данных в формате proto.
+// Если у вызова есть срок, ссылки, не обработанные незадолго до него, возвращаются со статусом
+// "deadline_exceeded" вместе с готовыми результатами, а не ошибкой всего вызова.
 // Возвращает ответ на отправку данных в формате proto и ошибку, если она возникла.
 func (dh *DataHandler) HandleSendData(ctx context.Context, req *pb.SendDataRequest) (*pb.SendDataResponse, error) {
 	// Логируем входные данные
@@ -38,24 +51,268 @@ func (dh *DataHandler) HandleSendData(ctx context.Context, req *pb.SendDataReque
 	dh.logger.Info("Links", zap.Strings("links", req.Links))
 
 	// Вызываем бизнес-логику
-	result, err := dh.BusinessLogic.ProcessData(req.Flag, req.Links)
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-min(time.Until(deadline)/10, maxDeadlineReserve)))
+		defer cancel()
+	}
+	options := usecase.ProcessOptions{Priority: req.Priority, Background: req.ContinueInBackground}
+	results, err := dh.BusinessLogic.ProcessData(ctx, req.Flag, req.Links, options)
+	if errors.Is(err, usecase.ErrUnknownPriority) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		dh.logger.Error("Failed to process data", zap.Error(err))
 		return nil, fmt.Errorf("failed to process data: %w", err)
 	}
 
 	// Конвертируем результат в формат, который клиент сможет обработать
-	images := append([][]byte(nil), result...)
+	images := make([][]byte, 0, len(results))
+	linkResults := make([]*pb.LinkResult, 0, len(results))
+	responseStatus := "success"
+	for _, result := range results {
+		linkResult := &pb.LinkResult{Link: result.Link}
+		if errors.Is(result.Err, context.DeadlineExceeded) {
+			linkResult.Status = "deadline_exceeded"
+			linkResult.Error = "DEADLINE_EXCEEDED: link was not processed before the call deadline"
+			if req.ContinueInBackground {
+				linkResult.Error += ", fetching continues in the background"
+			}
+			responseStatus = "partial"
+		} else if result.Err != nil {
+			linkResult.Status = "error"
+			linkResult.Error = result.Err.Error()
+		} else {
+			linkResult.Status = "ok"
+			linkResult.Phash = result.PHash
+			linkResult.DominantColor = result.DominantColor
+			linkResult.Blurhash = result.BlurHash
+			linkResult.ContentHash = result.ContentHash
+			// Изображение, которое уже есть у клиента для этой же ссылки, повторно не передаётся
+			if known, ok := req.KnownHashes[result.Link]; ok && known == result.ContentHash {
+				linkResult.Status = "not_modified"
+			} else {
+				images = append(images, result.Photo)
+			}
+		}
+		linkResults = append(linkResults, linkResult)
+	}
 
 	// Формируем успешный ответ с массивом картинок
 	dh.logger.Info("Forming successful response with image array")
 
 	return &pb.SendDataResponse{
-		Status: "success",
-		Images: images,
+		Status:  responseStatus,
+		Images:  images,
+		Results: linkResults,
+	}, nil
+}
+
+// HandleFindSimilar обрабатывает запрос на поиск похожих обложек.
+// ctx: контекст выполнения.
+// req: запрос на поиск в формате proto.
+// Возвращает найденные обложки в формате proto и ошибку, если она возникла.
+func (dh *DataHandler) HandleFindSimilar(ctx context.Context, req *pb.FindSimilarRequest) (*pb.FindSimilarResponse, error) {
+	dh.logger.Info("Received FindSimilar request", zap.String("link", req.Link), zap.Int32("maxDistance", req.MaxDistance))
+	if req.MaxDistance < 0 || req.MaxDistance > 64 {
+		return nil, status.Error(codes.InvalidArgument, "max_distance must be between 0 and 64")
+	}
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	similar, err := dh.BusinessLogic.FindSimilar(req.Link, req.Phash, int(req.MaxDistance), int(req.Limit))
+	if err != nil {
+		dh.logger.Error("Failed to find similar thumbnails", zap.Error(err))
+		return nil, fmt.Errorf("failed to find similar thumbnails: %w", err)
+	}
+
+	videos := make([]*pb.SimilarVideo, 0, len(similar))
+	for _, item := range similar {
+		videos = append(videos, &pb.SimilarVideo{
+			Link:          item.URL,
+			Phash:         item.PHash,
+			DominantColor: item.DominantColor,
+			Distance:      int32(item.Distance),
+		})
+	}
+	return &pb.FindSimilarResponse{Videos: videos}, nil
+}
+
+// HandleGetPlaceholders обрабатывает запрос на получение заглушек обложек.
+// ctx: контекст выполнения.
+// req: запрос на получение заглушек в формате proto.
+// Возвращает заглушки в порядке запроса и ошибку, если она возникла.
+func (dh *DataHandler) HandleGetPlaceholders(ctx context.Context, req *pb.GetPlaceholdersRequest) (*pb.GetPlaceholdersResponse, error) {
+	dh.logger.Info("Received GetPlaceholders request", zap.Int("links", len(req.Links)))
+
+	placeholders, err := dh.BusinessLogic.GetPlaceholders(req.Links)
+	if err != nil {
+		dh.logger.Error("Failed to load placeholders", zap.Error(err))
+		return nil, fmt.Errorf("failed to load placeholders: %w", err)
+	}
+
+	byLink := make(map[string]database.Placeholder, len(placeholders))
+	for _, placeholder := range placeholders {
+		byLink[placeholder.URL] = placeholder
+	}
+
+	result := make([]*pb.Placeholder, 0, len(req.Links))
+	for _, link := range req.Links {
+		placeholder, ok := byLink[link]
+		item := &pb.Placeholder{Link: link, Found: ok}
+		if ok {
+			item.Blurhash = placeholder.BlurHash
+			item.DominantColor = placeholder.DominantColor
+			if req.IncludePreview {
+				item.Preview = placeholder.Preview
+			}
+		}
+		result = append(result, item)
+	}
+	return &pb.GetPlaceholdersResponse{Placeholders: result}, nil
+}
+
+// Ограничения параметров контактного листа.
+const (
+	maxContactSheetLinks = 200
+	maxContactSheetTile  = 1280
+	maxContactSheetPad   = 256
+	// maxContactSheetPixels ограничивает площадь мозаики: она целиком собирается в памяти (4 байта на пиксель).
+	maxContactSheetPixels = 40_000_000
+)
+
+// tileCoordinates описывает координаты плитки контактного листа в JSON-карте.
+type tileCoordinates struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// HandleBuildContactSheet обрабатывает запрос на сборку контактного листа.
+// ctx: контекст выполнения.
+// req: запрос на сборку контактного листа в формате proto.
+// Возвращает изображение мозаики с картой координат плиток и ошибку, если она возникла.
+func (dh *DataHandler) HandleBuildContactSheet(ctx context.Context, req *pb.ContactSheetRequest) (*pb.ContactSheetResponse, error) {
+	dh.logger.Info("Received BuildContactSheet request", zap.Int("links", len(req.Links)), zap.Int32("columns", req.Columns))
+	switch {
+	case len(req.Links) == 0:
+		return nil, status.Error(codes.InvalidArgument, "links must not be empty")
+	case len(req.Links) > maxContactSheetLinks:
+		return nil, status.Errorf(codes.InvalidArgument, "too many links: at most %d allowed", maxContactSheetLinks)
+	case req.Columns <= 0:
+		return nil, status.Error(codes.InvalidArgument, "columns must be positive")
+	case req.TileWidth <= 0 || req.TileWidth > maxContactSheetTile || req.TileHeight <= 0 || req.TileHeight > maxContactSheetTile:
+		return nil, status.Errorf(codes.InvalidArgument, "tile size must be between 1 and %d", maxContactSheetTile)
+	case req.Padding < 0 || req.Padding > maxContactSheetPad:
+		return nil, status.Errorf(codes.InvalidArgument, "padding must be between 0 and %d", maxContactSheetPad)
+	}
+	layout := imaging.GridLayout{
+		Columns:    int(req.Columns),
+		TileWidth:  int(req.TileWidth),
+		TileHeight: int(req.TileHeight),
+		Padding:    int(req.Padding),
+	}
+	// Площадь оценивается по всем ссылкам: недоступные обложки лишь уменьшают мозаику
+	if width, height := layout.Size(len(req.Links)); width*height > maxContactSheetPixels {
+		return nil, status.Errorf(codes.InvalidArgument, "contact sheet of %dx%d pixels exceeds the limit of %d pixels", width, height, maxContactSheetPixels)
+	}
+	format := req.Format
+	if format == "" {
+		format = imaging.FormatJPEG
+	}
+	if format != imaging.FormatJPEG && format != imaging.FormatPNG {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported format %q", req.Format)
+	}
+
+	sheet, err := dh.BusinessLogic.BuildContactSheet(req.Links, usecase.ContactSheetOptions{
+		Layout:     layout,
+		Format:     format,
+		Background: req.Background,
+	})
+	if errors.Is(err, usecase.ErrDuplicateVideo) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		dh.logger.Error("Failed to build contact sheet", zap.Error(err))
+		return nil, fmt.Errorf("failed to build contact sheet: %w", err)
+	}
+
+	tiles := make(map[string]tileCoordinates, len(sheet.Tiles))
+	for videoID, rect := range sheet.Tiles {
+		tiles[videoID] = tileCoordinates{X: rect.Min.X, Y: rect.Min.Y, Width: rect.Dx(), Height: rect.Dy()}
+	}
+	tilesJSON, err := json.Marshal(tiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tile map: %w", err)
+	}
+
+	return &pb.ContactSheetResponse{
+		Image:       sheet.Image,
+		ContentType: "image/" + format,
+		Width:       int32(sheet.Width),
+		Height:      int32(sheet.Height),
+		TilesJson:   string(tilesJSON),
+		FailedLinks: sheet.Failed,
 	}, nil
 }
 
+// Ограничения запроса статистики кэша.
+const (
+	defaultTopVideos = 10
+	maxTopVideos     = 100
+)
+
+// HandleGetCacheStats обрабатывает запрос на получение статистики кэша.
+// ctx: контекст выполнения.
+// req: запрос статистики в формате proto.
+// Возвращает статистику кэша и счётчики обращений и ошибку, если она возникла.
+func (dh *DataHandler) HandleGetCacheStats(ctx context.Context, req *pb.CacheStatsRequest) (*pb.CacheStatsResponse, error) {
+	dh.logger.Info("Received GetCacheStats request", zap.Int32("topN", req.TopN))
+	if req.TopN < 0 || req.TopN > maxTopVideos {
+		return nil, status.Errorf(codes.InvalidArgument, "top_n must be between 0 and %d", maxTopVideos)
+	}
+	topN := int(req.TopN)
+	if topN == 0 {
+		topN = defaultTopVideos
+	}
+
+	stats, err := dh.BusinessLogic.GetCacheStats(topN)
+	if err != nil {
+		dh.logger.Error("Failed to get cache stats", zap.Error(err))
+		return nil, fmt.Errorf("failed to get cache stats: %w", err)
+	}
+
+	resp := &pb.CacheStatsResponse{
+		Entries:       int32(stats.Entries),
+		TotalBytes:    stats.OriginalBytes + stats.VariantBytes,
+		OriginalBytes: stats.OriginalBytes,
+		VariantBytes:  stats.VariantBytes,
+		OldestLink:    stats.OldestURL,
+		NewestLink:    stats.NewestURL,
+		Hits:          stats.Hits,
+		Misses:        stats.Misses,
+		Errors:        stats.Errors,
+		TopVideos:     make([]*pb.VideoRequests, 0, len(stats.TopVideos)),
+	}
+	if !stats.OldestFetchedAt.IsZero() {
+		resp.OldestFetchedAt = stats.OldestFetchedAt.Unix()
+	}
+	if !stats.NewestFetchedAt.IsZero() {
+		resp.NewestFetchedAt = stats.NewestFetchedAt.Unix()
+	}
+	for _, video := range stats.TopVideos {
+		resp.TopVideos = append(resp.TopVideos, &pb.VideoRequests{VideoId: video.VideoID, Requests: video.Requests})
+	}
+	for _, tier := range []string{database.TierMemory, database.TierDatabase} {
+		if counters, ok := stats.Tiers[tier]; ok {
+			resp.Tiers = append(resp.Tiers, &pb.CacheTierStats{Tier: tier, Hits: counters.Hits, Misses: counters.Misses})
+		}
+	}
+	return resp, nil
+}
+
 /*
 NewDataHandler создает новый экземпляр DataHandler с предоставленными зависимостями.
 logger: экземпляр интерфейса logger.Logger для логирования действий.
@@ -65,4 +322,24 @@ HandleSendData обрабатывает запрос на отправку да
 ctx: контекст выполнения.
 req: запрос на отправку данных в формате proto.
 Возвращает ответ на отправку данных в формате proto и ошибку, если она возникла.
+
+HandleFindSimilar обрабатывает запрос на поиск похожих обложек.
+ctx: контекст выполнения.
+req: запрос на поиск в формате proto.
+Возвращает найденные обложки в формате proto и ошибку, если она возникла.
+
+HandleGetPlaceholders обрабатывает запрос на получение заглушек обложек.
+ctx: контекст выполнения.
+req: запрос на получение заглушек в формате proto.
+Возвращает заглушки в порядке запроса и ошибку, если она возникла.
+
+HandleBuildContactSheet обрабатывает запрос на сборку контактного листа.
+ctx: контекст выполнения.
+req: запрос на сборку контактного листа в формате proto.
+Возвращает изображение мозаики с картой координат плиток и ошибку, если она возникла.
+
+HandleGetCacheStats обрабатывает запрос на получение статистики кэша.
+ctx: контекст выполнения.
+req: запрос статистики в формате proto.
+Возвращает статистику кэша и счётчики обращений и ошибку, если она возникла.
 */
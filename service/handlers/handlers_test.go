@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+	pb "shelon_server/proto"
+	"shelon_server/usecase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDataProcessor возвращает заданные результаты и запоминает срок контекста ProcessData.
+type fakeDataProcessor struct {
+	usecase.DataProcessorUsecase
+	results     []usecase.LinkResult
+	deadline    time.Time
+	hasDeadline bool
+	stats       usecase.CacheStats
+}
+
+func (f *fakeDataProcessor) ProcessData(ctx context.Context, flag bool, links []string, options usecase.ProcessOptions) ([]usecase.LinkResult, error) {
+	f.deadline, f.hasDeadline = ctx.Deadline()
+	return f.results, nil
+}
+
+func (f *fakeDataProcessor) GetCacheStats(topN int) (*usecase.CacheStats, error) {
+	return &f.stats, nil
+}
+
+// TestHandleSendDataDeadline проверяет, что ссылки, не обработанные к сроку, возвращаются
+// со статусом "deadline_exceeded" вместе с готовыми результатами, а ответ — со статусом "partial".
+func TestHandleSendDataDeadline(t *testing.T) {
+	processor := &fakeDataProcessor{results: []usecase.LinkResult{
+		{Link: "https://youtu.be/ok", Photo: []byte("image"), ContentHash: "abc"},
+		{Link: "https://youtu.be/late", Err: context.DeadlineExceeded},
+		{Link: "https://youtu.be/broken", Err: errors.New("thumbnail not found")},
+	}}
+	handler := NewDataHandler(&MockLogger{}, processor)
+
+	for _, background := range []bool{false, true} {
+		resp, err := handler.HandleSendData(context.Background(), &pb.SendDataRequest{ContinueInBackground: background})
+		if err != nil {
+			t.Fatalf("Expected partial response, got error %v", err)
+		}
+		if resp.Status != "partial" || len(resp.Images) != 1 || len(resp.Results) != 3 {
+			t.Fatalf("Unexpected response: %v", resp)
+		}
+		statuses := []string{resp.Results[0].Status, resp.Results[1].Status, resp.Results[2].Status}
+		if strings.Join(statuses, " ") != "ok deadline_exceeded error" {
+			t.Errorf("Unexpected link statuses %v", statuses)
+		}
+		late := resp.Results[1].Error
+		if !strings.HasPrefix(late, "DEADLINE_EXCEEDED") || strings.Contains(late, "background") != background {
+			t.Errorf("Unexpected deadline error %q for background %t", late, background)
+		}
+	}
+
+	processor.results = processor.results[:1]
+	if resp, err := handler.HandleSendData(context.Background(), &pb.SendDataRequest{}); err != nil || resp.Status != "success" {
+		t.Errorf("Expected success without deadline markers, got %v (%v)", resp, err)
+	}
+}
+
+// TestHandleSendDataDeadlineReserve проверяет, что обработка получает срок раньше срока вызова:
+// на десятую часть оставшегося времени, но не больше чем на maxDeadlineReserve.
+func TestHandleSendDataDeadlineReserve(t *testing.T) {
+	processor := &fakeDataProcessor{}
+	handler := NewDataHandler(&MockLogger{}, processor)
+
+	if _, err := handler.HandleSendData(context.Background(), &pb.SendDataRequest{}); err != nil {
+		t.Fatalf("Failed to handle request: %v", err)
+	}
+	if processor.hasDeadline {
+		t.Errorf("Expected no deadline for a call without deadline, got %v", processor.deadline)
+	}
+
+	tests := map[time.Duration]time.Duration{
+		10 * time.Second:       maxDeadlineReserve,
+		1 * time.Second:        100 * time.Millisecond,
+		500 * time.Millisecond: 50 * time.Millisecond,
+	}
+	for timeout, reserve := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		callDeadline, _ := ctx.Deadline()
+		_, err := handler.HandleSendData(ctx, &pb.SendDataRequest{})
+		cancel()
+		if err != nil {
+			t.Fatalf("Failed to handle request: %v", err)
+		}
+		// Оставшееся время измеряется чуть позже создания контекста, поэтому запас может быть немного меньше
+		got := callDeadline.Sub(processor.deadline)
+		if !processor.hasDeadline || got > reserve || got < reserve-10*time.Millisecond {
+			t.Errorf("Timeout %v: expected reserve of %v, got %v", timeout, reserve, got)
+		}
+	}
+}
+
+// TestHandleGetCacheStatsTiers проверяет, что попадания и промахи уровней кэша передаются
+// в порядке memory, database, а без кэша в памяти уровни не выводятся.
+func TestHandleGetCacheStatsTiers(t *testing.T) {
+	processor := &fakeDataProcessor{stats: usecase.CacheStats{Hits: 3, Tiers: map[string]database.TierStats{
+		database.TierDatabase: {Hits: 1, Misses: 2},
+		database.TierMemory:   {Hits: 3, Misses: 3},
+	}}}
+	handler := NewDataHandler(&MockLogger{}, processor)
+	resp, err := handler.HandleGetCacheStats(context.Background(), &pb.CacheStatsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to get cache stats: %v", err)
+	}
+	tiers := resp.GetTiers()
+	if len(tiers) != 2 || tiers[0].Tier != database.TierMemory || tiers[0].Hits != 3 || tiers[1].Tier != database.TierDatabase || tiers[1].Misses != 2 {
+		t.Errorf("Unexpected tiers: %v", tiers)
+	}
+
+	processor.stats.Tiers = nil
+	if resp, err := handler.HandleGetCacheStats(context.Background(), &pb.CacheStatsRequest{}); err != nil || len(resp.GetTiers()) != 0 {
+		t.Errorf("Expected no tiers without the memory cache, got %v (%v)", resp.GetTiers(), err)
+	}
+}
+
+// fakeAdmin — административный сценарий, отклоняющий обновление слишком длинного списка ссылок.
+type fakeAdmin struct {
+	usecase.AdminUsecase
+}
+
+func (f *fakeAdmin) RefreshEntries(ctx context.Context, filter database.EntryFilter, after string) ([]usecase.LinkResult, string, error) {
+	return nil, "", usecase.ErrTooManyLinks
+}
+
+// TestHandleRefreshEntriesTooManyLinks проверяет, что слишком длинный список ссылок
+// отклоняется с InvalidArgument.
+func TestHandleRefreshEntriesTooManyLinks(t *testing.T) {
+	handler := NewAdminHandler(&MockLogger{}, &fakeAdmin{})
+	_, err := handler.HandleRefreshEntries(context.Background(), &pb.RefreshEntriesRequest{Links: []string{"https://youtu.be/dQw4w9WgXcQ"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
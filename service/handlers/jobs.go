@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"shelon_server/integrations/webhook"
+	pb "shelon_server/proto"
+	"shelon_server/usecase"
+	"shelon_server/utilss/auth"
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxWarmRequestBytes ограничивает суммарный объём ссылок и файла со ссылками в одном потоке WarmCache.
+const maxWarmRequestBytes = 16 << 20
+
+// Размер страницы списка заданий.
+const (
+	defaultJobsPageSize = 50
+	maxJobsPageSize     = 500
+)
+
+// jobStates перечисляет состояния, по которым можно отфильтровать список заданий.
+var jobStates = map[string]bool{
+	usecase.JobQueued:    true,
+	usecase.JobRunning:   true,
+	usecase.JobCompleted: true,
+	usecase.JobCancelled: true,
+}
+
+// JobHandler структура для обработки запросов фоновых заданий.
+// Задание принадлежит поставившему его клиенту: остальным клиентам оно не видно и не может быть
+// отменено, если у них нет области доступа admin.
+type JobHandler struct {
+	logger logger.Logger
+	Jobs   usecase.JobUsecase
+}
+
+// NewJobHandler создает новый экземпляр JobHandler с предоставленными зависимостями.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// jobs: менеджер фоновых заданий.
+func NewJobHandler(logger logger.Logger, jobs usecase.JobUsecase) *JobHandler {
+	return &JobHandler{
+		logger: logger,
+		Jobs:   jobs,
+	}
+}
+
+// HandleWarmCache принимает ссылки и части файла со ссылками и ставит задание предварительной
+// загрузки в очередь. Ответ отправляется сразу после постановки, не дожидаясь загрузки.
+// Количество ссылок и объём потока проверяются по мере приёма: поток, превысивший
+// лимит ссылок задания или maxWarmRequestBytes, прерывается с ResourceExhausted.
+// stream: поток частей запроса.
+func (jh *JobHandler) HandleWarmCache(stream pb.JobService_WarmCacheServer) error {
+	var links []string
+	options := usecase.JobOptions{Owner: caller(stream.Context()).Client}
+	var file bytes.Buffer
+	limit := jh.Jobs.LinkLimit()
+	received := 0
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if len(links)+len(req.Links) > limit {
+			return status.Errorf(codes.ResourceExhausted, "too many links: at most %d allowed", limit)
+		}
+		for _, link := range req.Links {
+			received += len(link)
+		}
+		received += len(req.FileChunk)
+		if received > maxWarmRequestBytes {
+			return status.Errorf(codes.ResourceExhausted, "request exceeds %d bytes", maxWarmRequestBytes)
+		}
+		links = append(links, req.Links...)
+		if req.CallbackUrl != "" {
+			options.CallbackURL = req.CallbackUrl
+		}
+		if req.Priority != "" {
+			options.Priority = req.Priority
+		}
+		file.Write(req.FileChunk)
+	}
+	links = append(links, parseLinksFile(file.Bytes())...)
+	if len(links) > limit {
+		return status.Errorf(codes.ResourceExhausted, "too many links: at most %d allowed", limit)
+	}
+	jh.logger.Info("Received WarmCache request", zap.Int("links", len(links)))
+
+	job, err := jh.submit(links, options)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(&pb.WarmCacheResponse{JobId: job.ID, Total: int32(job.Total)})
+}
+
+// HandleSubmitJob ставит в очередь задание загрузки обложек по списку ссылок.
+// Ответ отправляется сразу после постановки, не дожидаясь загрузки.
+// ctx: контекст выполнения.
+// req: запрос со ссылками в формате proto.
+// Возвращает поставленное задание или ошибку.
+func (jh *JobHandler) HandleSubmitJob(ctx context.Context, req *pb.SubmitJobRequest) (*pb.SubmitJobResponse, error) {
+	jh.logger.Info("Received SubmitJob request", zap.Int("links", len(req.Links)))
+	job, err := jh.submit(req.Links, usecase.JobOptions{CallbackURL: req.CallbackUrl, Priority: req.Priority, Owner: caller(ctx).Client})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SubmitJobResponse{Job: toJob(job)}, nil
+}
+
+// HandleGetJob обрабатывает запрос состояния фонового задания.
+// ctx: контекст выполнения.
+// req: запрос состояния в формате proto.
+// Возвращает задание с прогрессом или ошибку NotFound, в том числе для чужого задания.
+func (jh *JobHandler) HandleGetJob(ctx context.Context, req *pb.GetJobRequest) (*pb.GetJobResponse, error) {
+	if req.JobId == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+	job, err := jh.ownedJob(ctx, req.JobId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetJobResponse{Job: toJob(job)}, nil
+}
+
+// HandleListJobs возвращает страницу заданий, начиная с самых новых. Клиент без области
+// доступа admin получает только свои задания.
+// ctx: контекст выполнения.
+// req: фильтр по состоянию и параметры страницы в формате proto.
+// Возвращает задания и токен следующей страницы.
+func (jh *JobHandler) HandleListJobs(ctx context.Context, req *pb.ListJobsRequest) (*pb.ListJobsResponse, error) {
+	if req.State != "" && !jobStates[req.State] {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported job state %q", req.State)
+	}
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultJobsPageSize
+	case pageSize > maxJobsPageSize:
+		pageSize = maxJobsPageSize
+	}
+
+	identity := caller(ctx)
+	owner := identity.Client
+	if identity.HasScope(auth.ScopeAdmin) {
+		owner = ""
+	}
+	jobs, nextPageToken, err := jh.Jobs.ListJobs(owner, req.State, req.PageToken, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	resp := &pb.ListJobsResponse{NextPageToken: nextPageToken}
+	for i := range jobs {
+		resp.Jobs = append(resp.Jobs, toJob(&jobs[i]))
+	}
+	return resp, nil
+}
+
+// HandleCancelJob отменяет задание из очереди или выполняющееся задание.
+// ctx: контекст выполнения.
+// req: запрос отмены в формате proto.
+// Возвращает задание, NotFound для неизвестного или чужого задания или FailedPrecondition для завершённого.
+func (jh *JobHandler) HandleCancelJob(ctx context.Context, req *pb.CancelJobRequest) (*pb.CancelJobResponse, error) {
+	if req.JobId == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+	if _, err := jh.ownedJob(ctx, req.JobId); err != nil {
+		return nil, err
+	}
+	job, err := jh.Jobs.CancelJob(req.JobId)
+	switch {
+	case errors.Is(err, usecase.ErrJobNotFound):
+		return nil, status.Errorf(codes.NotFound, "job %q not found", req.JobId)
+	case errors.Is(err, usecase.ErrJobFinished):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	jh.logger.Info("Job cancelled by request", zap.String("jobID", req.JobId))
+	return &pb.CancelJobResponse{Job: toJob(job)}, nil
+}
+
+// HandleWatchJob отправляет состояние задания при каждом изменении прогресса и завершает поток
+// вместе с заданием. Для завершённого задания отправляется одно сообщение.
+// Для неизвестного или чужого задания возвращается NotFound.
+// req: запрос наблюдения в формате proto.
+// stream: поток снимков задания.
+func (jh *JobHandler) HandleWatchJob(req *pb.WatchJobRequest, stream pb.JobService_WatchJobServer) error {
+	if req.JobId == "" {
+		return status.Error(codes.InvalidArgument, "job_id is required")
+	}
+	if _, err := jh.ownedJob(stream.Context(), req.JobId); err != nil {
+		return err
+	}
+	err := jh.Jobs.WatchJob(stream.Context(), req.JobId, func(job *usecase.Job) error {
+		return stream.Send(toJob(job))
+	})
+	switch {
+	case errors.Is(err, usecase.ErrJobNotFound):
+		return status.Errorf(codes.NotFound, "job %q not found", req.JobId)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+// ownedJob возвращает задание, если оно принадлежит клиенту вызова или у клиента есть область
+// доступа admin. Чужое задание неотличимо от несуществующего: в обоих случаях возвращается NotFound.
+func (jh *JobHandler) ownedJob(ctx context.Context, id string) (*usecase.Job, error) {
+	job, err := jh.Jobs.GetJob(id)
+	if err != nil {
+		jh.logger.Error("Failed to get job", zap.String("jobID", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	identity := caller(ctx)
+	if job == nil || (job.Owner != identity.Client && !identity.HasScope(auth.ScopeAdmin)) {
+		return nil, status.Errorf(codes.NotFound, "job %q not found", id)
+	}
+	return job, nil
+}
+
+// caller возвращает клиента вызова из контекста. Вызов без клиента в контексте считается анонимным.
+func caller(ctx context.Context) *auth.Identity {
+	if identity, ok := auth.FromContext(ctx); ok {
+		return identity
+	}
+	return &auth.Identity{Client: auth.AnonymousClient}
+}
+
+// submit ставит задание в очередь и преобразует ошибки постановки в статусы gRPC.
+func (jh *JobHandler) submit(links []string, options usecase.JobOptions) (*usecase.Job, error) {
+	job, err := jh.Jobs.SubmitJob(links, options)
+	switch {
+	case errors.Is(err, usecase.ErrNoLinks), errors.Is(err, usecase.ErrTooManyLinks), errors.Is(err, webhook.ErrInvalidURL),
+		errors.Is(err, usecase.ErrUnknownPriority):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, usecase.ErrWebhooksDisabled):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, usecase.ErrQueueFull):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		jh.logger.Error("Failed to queue job", zap.Error(err))
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+	return job, nil
+}
+
+// parseLinksFile извлекает ссылки из текстового файла: по одной в строке,
+// пустые строки и строки, начинающиеся с #, пропускаются.
+func parseLinksFile(data []byte) []string {
+	var links []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxWarmRequestBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		links = append(links, line)
+	}
+	return links
+}
+
+// toJob преобразует фоновое задание в формат proto.
+func toJob(job *usecase.Job) *pb.Job {
+	result := &pb.Job{
+		JobId:       job.ID,
+		Kind:        job.Kind,
+		State:       job.State,
+		Total:       int32(job.Total),
+		Fetched:     int32(job.Fetched),
+		Cached:      int32(job.Cached),
+		Failed:      int32(job.Failed),
+		CreatedAt:   job.CreatedAt.Unix(),
+		CallbackUrl: job.CallbackURL,
+		Priority:    job.Priority,
+		Owner:       job.Owner,
+	}
+	if !job.StartedAt.IsZero() {
+		result.StartedAt = job.StartedAt.Unix()
+	}
+	if !job.FinishedAt.IsZero() {
+		result.FinishedAt = job.FinishedAt.Unix()
+	}
+	for _, failure := range job.Failures {
+		result.Failures = append(result.Failures, &pb.LinkResult{Link: failure.Link, Status: "error", Error: failure.Err.Error()})
+	}
+	for _, delivery := range job.Deliveries {
+		result.Deliveries = append(result.Deliveries, &pb.WebhookDelivery{
+			Attempt:     int32(delivery.Attempt),
+			AttemptedAt: delivery.AttemptedAt.Unix(),
+			StatusCode:  int32(delivery.StatusCode),
+			Error:       delivery.Error,
+		})
+	}
+	return result
+}
+
+/*
+NewJobHandler создает новый экземпляр JobHandler с предоставленными зависимостями.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+jobs: менеджер фоновых заданий.
+
+HandleWarmCache принимает ссылки и части файла со ссылками и ставит задание предварительной
+загрузки в очередь. Поток, превысивший лимит ссылок или объёма, прерывается с ResourceExhausted.
+stream: поток частей запроса.
+
+HandleSubmitJob ставит в очередь задание загрузки обложек по списку ссылок.
+ctx: контекст выполнения.
+req: запрос со ссылками в формате proto.
+Возвращает поставленное задание или ошибку.
+
+HandleGetJob обрабатывает запрос состояния фонового задания.
+ctx: контекст выполнения.
+req: запрос состояния в формате proto.
+Возвращает задание с прогрессом или ошибку NotFound, в том числе для чужого задания.
+
+HandleListJobs возвращает страницу заданий клиента (для области admin — всех клиентов).
+ctx: контекст выполнения.
+req: фильтр по состоянию и параметры страницы в формате proto.
+Возвращает задания и токен следующей страницы.
+
+HandleCancelJob отменяет задание из очереди или выполняющееся задание.
+ctx: контекст выполнения.
+req: запрос отмены в формате proto.
+Возвращает задание, NotFound или FailedPrecondition.
+
+HandleWatchJob отправляет состояние задания при каждом изменении прогресса.
+req: запрос наблюдения в формате proto.
+stream: поток снимков задания.
+
+ownedJob возвращает задание клиента вызова или NotFound для неизвестного или чужого задания.
+ctx: контекст с клиентом вызова.
+id: идентификатор задания.
+
+caller возвращает клиента вызова из контекста или анонимного клиента.
+ctx: контекст вызова.
+
+submit ставит задание в очередь и преобразует ошибки постановки в статусы gRPC.
+links: ссылки на видео.
+options: параметры задания.
+
+parseLinksFile извлекает ссылки из текстового файла: по одной в строке.
+data: содержимое файла.
+
+toJob преобразует фоновое задание в формат proto.
+*/
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	pb "shelon_server/proto"
+	"shelon_server/usecase"
+	"shelon_server/utilss/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MockLogger заглушка для логирования в тестах
+type MockLogger struct{}
+
+func (m *MockLogger) Info(message string, fields ...interface{})  {}
+func (m *MockLogger) Warn(message string, fields ...interface{})  {}
+func (m *MockLogger) Error(message string, fields ...interface{}) {}
+
+// fakeJobs запоминает ссылки и владельца поставленного задания, ограничивает задание maxLinks
+// ссылками и отдаёт задания из jobs.
+type fakeJobs struct {
+	usecase.JobUsecase
+	maxLinks  int
+	submitted []string
+	owner     string // Владелец поставленного задания или владелец из запроса списка
+	jobs      map[string]*usecase.Job
+	cancelled []string
+}
+
+func (f *fakeJobs) LinkLimit() int { return f.maxLinks }
+
+func (f *fakeJobs) SubmitJob(links []string, options usecase.JobOptions) (*usecase.Job, error) {
+	f.submitted, f.owner = links, options.Owner
+	return &usecase.Job{ID: "job", Total: len(links), Owner: options.Owner}, nil
+}
+
+func (f *fakeJobs) GetJob(id string) (*usecase.Job, error) { return f.jobs[id], nil }
+
+func (f *fakeJobs) ListJobs(owner string, state string, pageToken string, pageSize int) ([]usecase.Job, string, error) {
+	f.owner = owner
+	return nil, "", nil
+}
+
+func (f *fakeJobs) CancelJob(id string) (*usecase.Job, error) {
+	f.cancelled = append(f.cancelled, id)
+	return f.jobs[id], nil
+}
+
+func (f *fakeJobs) WatchJob(ctx context.Context, id string, send func(*usecase.Job) error) error {
+	return send(f.jobs[id])
+}
+
+// fakeWarmStream отдаёт части запроса WarmCache по очереди и запоминает ответ.
+type fakeWarmStream struct {
+	grpc.ServerStream
+	requests []*pb.WarmCacheRequest
+	response *pb.WarmCacheResponse
+}
+
+func (s *fakeWarmStream) Recv() (*pb.WarmCacheRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *fakeWarmStream) SendAndClose(response *pb.WarmCacheResponse) error {
+	s.response = response
+	return nil
+}
+
+func (s *fakeWarmStream) Context() context.Context { return context.Background() }
+
+// fakeWatchStream запоминает снимки задания, отправленные в поток WatchJob.
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.Job
+}
+
+func (s *fakeWatchStream) Send(job *pb.Job) error {
+	s.sent = append(s.sent, job)
+	return nil
+}
+
+func (s *fakeWatchStream) Context() context.Context { return s.ctx }
+
+// TestHandleWarmCache проверяет постановку задания из ссылок и файла со ссылками.
+func TestHandleWarmCache(t *testing.T) {
+	jobs := &fakeJobs{maxLinks: 3}
+	stream := &fakeWarmStream{requests: []*pb.WarmCacheRequest{
+		{Links: []string{"https://youtu.be/a"}},
+		{FileChunk: []byte("# comment\nhttps://youtu.be/b\n\nhttps://you")},
+		{FileChunk: []byte("tu.be/c\n")},
+	}}
+	if err := NewJobHandler(&MockLogger{}, jobs).HandleWarmCache(stream); err != nil {
+		t.Fatalf("Failed to warm cache: %v", err)
+	}
+	expected := "https://youtu.be/a https://youtu.be/b https://youtu.be/c"
+	if strings.Join(jobs.submitted, " ") != expected || stream.response.GetTotal() != 3 {
+		t.Errorf("Expected links %q, got %q (%v)", expected, jobs.submitted, stream.response)
+	}
+}
+
+// TestHandleWarmCacheLimits проверяет, что поток, превысивший лимит ссылок или объёма,
+// прерывается с ResourceExhausted, не дочитываясь до конца и не ставя задание.
+func TestHandleWarmCacheLimits(t *testing.T) {
+	chunk := make([]byte, maxWarmRequestBytes/2)
+	tests := map[string][]*pb.WarmCacheRequest{
+		"links":      {{Links: []string{"https://youtu.be/a", "https://youtu.be/b"}}, {Links: []string{"https://youtu.be/c", "https://youtu.be/d"}}, {}},
+		"file links": {{Links: []string{"https://youtu.be/a"}}, {FileChunk: []byte("https://youtu.be/b\nhttps://youtu.be/c\nhttps://youtu.be/d\n")}},
+		"bytes":      {{FileChunk: chunk}, {Links: []string{"https://youtu.be/a"}}, {FileChunk: chunk}, {}},
+	}
+	for name, requests := range tests {
+		t.Run(name, func(t *testing.T) {
+			jobs := &fakeJobs{maxLinks: 3}
+			stream := &fakeWarmStream{requests: requests}
+			err := NewJobHandler(&MockLogger{}, jobs).HandleWarmCache(stream)
+			if status.Code(err) != codes.ResourceExhausted {
+				t.Fatalf("Expected ResourceExhausted, got %v", err)
+			}
+			if jobs.submitted != nil {
+				t.Errorf("Expected no job to be queued, got %v", jobs.submitted)
+			}
+			if name != "file links" && len(stream.requests) == 0 {
+				t.Errorf("Expected the stream to be aborted before the last part")
+			}
+		})
+	}
+}
+
+// TestJobOwnership проверяет, что задание принадлежит поставившему его клиенту: чужие задания
+// не видны, не отменяются и не наблюдаются, а клиенту с областью admin доступны все задания.
+func TestJobOwnership(t *testing.T) {
+	jobs := &fakeJobs{maxLinks: 10, jobs: map[string]*usecase.Job{
+		"mine":   {ID: "mine", Owner: "dashboard"},
+		"theirs": {ID: "theirs", Owner: "reporter"},
+	}}
+	handler := NewJobHandler(&MockLogger{}, jobs)
+	ctx := auth.NewContext(context.Background(), &auth.Identity{Client: "dashboard", Scopes: []string{auth.ScopeWarm}})
+	admin := auth.NewContext(context.Background(), &auth.Identity{Client: auth.AdminClient, Scopes: []string{auth.ScopeAdmin}})
+
+	if _, err := handler.HandleSubmitJob(ctx, &pb.SubmitJobRequest{Links: []string{"https://youtu.be/a"}}); err != nil || jobs.owner != "dashboard" {
+		t.Errorf("Expected job to be owned by the caller, got %q (%v)", jobs.owner, err)
+	}
+	if _, err := handler.HandleListJobs(ctx, &pb.ListJobsRequest{}); err != nil || jobs.owner != "dashboard" {
+		t.Errorf("Expected list to be limited to the caller, got %q (%v)", jobs.owner, err)
+	}
+	if _, err := handler.HandleListJobs(admin, &pb.ListJobsRequest{}); err != nil || jobs.owner != "" {
+		t.Errorf("Expected admin to list all jobs, got %q (%v)", jobs.owner, err)
+	}
+
+	if resp, err := handler.HandleGetJob(ctx, &pb.GetJobRequest{JobId: "mine"}); err != nil || resp.Job.GetOwner() != "dashboard" {
+		t.Errorf("Expected own job, got %v (%v)", resp, err)
+	}
+	if _, err := handler.HandleGetJob(ctx, &pb.GetJobRequest{JobId: "theirs"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for another client's job, got %v", err)
+	}
+	if _, err := handler.HandleCancelJob(ctx, &pb.CancelJobRequest{JobId: "theirs"}); status.Code(err) != codes.NotFound || len(jobs.cancelled) != 0 {
+		t.Errorf("Expected another client's job not to be cancelled, got %v (%v)", jobs.cancelled, err)
+	}
+	stream := &fakeWatchStream{ctx: ctx}
+	if err := handler.HandleWatchJob(&pb.WatchJobRequest{JobId: "theirs"}, stream); status.Code(err) != codes.NotFound || len(stream.sent) != 0 {
+		t.Errorf("Expected another client's job not to be watched, got %d snapshots (%v)", len(stream.sent), err)
+	}
+
+	if _, err := handler.HandleGetJob(admin, &pb.GetJobRequest{JobId: "theirs"}); err != nil {
+		t.Errorf("Expected admin to get any job, got %v", err)
+	}
+	if _, err := handler.HandleCancelJob(admin, &pb.CancelJobRequest{JobId: "theirs"}); err != nil || len(jobs.cancelled) != 1 {
+		t.Errorf("Expected admin to cancel any job, got %v (%v)", jobs.cancelled, err)
+	}
+	stream = &fakeWatchStream{ctx: admin}
+	if err := handler.HandleWatchJob(&pb.WatchJobRequest{JobId: "theirs"}, stream); err != nil || len(stream.sent) != 1 {
+		t.Errorf("Expected admin to watch any job, got %d snapshots (%v)", len(stream.sent), err)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	pb "shelon_server/proto"
+	"shelon_server/utilss/urlsign"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Сроки действия подписанных ссылок по умолчанию.
+const (
+	defaultSignedURLTTL = time.Hour
+	maxSignedURLTTL     = 7 * 24 * time.Hour
+)
+
+// SignedURLs задает выдачу подписанных ссылок на обложки в HTTP-шлюзе.
+type SignedURLs struct {
+	Signer     *urlsign.Signer // Подписчик ссылок; те же ключи проверяет HTTP-шлюз.
+	PublicURL  string          // Внешний адрес HTTP-шлюза (пусто — выдаётся только путь).
+	DefaultTTL time.Duration   // Срок действия, если он не задан в запросе (0 — час).
+	MaxTTL     time.Duration   // Максимальный срок действия (0 — неделя).
+}
+
+// HandleSignThumbnailURL выдаёт подписанную ссылку на обложку с ограниченным сроком действия.
+// ctx: контекст выполнения.
+// req: запрос подписанной ссылки в формате proto.
+// Возвращает ссылку и время её истечения, InvalidArgument или FailedPrecondition, если подпись ссылок не настроена.
+func (ah *AdminHandler) HandleSignThumbnailURL(ctx context.Context, req *pb.SignThumbnailURLRequest) (*pb.SignThumbnailURLResponse, error) {
+	ah.logger.Info("Received SignThumbnailURL request", zap.String("videoID", req.VideoId), zap.Int64("ttlSeconds", req.TtlSeconds))
+	if ah.SignedURLs == nil || ah.SignedURLs.Signer == nil {
+		return nil, status.Error(codes.FailedPrecondition, "signed urls are disabled: no signing keys configured")
+	}
+	options := ThumbnailOptions{Quality: req.Quality, Width: int(req.Width), Format: req.Format}
+	if err := validateThumbnail(req.VideoId, options); err != nil {
+		return nil, err
+	}
+
+	defaultTTL, maxTTL := ah.SignedURLs.DefaultTTL, ah.SignedURLs.MaxTTL
+	if defaultTTL <= 0 {
+		defaultTTL = defaultSignedURLTTL
+	}
+	if maxTTL <= 0 {
+		maxTTL = maxSignedURLTTL
+	}
+	// Срок сравнивается в секундах до умножения: большое значение переполнило бы time.Duration
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	switch {
+	case req.TtlSeconds < 0 || req.TtlSeconds > int64(maxTTL/time.Second):
+		return nil, status.Errorf(codes.InvalidArgument, "ttl_seconds must be between 1 and %d", int64(maxTTL.Seconds()))
+	case ttl == 0:
+		ttl = min(defaultTTL, maxTTL)
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	signed := ah.SignedURLs.Signer.Sign(ThumbnailPathPrefix+req.VideoId, thumbnailQuery(options), expires)
+	return &pb.SignThumbnailURLResponse{
+		Url:       strings.TrimSuffix(ah.SignedURLs.PublicURL, "/") + signed,
+		ExpiresAt: expires.Unix(),
+	}, nil
+}
+
+/*
+HandleSignThumbnailURL выдаёт подписанную ссылку на обложку с ограниченным сроком действия.
+ctx: контекст выполнения.
+req: запрос подписанной ссылки в формате proto.
+Возвращает ссылку и время её истечения.
+*/
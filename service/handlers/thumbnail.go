@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"shelon_server/imaging"
+	youtubeclient "shelon_server/integrations/youtubeCLient"
+	"shelon_server/usecase"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ThumbnailPathPrefix — путь HTTP-шлюза, по которому обложка отдаётся по идентификатору видео.
+const ThumbnailPathPrefix = "/v1/thumbnails/"
+
+// maxThumbnailWidth ограничивает ширину обложки, запрашиваемой по идентификатору видео.
+const maxThumbnailWidth = 1920
+
+// videoIDPattern описывает идентификатор видео YouTube.
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// ThumbnailOptions задает вид обложки, запрашиваемой по идентификатору видео.
+type ThumbnailOptions struct {
+	Quality string // Качество превью YouTube; пусто — качество, которое загружает сервис.
+	Width   int    // Ширина в пикселях с сохранением пропорций; 0 — исходная.
+	Format  string // Формат результата (jpeg или png); пусто — исходный.
+}
+
+// Thumbnail содержит обложку, готовую к отдаче клиенту.
+type Thumbnail struct {
+	Image       []byte // Закодированное изображение.
+	ContentType string // MIME-тип изображения.
+	ContentHash string // SHA-256 содержимого изображения.
+}
+
+// HandleGetThumbnail возвращает обложку видео по его идентификатору, при необходимости
+// уменьшенную до заданной ширины и перекодированную в заданный формат.
+// ctx: контекст выполнения.
+// videoID: идентификатор видео YouTube.
+// options: качество, ширина и формат обложки.
+// Возвращает обложку или ошибку со статусом gRPC (InvalidArgument, NotFound, DeadlineExceeded).
+func (dh *DataHandler) HandleGetThumbnail(ctx context.Context, videoID string, options ThumbnailOptions) (*Thumbnail, error) {
+	dh.logger.Info("Received GetThumbnail request", zap.String("videoID", videoID), zap.Int("width", options.Width), zap.String("format", options.Format))
+	if err := validateThumbnail(videoID, options); err != nil {
+		return nil, err
+	}
+
+	link := "https://www.youtube.com/watch?v=" + videoID
+	results, err := dh.BusinessLogic.ProcessData(ctx, false, []string{link}, usecase.ProcessOptions{})
+	if err != nil {
+		dh.logger.Error("Failed to process thumbnail", zap.String("videoID", videoID), zap.Error(err))
+		return nil, fmt.Errorf("failed to process thumbnail: %w", err)
+	}
+	result := results[0]
+	if errors.Is(result.Err, context.DeadlineExceeded) {
+		return nil, status.Errorf(codes.DeadlineExceeded, "thumbnail for %q was not fetched before the deadline", videoID)
+	}
+	if result.Err != nil {
+		return nil, status.Errorf(codes.NotFound, "thumbnail for %q is not available: %v", videoID, result.Err)
+	}
+
+	thumbnail, err := renderThumbnail(result.Photo, result.ContentHash, options)
+	if err != nil {
+		dh.logger.Error("Failed to render thumbnail", zap.String("videoID", videoID), zap.Error(err))
+		return nil, fmt.Errorf("failed to render thumbnail: %w", err)
+	}
+	return thumbnail, nil
+}
+
+// validateThumbnail проверяет идентификатор видео и параметры обложки.
+// Возвращает ошибку со статусом InvalidArgument.
+func validateThumbnail(videoID string, options ThumbnailOptions) error {
+	switch {
+	case !videoIDPattern.MatchString(videoID):
+		return status.Errorf(codes.InvalidArgument, "invalid video id %q", videoID)
+	case options.Quality != "" && options.Quality != youtubeclient.ThumbnailQuality:
+		return status.Errorf(codes.InvalidArgument, "unsupported quality %q: only %s is cached", options.Quality, youtubeclient.ThumbnailQuality)
+	case options.Width < 0 || options.Width > maxThumbnailWidth:
+		return status.Errorf(codes.InvalidArgument, "width must be between 1 and %d", maxThumbnailWidth)
+	case options.Format != "" && options.Format != imaging.FormatJPEG && options.Format != imaging.FormatPNG:
+		return status.Errorf(codes.InvalidArgument, "unsupported format %q", options.Format)
+	}
+	return nil
+}
+
+// thumbnailQuery формирует параметры запроса HTTP-шлюза для обложки; незаданные параметры опускаются.
+func thumbnailQuery(options ThumbnailOptions) url.Values {
+	query := url.Values{}
+	if options.Quality != "" {
+		query.Set("quality", options.Quality)
+	}
+	if options.Width > 0 {
+		query.Set("w", strconv.Itoa(options.Width))
+	}
+	if options.Format != "" {
+		query.Set("format", options.Format)
+	}
+	return query
+}
+
+// renderThumbnail приводит обложку к запрошенной ширине и формату. Если ни ширина, ни другой
+// формат не запрошены, обложка возвращается без перекодирования.
+func renderThumbnail(photo []byte, hash string, options ThumbnailOptions) (*Thumbnail, error) {
+	format, err := imaging.DetectFormat(photo)
+	if err != nil {
+		return nil, err
+	}
+	if options.Width == 0 && (options.Format == "" || options.Format == format) {
+		return &Thumbnail{Image: photo, ContentType: "image/" + format, ContentHash: hash}, nil
+	}
+
+	img, err := imaging.Decode(photo)
+	if err != nil {
+		return nil, err
+	}
+	if options.Width > 0 {
+		bounds := img.Bounds()
+		height := max(1, bounds.Dy()*options.Width/max(1, bounds.Dx()))
+		img = imaging.Resize(img, options.Width, height)
+	}
+	target := options.Format
+	if target == "" {
+		// Кодировать GIF сервис не умеет, поэтому он перекодируется в JPEG
+		target = imaging.FormatJPEG
+		if format == imaging.FormatPNG {
+			target = imaging.FormatPNG
+		}
+	}
+	encoded, err := imaging.Encode(img, target)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(encoded)
+	return &Thumbnail{Image: encoded, ContentType: "image/" + target, ContentHash: hex.EncodeToString(sum[:])}, nil
+}
+
+/*
+HandleGetThumbnail возвращает обложку видео по его идентификатору.
+ctx: контекст выполнения.
+videoID: идентификатор видео YouTube.
+options: качество, ширина и формат обложки.
+Возвращает обложку или ошибку со статусом gRPC.
+
+validateThumbnail проверяет идентификатор видео и параметры обложки.
+videoID: идентификатор видео YouTube.
+options: качество, ширина и формат обложки.
+
+thumbnailQuery формирует параметры запроса HTTP-шлюза для обложки.
+options: качество, ширина и формат обложки.
+
+renderThumbnail приводит обложку к запрошенной ширине и формату.
+photo: исходное изображение.
+hash: SHA-256 исходного изображения.
+options: ширина и формат обложки.
+*/
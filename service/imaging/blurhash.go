@@ -0,0 +1,132 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const (
+	// blurHashComponentsX количество горизонтальных компонент BlurHash.
+	blurHashComponentsX = 4
+	// blurHashComponentsY количество вертикальных компонент BlurHash.
+	blurHashComponentsY = 3
+	// blurHashSampleSize размер стороны уменьшенной копии, по которой считается BlurHash.
+	blurHashSampleSize = 32
+)
+
+// base83Chars алфавит кодировки base83, используемой в BlurHash.
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash вычисляет строку BlurHash (https://blurha.sh) для изображения с 4x3 компонентами.
+// Для ускорения расчёт ведётся по уменьшенной копии изображения: BlurHash хранит только
+// низкочастотные составляющие, поэтому точность от этого не страдает.
+func BlurHash(img image.Image) string {
+	small := Resize(img, blurHashSampleSize, blurHashSampleSize)
+	width, height := blurHashSampleSize, blurHashSampleSize
+
+	// Переводим пиксели в линейное цветовое пространство один раз
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := small.RGBAAt(x, y)
+			linear[y*width+x] = [3]float64{srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)}
+		}
+	}
+
+	factors := make([][3]float64, 0, blurHashComponentsX*blurHashComponentsY)
+	for j := 0; j < blurHashComponentsY; j++ {
+		for i := 0; i < blurHashComponentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+			var r, g, b float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					px := linear[y*width+x]
+					r += basis * px[0]
+					g += basis * px[1]
+					b += basis * px[2]
+				}
+			}
+			scale := 1.0 / float64(width*height)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var sb strings.Builder
+	sizeFlag := (blurHashComponentsX - 1) + (blurHashComponentsY-1)*9
+	sb.WriteString(encode83(sizeFlag, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, f := range ac {
+			for _, v := range f {
+				actualMaximum = math.Max(actualMaximum, math.Abs(v))
+			}
+		}
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		sb.WriteString(encode83(quantisedMaximum, 1))
+	} else {
+		sb.WriteString(encode83(0, 1))
+	}
+
+	sb.WriteString(encode83(encodeDC(dc), 4))
+	for _, f := range ac {
+		sb.WriteString(encode83(encodeAC(f, maximumValue), 2))
+	}
+	return sb.String()
+}
+
+// encodeDC кодирует среднюю (DC) компоненту в 24-битное значение sRGB.
+func encodeDC(value [3]float64) int {
+	return linearToSrgb(value[0])<<16 + linearToSrgb(value[1])<<8 + linearToSrgb(value[2])
+}
+
+// encodeAC квантует частотную (AC) компоненту относительно максимального значения.
+func encodeAC(value [3]float64, maximumValue float64) int {
+	quant := func(v float64) int {
+		return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+	}
+	return quant(value[0])*19*19 + quant(value[1])*19 + quant(value[2])
+}
+
+// encode83 кодирует число в base83 строкой фиксированной длины.
+func encode83(value, length int) string {
+	result := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		result[i-1] = base83Chars[digit]
+	}
+	return string(result)
+}
+
+// srgbToLinear переводит компоненту цвета sRGB в линейное пространство.
+func srgbToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSrgb переводит линейную компоненту цвета обратно в sRGB.
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+// signPow возводит модуль числа в степень, сохраняя знак.
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
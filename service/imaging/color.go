@@ -0,0 +1,68 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// dominantSampleSize размер стороны уменьшенной копии, по которой считается доминирующий цвет.
+const dominantSampleSize = 64
+
+// DominantColor возвращает доминирующий цвет изображения в формате #rrggbb.
+// Цвета квантуются до 4 бит на канал, выбирается самая заполненная корзина,
+// а результатом служит средний цвет пикселей этой корзины.
+func DominantColor(img image.Image) string {
+	small := Resize(img, dominantSampleSize, dominantSampleSize)
+
+	type bucket struct {
+		r, g, b, count int
+	}
+	buckets := make(map[int]*bucket)
+	var best *bucket
+
+	for y := 0; y < dominantSampleSize; y++ {
+		for x := 0; x < dominantSampleSize; x++ {
+			c := small.RGBAAt(x, y)
+			if c.A < 128 {
+				continue // Прозрачные пиксели не учитываются
+			}
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			bk, ok := buckets[key]
+			if !ok {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+			bk.count++
+			if best == nil || bk.count > best.count {
+				best = bk
+			}
+		}
+	}
+
+	if best == nil {
+		return "#000000"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.count, best.g/best.count, best.b/best.count)
+}
+
+// ParseHexColor разбирает цвет в формате #rrggbb.
+func ParseHexColor(value string) (color.RGBA, error) {
+	var c color.RGBA
+	if len(value) != 7 || value[0] != '#' {
+		return c, fmt.Errorf("invalid color %q: expected #rrggbb", value)
+	}
+	if _, err := fmt.Sscanf(value[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q: %w", value, err)
+	}
+	c.A = 255
+	return c, nil
+}
+
+// colorAlpha возвращает цвет-маску с заданной непрозрачностью от 0 до 1.
+func colorAlpha(opacity float64) color.Alpha {
+	return color.Alpha{A: uint8(opacity*255 + 0.5)}
+}
@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"image"
+	"math/bits"
+)
+
+// DHash вычисляет разностный перцептивный хеш (dHash) изображения.
+// Изображение уменьшается до 9x8 в оттенках серого, после чего каждый бит хеша
+// показывает, ярче ли пиксель своего правого соседа. Похожие изображения дают
+// хеши с небольшим расстоянием Хэмминга.
+func DHash(img image.Image) uint64 {
+	small := Resize(img, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HammingDistance возвращает количество различающихся битов двух хешей.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// luminance возвращает яркость пикселя по формуле ITU-R BT.601.
+func luminance(img *image.RGBA, x, y int) uint32 {
+	c := img.RGBAAt(x, y)
+	return (299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B)) / 1000
+}
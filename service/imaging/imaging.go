@@ -0,0 +1,56 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // регистрация декодера GIF
+	_ "image/jpeg" // регистрация декодера JPEG
+	_ "image/png"  // регистрация декодера PNG
+)
+
+// Features содержит характеристики изображения, вычисляемые один раз при сохранении в кэш.
+type Features struct {
+	PHash         uint64 // Перцептивный хеш (dHash) изображения.
+	DominantColor string // Доминирующий цвет в формате #rrggbb.
+	BlurHash      string // Строка BlurHash для отрисовки размытой заглушки.
+	Preview       []byte // Миниатюра шириной 20 пикселей в формате JPEG.
+}
+
+// Decode декодирует изображение из массива байтов.
+// Поддерживаются форматы JPEG, PNG и GIF.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// DetectFormat определяет формат закодированного изображения (jpeg, png или gif) по его заголовку.
+func DetectFormat(data []byte) (string, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to detect image format: %w", err)
+	}
+	return format, nil
+}
+
+// Analyze декодирует изображение и вычисляет его характеристики.
+// Возвращает ошибку, если данные не являются поддерживаемым изображением.
+func Analyze(data []byte) (Features, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return Features{}, err
+	}
+	preview, err := Preview(img)
+	if err != nil {
+		return Features{}, err
+	}
+	return Features{
+		PHash:         DHash(img),
+		DominantColor: DominantColor(img),
+		BlurHash:      BlurHash(img),
+		Preview:       preview,
+	}, nil
+}
@@ -0,0 +1,184 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gradient создает тестовое изображение с горизонтальным градиентом заданного цвета.
+func gradient(width, height int, base color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			k := uint8(x * 255 / width)
+			img.SetRGBA(x, y, color.RGBA{R: base.R/2 + k/2, G: base.G / 2, B: base.B / 2, A: 255})
+		}
+	}
+	return img
+}
+
+// TestDHash_ScaledCopiesAreClose проверяет, что масштабированные копии изображения дают близкие хеши.
+func TestDHash_ScaledCopiesAreClose(t *testing.T) {
+	original := gradient(320, 180, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+	scaled := Resize(original, 160, 90)
+	mirrored := image.NewRGBA(original.Bounds())
+	for y := 0; y < 180; y++ {
+		for x := 0; x < 320; x++ {
+			mirrored.Set(319-x, y, original.At(x, y))
+		}
+	}
+
+	if d := HammingDistance(DHash(original), DHash(scaled)); d > 4 {
+		t.Errorf("Expected scaled copy to be within distance 4, got %d", d)
+	}
+	if d := HammingDistance(DHash(original), DHash(mirrored)); d < 32 {
+		t.Errorf("Expected mirrored image to be far, got distance %d", d)
+	}
+}
+
+// TestAnalyze проверяет вычисление характеристик закодированного изображения.
+func TestAnalyze(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			if x < 30 {
+				img.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+
+	features, err := Analyze(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to analyze image: %v", err)
+	}
+	if features.DominantColor != "#ff0000" {
+		t.Errorf("Expected dominant color #ff0000, got %s", features.DominantColor)
+	}
+
+	if len(features.BlurHash) != 28 {
+		t.Errorf("Expected 28-character BlurHash for 4x3 components, got %q", features.BlurHash)
+	}
+	preview, err := Decode(features.Preview)
+	if err != nil {
+		t.Fatalf("Failed to decode preview: %v", err)
+	}
+	if preview.Bounds().Dx() != 20 || preview.Bounds().Dy() != 20 {
+		t.Errorf("Expected 20x20 preview, got %v", preview.Bounds())
+	}
+
+	if _, err := Analyze([]byte{1, 2, 3}); err == nil {
+		t.Errorf("Expected error for invalid image data")
+	}
+}
+
+// TestBlurHash_SolidColor проверяет BlurHash однотонного изображения.
+func TestBlurHash_SolidColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 9))
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+
+	hash := BlurHash(img)
+	if len(hash) != 28 || hash[0] != 'L' {
+		t.Fatalf("Expected 28-character BlurHash with 4x3 size flag, got %q", hash)
+	}
+	// Символы 2-5 кодируют средний цвет изображения
+	dc := 0
+	for _, c := range hash[2:6] {
+		dc = dc*83 + strings.IndexRune(base83Chars, c)
+	}
+	if dc != 0xFFFFFF {
+		t.Errorf("Expected white DC component, got %06x", dc)
+	}
+}
+
+// TestMosaic проверяет размеры сетки и координаты плиток.
+func TestMosaic(t *testing.T) {
+	images := []image.Image{
+		gradient(320, 180, color.RGBA{R: 255, A: 255}),
+		gradient(320, 180, color.RGBA{G: 255, A: 255}),
+		gradient(320, 180, color.RGBA{B: 255, A: 255}),
+	}
+	layout := GridLayout{Columns: 2, TileWidth: 160, TileHeight: 90, Padding: 4}
+
+	sheet, tiles := Mosaic(images, layout, color.White)
+	if sheet.Bounds().Dx() != 2*160+3*4 || sheet.Bounds().Dy() != 2*90+3*4 {
+		t.Fatalf("Unexpected sheet size %v", sheet.Bounds())
+	}
+	if width, height := layout.Size(len(images)); width != sheet.Bounds().Dx() || height != sheet.Bounds().Dy() {
+		t.Errorf("Expected Size to match the sheet, got %dx%d", width, height)
+	}
+	expected := image.Rect(4, 98, 164, 188)
+	if tiles[2] != expected {
+		t.Errorf("Expected third tile at %v, got %v", expected, tiles[2])
+	}
+	if c := sheet.RGBAAt(0, 0); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("Expected background in padding, got %v", c)
+	}
+}
+
+// TestOverlay проверяет наложение изображения в заданную позицию и ключ варианта.
+func TestOverlay(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			logo.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		t.Fatalf("Failed to encode overlay: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write overlay: %v", err)
+	}
+
+	overlay, err := NewOverlay(path, PositionBottomRight, 1, 5)
+	if err != nil {
+		t.Fatalf("Failed to create overlay: %v", err)
+	}
+	base := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	result := overlay.Apply(base)
+
+	if c := color.RGBAModel.Convert(result.At(90, 40)).(color.RGBA); c.R != 255 {
+		t.Errorf("Expected overlay pixel at (90,40), got %v", c)
+	}
+	if c := color.RGBAModel.Convert(result.At(95, 45)).(color.RGBA); c.R != 0 {
+		t.Errorf("Expected untouched margin at (95,45), got %v", c)
+	}
+	if base.RGBAAt(90, 40).R != 0 {
+		t.Errorf("Expected source image to stay untouched")
+	}
+
+	other, err := NewOverlay(path, PositionTopLeft, 1, 5)
+	if err != nil {
+		t.Fatalf("Failed to create overlay: %v", err)
+	}
+	if VariantKey([]Transform{overlay}) == VariantKey([]Transform{other}) {
+		t.Errorf("Expected different variant keys for different positions")
+	}
+	if VariantKey(nil) != "" {
+		t.Errorf("Expected empty variant key without transforms")
+	}
+
+	for _, opacity := range []float64{0, -0.5, 1.5} {
+		if _, err := NewOverlay(path, PositionTopLeft, opacity, 5); err == nil {
+			t.Errorf("Expected opacity %v to be rejected", opacity)
+		}
+	}
+}
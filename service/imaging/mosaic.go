@@ -0,0 +1,113 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// Поддерживаемые форматы кодирования результирующих изображений.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// jpegQuality качество JPEG-кодирования составных изображений.
+const jpegQuality = 85
+
+// GridLayout описывает раскладку плиток в сетке.
+type GridLayout struct {
+	Columns    int // Количество столбцов.
+	TileWidth  int // Ширина плитки в пикселях.
+	TileHeight int // Высота плитки в пикселях.
+	Padding    int // Отступ между плитками и от краёв в пикселях.
+}
+
+// Size возвращает размеры сетки из count плиток в пикселях.
+func (l GridLayout) Size(count int) (int, int) {
+	columns := l.columns(count)
+	rows := (count + columns - 1) / columns
+	return columns*l.TileWidth + (columns+1)*l.Padding, rows*l.TileHeight + (rows+1)*l.Padding
+}
+
+// columns возвращает фактическое количество столбцов сетки из count плиток.
+func (l GridLayout) columns(count int) int {
+	columns := l.Columns
+	if columns > count {
+		columns = count
+	}
+	if columns < 1 {
+		columns = 1
+	}
+	return columns
+}
+
+// Mosaic собирает изображения в одну сетку. Каждое изображение вписывается в плитку
+// с сохранением пропорций и центрируется. Элемент nil оставляет плитку пустой.
+// Возвращает итоговое изображение и прямоугольники плиток в порядке входных изображений.
+func Mosaic(images []image.Image, layout GridLayout, background color.Color) (*image.RGBA, []image.Rectangle) {
+	columns := layout.columns(len(images))
+	width, height := layout.Size(len(images))
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	tiles := make([]image.Rectangle, len(images))
+	for i, img := range images {
+		col, row := i%columns, i/columns
+		x := layout.Padding + col*(layout.TileWidth+layout.Padding)
+		y := layout.Padding + row*(layout.TileHeight+layout.Padding)
+		tiles[i] = image.Rect(x, y, x+layout.TileWidth, y+layout.TileHeight)
+		if img == nil {
+			continue
+		}
+
+		fitted := fit(img, layout.TileWidth, layout.TileHeight)
+		offset := image.Pt(
+			x+(layout.TileWidth-fitted.Bounds().Dx())/2,
+			y+(layout.TileHeight-fitted.Bounds().Dy())/2,
+		)
+		draw.Draw(sheet, fitted.Bounds().Add(offset), fitted, image.Point{}, draw.Over)
+	}
+	return sheet, tiles
+}
+
+// Encode кодирует изображение в указанный формат (jpeg или png).
+func Encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatJPEG, "":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fit масштабирует изображение так, чтобы оно целиком поместилось в прямоугольник width x height.
+func fit(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	w, h := width, bounds.Dy()*width/bounds.Dx()
+	if h > height {
+		w, h = bounds.Dx()*height/bounds.Dy(), height
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return Resize(img, w, h)
+}
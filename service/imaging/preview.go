@@ -0,0 +1,34 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+)
+
+const (
+	// previewWidth ширина миниатюры для прогрессивной загрузки.
+	previewWidth = 20
+	// previewQuality качество JPEG-кодирования миниатюры.
+	previewQuality = 60
+)
+
+// Preview создает миниатюру шириной 20 пикселей с сохранением пропорций и кодирует её в JPEG.
+// Результат предназначен для встраивания в страницу (base64) в качестве заглушки.
+func Preview(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("failed to create preview: empty image")
+	}
+	height := bounds.Dy() * previewWidth / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Resize(img, previewWidth, height), &jpeg.Options{Quality: previewQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode preview: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"strings"
+)
+
+// Transform описывает преобразование изображения, применяемое после получения обложки.
+type Transform interface {
+	// Key возвращает стабильный идентификатор преобразования вместе с его параметрами.
+	Key() string
+	// Apply применяет преобразование и возвращает новое изображение, не изменяя исходное.
+	Apply(img image.Image) image.Image
+}
+
+// VariantKey вычисляет ключ производного варианта изображения для цепочки преобразований.
+// Пустая цепочка даёт пустой ключ (исходное изображение).
+func VariantKey(transforms []Transform) string {
+	if len(transforms) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(transforms))
+	for _, t := range transforms {
+		keys = append(keys, t.Key())
+	}
+	sum := sha256.Sum256([]byte(strings.Join(keys, "|")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ApplyTransforms последовательно применяет цепочку преобразований к изображению.
+func ApplyTransforms(img image.Image, transforms []Transform) image.Image {
+	for _, t := range transforms {
+		img = t.Apply(img)
+	}
+	return img
+}
+
+// Позиции наложения на изображение.
+const (
+	PositionTopLeft     = "top-left"
+	PositionTopRight    = "top-right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottomRight = "bottom-right"
+	PositionCenter      = "center"
+)
+
+// Overlay накладывает изображение (логотип, плашку) поверх обложки.
+type Overlay struct {
+	image    image.Image
+	digest   string  // Хеш содержимого файла наложения, входит в ключ варианта.
+	position string  // Позиция наложения.
+	opacity  float64 // Непрозрачность от 0 до 1.
+	margin   int     // Отступ от края обложки в пикселях.
+}
+
+// NewOverlay создает преобразование наложения из PNG-файла.
+// path: путь к изображению наложения.
+// position: позиция (top-left, top-right, bottom-left, bottom-right, center).
+// opacity: непрозрачность больше 0 и не больше 1; нулевая сделала бы наложение невидимым.
+// margin: отступ от края обложки в пикселях.
+func NewOverlay(path, position string, opacity float64, margin int) (*Overlay, error) {
+	switch position {
+	case PositionTopLeft, PositionTopRight, PositionBottomLeft, PositionBottomRight, PositionCenter:
+	case "":
+		position = PositionBottomRight
+	default:
+		return nil, fmt.Errorf("unsupported overlay position: %s", position)
+	}
+	if opacity <= 0 || opacity > 1 {
+		return nil, fmt.Errorf("overlay opacity must be greater than 0 and at most 1, got %v", opacity)
+	}
+	if margin < 0 {
+		return nil, fmt.Errorf("overlay margin must not be negative, got %d", margin)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overlay image: %w", err)
+	}
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+
+	return &Overlay{
+		image:    img,
+		digest:   hex.EncodeToString(sum[:8]),
+		position: position,
+		opacity:  opacity,
+		margin:   margin,
+	}, nil
+}
+
+// Key возвращает идентификатор наложения, включающий хеш файла и параметры.
+func (o *Overlay) Key() string {
+	return fmt.Sprintf("overlay:%s:%s:%.3f:%d", o.digest, o.position, o.opacity, o.margin)
+}
+
+// Apply накладывает изображение на копию обложки с заданной непрозрачностью.
+func (o *Overlay) Apply(img image.Image) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	size := o.image.Bounds().Size()
+	var at image.Point
+	switch o.position {
+	case PositionTopLeft:
+		at = image.Pt(o.margin, o.margin)
+	case PositionTopRight:
+		at = image.Pt(bounds.Dx()-size.X-o.margin, o.margin)
+	case PositionBottomLeft:
+		at = image.Pt(o.margin, bounds.Dy()-size.Y-o.margin)
+	case PositionBottomRight:
+		at = image.Pt(bounds.Dx()-size.X-o.margin, bounds.Dy()-size.Y-o.margin)
+	case PositionCenter:
+		at = image.Pt((bounds.Dx()-size.X)/2, (bounds.Dy()-size.Y)/2)
+	}
+
+	mask := image.NewUniform(colorAlpha(o.opacity))
+	draw.DrawMask(dst, image.Rectangle{Min: at, Max: at.Add(size)}, o.image, o.image.Bounds().Min, mask, image.Point{}, draw.Over)
+	return dst
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"shelon_server/utilss/logger"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// contentHash вычисляет SHA-256 содержимого в шестнадцатеричном виде.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// validateBlobHash проверяет, что хеш — это 64 шестнадцатеричных символа SHA-256.
+// Хеш используется в путях файлов и ключах объектов, поэтому другие значения отклоняются.
+func validateBlobHash(hash string) error {
+	if len(hash) != 64 {
+		return fmt.Errorf("invalid blob hash %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("invalid blob hash %q", hash)
+	}
+	return nil
+}
+
+// SQLBlobStore хранит содержимое изображений в таблице blob_data той же реляционной базы.
+type SQLBlobStore struct {
+	Logger  logger.Logger
+	DB      *sqlx.DB
+	Builder squirrel.StatementBuilderType
+}
+
+// Put сохраняет содержимое по хешу. Повторное сохранение того же хеша ничего не делает.
+func (bs *SQLBlobStore) Put(hash string, data []byte) error {
+	query, args, err := bs.Builder.
+		Insert("blob_data").
+		Columns("hash", "data").
+		Values(hash, data).
+		Suffix("ON CONFLICT (hash) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := bs.DB.Exec(query, args...); err != nil {
+		bs.Logger.Error("Failed to store blob data", zap.String("hash", hash), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Get возвращает содержимое по хешу или nil, если оно отсутствует.
+func (bs *SQLBlobStore) Get(hash string) ([]byte, error) {
+	query, args, err := bs.Builder.
+		Select("data").
+		From("blob_data").
+		Where(squirrel.Eq{"hash": hash}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	err = bs.DB.Get(&data, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		bs.Logger.Error("Failed to read blob data", zap.String("hash", hash), zap.Error(err))
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete удаляет содержимое по хешу. Отсутствие содержимого ошибкой не считается.
+func (bs *SQLBlobStore) Delete(hash string) error {
+	query, args, err := bs.Builder.
+		Delete("blob_data").
+		Where(squirrel.Eq{"hash": hash}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := bs.DB.Exec(query, args...); err != nil {
+		bs.Logger.Error("Failed to delete blob data", zap.String("hash", hash), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// acquireBlob регистрирует ссылку на содержимое в таблице blobs в рамках транзакции:
+// добавляет запись с единичным счётчиком или увеличивает счётчик существующей записи.
+func (s *SQLDatabase) acquireBlob(tx *sqlx.Tx, hash string, size int) error {
+	query, args, err := s.Builder.
+		Insert("blobs").
+		Columns("hash", "size", "ref_count").
+		Values(hash, size, 1).
+		Suffix("ON CONFLICT (hash) DO UPDATE SET ref_count = blobs.ref_count + 1").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// releaseBlob уменьшает счётчик ссылок содержимого и удаляет запись, когда ссылок не осталось.
+// Возвращает true, если запись удалена и содержимое можно убрать из хранилища.
+func (s *SQLDatabase) releaseBlob(tx *sqlx.Tx, hash string) (bool, error) {
+	query, args, err := s.Builder.
+		Update("blobs").
+		Set("ref_count", squirrel.Expr("ref_count - 1")).
+		Where(squirrel.Eq{"hash": hash}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return false, err
+	}
+
+	query, args, err = s.Builder.
+		Delete("blobs").
+		Where(squirrel.And{squirrel.Eq{"hash": hash}, squirrel.LtOrEq{"ref_count": 0}}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// lockBlob берёт в PostgreSQL транзакционную advisory-блокировку содержимого hash: разделяемую
+// для вставок, которые записывают содержимое и ссылаются на него, и монопольную для сборки,
+// которая удаляет содержимое без ссылок. Блокировка общая для всех реплик с одной базой.
+// В SQLite ничего не делает: базу не делят реплики, а внутри процесса хватает blobMu.
+func (s *SQLDatabase) lockBlob(tx *sqlx.Tx, hash string, exclusive bool) error {
+	if s.dialect != DialectPostgres {
+		return nil
+	}
+	lock := "pg_advisory_xact_lock_shared"
+	if exclusive {
+		lock = "pg_advisory_xact_lock"
+	}
+	_, err := tx.Exec(`SELECT `+lock+`(hashtext($1))`, "blob:"+hash)
+	return err
+}
+
+// collectBlobs удаляет из хранилища содержимое, на которое больше не ссылается ни одна запись.
+// Вызывается после фиксации транзакции; перед удалением повторно проверяет отсутствие ссылок,
+// так как за это время содержимое могло быть снова добавлено. Проверка и удаление выполняются
+// под blobMu (и в PostgreSQL под монопольной lockBlob), чтобы не удалить содержимое, которое
+// параллельная вставка уже записала, но ещё не успела сослаться на него.
+func (s *SQLDatabase) collectBlobs(hashes []string) {
+	if len(hashes) == 0 {
+		return
+	}
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+	for _, hash := range hashes {
+		if err := s.collectBlob(hash); err != nil {
+			s.Logger.Warn("Failed to delete unreferenced blob", zap.String("hash", hash), zap.Error(err))
+		}
+	}
+}
+
+// collectBlob удаляет содержимое hash из хранилища, если на него нет ссылок. В PostgreSQL
+// проверка и удаление выполняются в транзакции, удерживающей монопольную блокировку хеша.
+func (s *SQLDatabase) collectBlob(hash string) error {
+	query, args, err := s.Builder.
+		Select("COUNT(*)").
+		From("blobs").
+		Where(squirrel.Eq{"hash": hash}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	var queryer sqlx.Queryer = s.DB
+	var tx *sqlx.Tx
+	if s.dialect == DialectPostgres {
+		if tx, err = s.DB.Beginx(); err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := s.lockBlob(tx, hash, true); err != nil {
+			return err
+		}
+		queryer = tx
+	}
+	var count int
+	if err := sqlx.Get(queryer, &count, query, args...); err != nil || count > 0 {
+		return err
+	}
+	if err := s.Blobs.Delete(hash); err != nil {
+		return err
+	}
+	if tx != nil {
+		return tx.Commit()
+	}
+	return nil
+}
+
+// blobMigrationBatch — количество хешей, читаемых из таблицы blobs за один запрос при переносе.
+const blobMigrationBatch = 500
+
+// MigrateBlobs читает содержимое всех записей таблицы blobs через хранилище Blobs. Если это
+// FallbackBlobStore, чтение переносит содержимое из прежнего хранилища в новое, поэтому после
+// прохода прежнее хранилище можно отключить. Содержимое, которое не удалось прочитать, пропускается.
+// Возвращает количество найденного содержимого и ошибку чтения базы или отмены ctx.
+func (s *SQLDatabase) MigrateBlobs(ctx context.Context) (int, error) {
+	found, after := 0, ""
+	for ctx.Err() == nil {
+		query, args, err := s.Builder.
+			Select("hash").
+			From("blobs").
+			Where(squirrel.Gt{"hash": after}).
+			OrderBy("hash").
+			Limit(blobMigrationBatch).
+			ToSql()
+		if err != nil {
+			return found, err
+		}
+		var hashes []string
+		if err := s.DB.SelectContext(ctx, &hashes, query, args...); err != nil {
+			return found, fmt.Errorf("failed to list blobs: %w", err)
+		}
+		for _, hash := range hashes {
+			data, err := s.Blobs.Get(hash)
+			if err != nil {
+				s.Logger.Warn("Failed to migrate blob", zap.String("hash", hash), zap.Error(err))
+			} else if data != nil {
+				found++
+			}
+		}
+		if len(hashes) < blobMigrationBatch {
+			return found, nil
+		}
+		after = hashes[len(hashes)-1]
+	}
+	return found, ctx.Err()
+}
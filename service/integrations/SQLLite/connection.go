@@ -0,0 +1,153 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ConnectionOptions задает параметры подключения и пула соединений.
+// JournalMode, BusyTimeout и Synchronous применяются только к SQLite: они передаются драйверу
+// в строке подключения и выполняются для каждого нового соединения пула.
+type ConnectionOptions struct {
+	JournalMode        string        // Режим журнала SQLite: WAL, DELETE, TRUNCATE и т.д. (пусто — по умолчанию драйвера)
+	BusyTimeout        time.Duration // Сколько ждать снятия блокировки базы, прежде чем вернуть "database is locked"
+	Synchronous        string        // Уровень синхронизации SQLite: OFF, NORMAL, FULL, EXTRA
+	MaxOpenConns       int           // Максимум открытых соединений (0 — без ограничения)
+	MaxIdleConns       int           // Максимум простаивающих соединений (0 — по умолчанию database/sql)
+	ConnMaxLifetime    time.Duration // Время жизни соединения (0 — без ограничения)
+	PreparedStatements bool          // Подготавливать часто выполняемые запросы чтения один раз и переиспользовать их
+}
+
+// DefaultConnectionOptions возвращает параметры, при которых параллельные запросы
+// из асинхронной обработки не упираются в блокировку SQLite: журнал WAL позволяет читать
+// во время записи, а писатели ждут друг друга до пяти секунд.
+func DefaultConnectionOptions() ConnectionOptions {
+	return ConnectionOptions{
+		JournalMode:        "WAL",
+		BusyTimeout:        5 * time.Second,
+		Synchronous:        "NORMAL",
+		PreparedStatements: true,
+	}
+}
+
+// sqliteDSN дополняет имя файла базы параметрами драйвера go-sqlite3.
+// Транзакции открываются как BEGIN IMMEDIATE: блокировка на запись берётся сразу,
+// поэтому конкурирующий писатель ждёт busy timeout, а не получает ошибку при повышении блокировки.
+func sqliteDSN(dbName string, options ConnectionOptions) string {
+	params := url.Values{}
+	if options.JournalMode != "" {
+		params.Set("_journal_mode", options.JournalMode)
+	}
+	if options.BusyTimeout > 0 {
+		params.Set("_busy_timeout", fmt.Sprint(options.BusyTimeout.Milliseconds()))
+	}
+	if options.Synchronous != "" {
+		params.Set("_synchronous", options.Synchronous)
+	}
+	params.Set("_txlock", "immediate")
+
+	separator := "?"
+	if strings.Contains(dbName, "?") {
+		separator = "&"
+	}
+	return dbName + separator + params.Encode()
+}
+
+// configurePool применяет ограничения пула соединений.
+func configurePool(db *sqlx.DB, options ConnectionOptions) {
+	if options.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(options.MaxOpenConns)
+	}
+	if options.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(options.MaxIdleConns)
+	}
+	if options.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(options.ConnMaxLifetime)
+	}
+}
+
+// statementCache хранит подготовленные запросы по их тексту.
+// Запрос подготавливается при первом выполнении; database/sql сам переподготавливает его
+// на других соединениях пула.
+type statementCache struct {
+	mu    sync.Mutex
+	db    *sqlx.DB
+	stmts map[string]*sqlx.Stmt
+}
+
+// newStatementCache создает пустой кэш подготовленных запросов.
+func newStatementCache(db *sqlx.DB) *statementCache {
+	return &statementCache{db: db, stmts: make(map[string]*sqlx.Stmt)}
+}
+
+// prepare возвращает подготовленный запрос, подготавливая его при первом обращении.
+func (c *statementCache) prepare(query string) (*sqlx.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.Preparex(query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// close закрывает все подготовленные запросы.
+func (c *statementCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
+
+// get выполняет запрос чтения одной строки, используя подготовленный запрос, если они включены.
+// Подходит для запросов с неизменным текстом; запросы с переменным числом параметров
+// (например, IN по списку) выполняются напрямую, чтобы не раздувать кэш.
+func (s *SQLDatabase) get(dest interface{}, query string, args ...interface{}) error {
+	if s.statements == nil {
+		return s.DB.Get(dest, query, args...)
+	}
+	stmt, err := s.statements.prepare(query)
+	if err != nil {
+		s.Logger.Warn("Failed to prepare statement, executing directly", zap.Error(err))
+		return s.DB.Get(dest, query, args...)
+	}
+	return stmt.Get(dest, args...)
+}
+
+/*
+DefaultConnectionOptions возвращает параметры подключения по умолчанию: WAL, busy timeout 5 секунд,
+synchronous = NORMAL и подготовленные запросы.
+
+sqliteDSN дополняет имя файла базы параметрами драйвера go-sqlite3.
+dbName: имя файла базы данных.
+options: параметры подключения.
+
+configurePool применяет ограничения пула соединений.
+db: подключение к базе данных.
+options: параметры подключения.
+
+newStatementCache создает пустой кэш подготовленных запросов.
+db: подключение к базе данных.
+
+prepare возвращает подготовленный запрос, подготавливая его при первом обращении.
+query: текст запроса.
+
+close закрывает все подготовленные запросы.
+
+get выполняет запрос чтения одной строки, используя подготовленный запрос, если они включены.
+dest: указатель на результат.
+query: текст запроса.
+args: параметры запроса.
+*/
@@ -1,8 +1,13 @@
 package database
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -36,7 +41,7 @@ func TestDatabaseOperations(t *testing.T) {
 	// Тест добавления ресурса
 	url := "https://www.example.com/image.jpg"
 	photo := []byte{1, 2, 3, 4} // Заглушка фото
-	err = db.InsertResource(url, photo)
+	err = db.InsertResource(&Resource{URL: url, Photo: photo})
 	if err != nil {
 		t.Fatalf("Failed to insert resource: %v", err)
 	}
@@ -65,3 +70,1099 @@ func TestDatabaseOperations(t *testing.T) {
 		t.Errorf("Expected database to close successfully, got error: %v", err)
 	}
 }
+
+// TestFindSimilar проверяет поиск ресурсов по расстоянию Хэмминга перцептивного хеша.
+func TestFindSimilar(t *testing.T) {
+	dbFile := "test_similar.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	resources := []*Resource{
+		{URL: "https://youtu.be/exact", Photo: []byte{1}, PHash: 0xFF00FF00FF00FF00, HasFeatures: true, DominantColor: "#ff0000"},
+		{URL: "https://youtu.be/close", Photo: []byte{2}, PHash: 0xFF00FF00FF00FF03, HasFeatures: true, DominantColor: "#00ff00"},
+		{URL: "https://youtu.be/far", Photo: []byte{3}, PHash: 0x00FF00FF00FF00FF, HasFeatures: true, DominantColor: "#0000ff"},
+		{URL: "https://youtu.be/nohash", Photo: []byte{4}},
+	}
+	for _, res := range resources {
+		if err := db.InsertResource(res); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+
+	similar, err := db.FindSimilar(0xFF00FF00FF00FF00, 4, 0)
+	if err != nil {
+		t.Fatalf("Failed to find similar resources: %v", err)
+	}
+	if len(similar) != 2 {
+		t.Fatalf("Expected 2 similar resources, got %d", len(similar))
+	}
+	if similar[0].URL != "https://youtu.be/exact" || similar[0].Distance != 0 {
+		t.Errorf("Expected exact match first, got %+v", similar[0])
+	}
+	if similar[1].URL != "https://youtu.be/close" || similar[1].Distance != 2 {
+		t.Errorf("Expected close match with distance 2, got %+v", similar[1])
+	}
+
+	stored, err := db.GetResourceByUrl("https://youtu.be/close")
+	if err != nil {
+		t.Fatalf("Failed to get resource: %v", err)
+	}
+	if stored == nil || stored.PHash != 0xFF00FF00FF00FF03 || stored.DominantColor != "#00ff00" || !stored.HasFeatures {
+		t.Errorf("Unexpected stored resource: %+v", stored)
+	}
+
+	// Запись без характеристик не участвует в поиске, пока их не дополнят
+	missing, err := db.ResourcesWithoutFeatures("", 10)
+	if err != nil || len(missing) != 1 || missing[0] != "https://youtu.be/nohash" {
+		t.Fatalf("Expected only nohash to lack features, got %v, %v", missing, err)
+	}
+	if err := db.UpdateFeatures(&Resource{URL: "https://youtu.be/nohash", PHash: 0xFF00FF00FF00FF01, DominantColor: "#ffffff"}); err != nil {
+		t.Fatalf("Failed to update features: %v", err)
+	}
+	if missing, err = db.ResourcesWithoutFeatures("", 10); err != nil || len(missing) != 0 {
+		t.Errorf("Expected no resources without features, got %v, %v", missing, err)
+	}
+	if similar, err = db.FindSimilar(0xFF00FF00FF00FF00, 4, 0); err != nil || len(similar) != 3 {
+		t.Errorf("Expected backfilled resource to be found, got %+v, %v", similar, err)
+	}
+	if stored, err = db.GetResourceByUrl("https://youtu.be/nohash"); err != nil || !bytes.Equal(stored.Photo, []byte{4}) {
+		t.Errorf("Expected photo to be kept after features update, got %+v, %v", stored, err)
+	}
+}
+
+// TestGetPlaceholders проверяет выборку заглушек без чтения изображений.
+func TestGetPlaceholders(t *testing.T) {
+	dbFile := "test_placeholders.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	err = db.InsertResource(&Resource{
+		URL:           "https://youtu.be/cached",
+		Photo:         []byte{1, 2, 3},
+		DominantColor: "#112233",
+		HasFeatures:   true,
+		BlurHash:      "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+		Preview:       []byte{0xFF, 0xD8},
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+
+	placeholders, err := db.GetPlaceholders([]string{"https://youtu.be/cached", "https://youtu.be/missing"})
+	if err != nil {
+		t.Fatalf("Failed to get placeholders: %v", err)
+	}
+	if len(placeholders) != 1 {
+		t.Fatalf("Expected 1 placeholder, got %d", len(placeholders))
+	}
+	if placeholders[0].BlurHash != "LEHV6nWB2yk8pyo0adR*.7kCMdnj" || len(placeholders[0].Preview) != 2 {
+		t.Errorf("Unexpected placeholder: %+v", placeholders[0])
+	}
+}
+
+// TestBlobDeduplication проверяет хранение одинаковых изображений в одном экземпляре и подсчёт ссылок.
+func TestBlobDeduplication(t *testing.T) {
+	dbFile := "test_blobs.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Ресурс в старой схеме (до переноса изображений в blobs)
+	legacyPhoto := []byte{9, 9, 9}
+	if _, err := db.DB.Exec(`CREATE TABLE resources (id INTEGER PRIMARY KEY AUTOINCREMENT, url TEXT NOT NULL, photo BLOB)`); err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	if _, err := db.DB.Exec(`INSERT INTO resources (url, photo) VALUES (?, ?)`, "https://youtu.be/legacy", legacyPhoto); err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	legacy, err := db.GetResourceByUrl("https://youtu.be/legacy")
+	if err != nil {
+		t.Fatalf("Failed to get legacy resource: %v", err)
+	}
+	if legacy == nil || string(legacy.Photo) != string(legacyPhoto) || legacy.ContentHash != contentHash(legacyPhoto) {
+		t.Fatalf("Expected legacy photo to be migrated to blobs, got %+v", legacy)
+	}
+
+	photo := []byte{1, 2, 3, 4}
+	first := &Resource{URL: "https://youtu.be/first", Photo: photo}
+	second := &Resource{URL: "https://youtu.be/second", Photo: photo}
+	for _, res := range []*Resource{first, second} {
+		if err := db.InsertResource(res); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	if first.ContentHash == "" || first.ContentHash != second.ContentHash {
+		t.Fatalf("Expected identical content hashes, got %q and %q", first.ContentHash, second.ContentHash)
+	}
+
+	refCount := func() int {
+		var count int
+		err := db.DB.Get(&count, `SELECT COALESCE(SUM(ref_count), 0) FROM blobs WHERE hash = ?`, first.ContentHash)
+		if err != nil {
+			t.Fatalf("Failed to read ref count: %v", err)
+		}
+		return count
+	}
+	if count := refCount(); count != 2 {
+		t.Errorf("Expected ref count 2, got %d", count)
+	}
+
+	if err := db.DeleteResource("https://youtu.be/first"); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	if count := refCount(); count != 1 {
+		t.Errorf("Expected ref count 1 after delete, got %d", count)
+	}
+	if retrieved, err := db.GetPhotoByUrl("https://youtu.be/second"); err != nil || len(retrieved) != len(photo) {
+		t.Errorf("Expected shared blob to remain available, got %v (%v)", retrieved, err)
+	}
+
+	if err := db.DeleteResource("https://youtu.be/second"); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	var blobs int
+	if err := db.DB.Get(&blobs, `SELECT COUNT(*) FROM blobs WHERE hash = ?`, first.ContentHash); err != nil {
+		t.Fatalf("Failed to count blobs: %v", err)
+	}
+	if blobs != 0 {
+		t.Errorf("Expected unreferenced blob to be removed, got %d rows", blobs)
+	}
+}
+
+// TestFileBlobStore проверяет хранение содержимого в файловой системе при метаданных в SQLite.
+func TestFileBlobStore(t *testing.T) {
+	dbFile := "test_file_blobs.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	root := t.TempDir()
+	store, err := NewFileBlobStore(&MockLogger{}, root)
+	if err != nil {
+		t.Fatalf("Failed to create file blob store: %v", err)
+	}
+	db.Blobs = store
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	photo := []byte("jpeg bytes")
+	resource := &Resource{URL: "https://youtu.be/file", Photo: photo}
+	if err := db.InsertResource(resource); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+
+	path := filepath.Join(root, resource.ContentHash[0:2], resource.ContentHash[2:4], resource.ContentHash)
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(photo) {
+		t.Fatalf("Expected blob file at %s, got %q (%v)", path, data, err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the blob file in shard directory, got %v (%v)", entries, err)
+	}
+	var rows int
+	if err := db.DB.Get(&rows, `SELECT COUNT(*) FROM blob_data`); err != nil || rows != 0 {
+		t.Errorf("Expected no blob data in SQLite, got %d rows (%v)", rows, err)
+	}
+
+	retrieved, err := db.GetPhotoByUrl(resource.URL)
+	if err != nil || string(retrieved) != string(photo) {
+		t.Errorf("Expected photo from file store, got %q (%v)", retrieved, err)
+	}
+
+	if err := db.DeleteResource(resource.URL); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected blob file to be removed, got %v", err)
+	}
+	if _, err := store.Get("../../etc/passwd"); err == nil {
+		t.Errorf("Expected invalid hash to be rejected")
+	}
+}
+
+// TestInsertResourceFailureCollectsBlob проверяет, что содержимое, записанное в хранилище перед
+// неудавшейся вставкой, удаляется, если на него никто не ссылается, и остаётся, если ссылается.
+func TestInsertResourceFailureCollectsBlob(t *testing.T) {
+	dbFile := "test_failed_insert.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	root := t.TempDir()
+	store, err := NewFileBlobStore(&MockLogger{}, root)
+	if err != nil {
+		t.Fatalf("Failed to create file blob store: %v", err)
+	}
+	db.Blobs = store
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	shared := &Resource{URL: "https://youtu.be/shared", Photo: []byte("shared bytes")}
+	if err := db.InsertResource(shared); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	if _, err := db.DB.Exec(`CREATE TRIGGER fail_insert BEFORE INSERT ON resources
+		WHEN NEW.url LIKE '%/broken%' BEGIN SELECT RAISE(ABORT, 'insert failed'); END`); err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+
+	photo := []byte("orphan bytes")
+	if err := db.InsertResource(&Resource{URL: "https://youtu.be/broken", Photo: photo}); err == nil {
+		t.Fatalf("Expected insert to fail")
+	}
+	if data, err := store.Get(contentHash(photo)); err != nil || data != nil {
+		t.Errorf("Expected blob of the failed insert to be removed, got %q (%v)", data, err)
+	}
+
+	if err := db.InsertResource(&Resource{URL: "https://youtu.be/broken-shared", Photo: shared.Photo}); err == nil {
+		t.Fatalf("Expected insert to fail")
+	}
+	if data, err := store.Get(shared.ContentHash); err != nil || string(data) != string(shared.Photo) {
+		t.Errorf("Expected referenced blob to be kept, got %q (%v)", data, err)
+	}
+}
+
+// TestMemoryCachedDatabase проверяет чтение сквозь кэш, вытеснение по объёму и сброс записей.
+func TestMemoryCachedDatabase(t *testing.T) {
+	dbFile := "test_memory_cache.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	for _, url := range []string{"https://youtu.be/a", "https://youtu.be/b"} {
+		if err := db.InsertResource(&Resource{URL: url, Photo: make([]byte, 60)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+
+	cache := NewMemoryCachedDatabase(&MockLogger{}, db, 100)
+
+	// Первое чтение идёт в базу, второе — из памяти
+	for i := 0; i < 2; i++ {
+		if res, err := cache.GetResourceByUrl("https://youtu.be/a"); err != nil || res == nil {
+			t.Fatalf("Failed to get resource: %v", err)
+		}
+	}
+	stats := cache.Stats()
+	if stats.Tiers[TierMemory] != (TierStats{Hits: 1, Misses: 1}) || stats.Tiers[TierDatabase] != (TierStats{Hits: 1}) {
+		t.Errorf("Unexpected tier stats: %+v", stats.Tiers)
+	}
+
+	// Вторая запись не помещается вместе с первой и вытесняет её
+	if _, err := cache.GetResourceByUrl("https://youtu.be/b"); err != nil {
+		t.Fatalf("Failed to get resource: %v", err)
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Bytes != 60 {
+		t.Errorf("Expected a single 60-byte entry after eviction, got %+v", stats)
+	}
+
+	// Удаление сбрасывает запись в памяти
+	if err := cache.DeleteResource("https://youtu.be/b"); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	if res, err := cache.GetResourceByUrl("https://youtu.be/b"); err != nil || res != nil {
+		t.Errorf("Expected deleted resource to be gone, got %+v (%v)", res, err)
+	}
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Tiers[TierDatabase].Misses != 1 {
+		t.Errorf("Expected empty memory tier and a database miss, got %+v", stats)
+	}
+}
+
+// testDatabaseContract проверяет поведение любой реализации Database на инициализированной пустой базе.
+// Используется для SQLite и PostgreSQL.
+func testDatabaseContract(t *testing.T, db Database) {
+	t.Helper()
+	photo := []byte{7, 7, 7, 7}
+	first := &Resource{URL: "https://youtu.be/contract1", Photo: photo, PHash: 0xF0F0, HasFeatures: true, DominantColor: "#101010", BlurHash: "L00000fQfQfQfQfQfQfQfQfQfQfQ", Preview: []byte{1}}
+	second := &Resource{URL: "https://youtu.be/contract2", Photo: photo, PHash: 0xF0F1, HasFeatures: true, DominantColor: "#202020"}
+	for _, res := range []*Resource{first, second} {
+		if err := db.InsertResource(res); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	if first.ContentHash == "" || first.ContentHash != second.ContentHash {
+		t.Errorf("Expected identical content hashes, got %q and %q", first.ContentHash, second.ContentHash)
+	}
+
+	exists, err := db.ResourceExists(first.URL)
+	if err != nil || !exists {
+		t.Errorf("Expected resource to exist, got %v (%v)", exists, err)
+	}
+	stored, err := db.GetResourceByUrl(first.URL)
+	if err != nil || stored == nil {
+		t.Fatalf("Failed to get resource: %v", err)
+	}
+	if string(stored.Photo) != string(photo) || stored.PHash != first.PHash || stored.BlurHash != first.BlurHash {
+		t.Errorf("Unexpected stored resource: %+v", stored)
+	}
+	if missing, err := db.GetResourceByUrl("https://youtu.be/missing"); err != nil || missing != nil {
+		t.Errorf("Expected nil for missing resource, got %+v (%v)", missing, err)
+	}
+
+	similar, err := db.FindSimilar(0xF0F0, 1, 0)
+	if err != nil || len(similar) != 2 || similar[0].URL != first.URL {
+		t.Errorf("Unexpected similar resources: %+v (%v)", similar, err)
+	}
+	placeholders, err := db.GetPlaceholders([]string{first.URL})
+	if err != nil || len(placeholders) != 1 || placeholders[0].BlurHash != first.BlurHash {
+		t.Errorf("Unexpected placeholders: %+v (%v)", placeholders, err)
+	}
+
+	if err := db.InsertVariant(first.URL, "overlay", []byte{5}); err != nil {
+		t.Fatalf("Failed to insert variant: %v", err)
+	}
+	if err := db.InsertVariant(first.URL, "overlay", []byte{6}); err != nil {
+		t.Fatalf("Failed to overwrite variant: %v", err)
+	}
+	if variant, err := db.GetVariant(first.URL, "overlay"); err != nil || len(variant) != 1 || variant[0] != 6 {
+		t.Errorf("Unexpected variant: %v (%v)", variant, err)
+	}
+
+	if err := db.DeleteResource(first.URL); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	if variant, err := db.GetVariant(first.URL, "overlay"); err != nil || variant != nil {
+		t.Errorf("Expected variant to be deleted with resource, got %v (%v)", variant, err)
+	}
+	if shared, err := db.GetPhotoByUrl(second.URL); err != nil || string(shared) != string(photo) {
+		t.Errorf("Expected shared blob to remain, got %v (%v)", shared, err)
+	}
+
+	// Повторное сохранение URL обновляет существующую строку
+	refreshed := &Resource{URL: second.URL, Photo: []byte{8, 8}, PHash: 0x0F0F, HasFeatures: true, DominantColor: "#303030"}
+	if err := db.InsertResource(refreshed); err != nil {
+		t.Fatalf("Failed to upsert resource: %v", err)
+	}
+	if res, err := db.GetResourceByUrl(second.URL); err != nil || res == nil || len(res.Photo) != 2 || res.DominantColor != "#303030" {
+		t.Errorf("Expected refreshed resource, got %+v (%v)", res, err)
+	}
+	if similar, err := db.FindSimilar(0x0F0F, 0, 0); err != nil || len(similar) != 1 {
+		t.Errorf("Expected a single row for the refreshed URL, got %+v (%v)", similar, err)
+	}
+
+	entries, err := db.ListEntries(EntryFilter{VideoIDPrefix: "contract"}, "", 10)
+	if err != nil || len(entries) != 1 || entries[0].URL != second.URL || entries[0].Size != 2 {
+		t.Errorf("Unexpected entries: %+v (%v)", entries, err)
+	}
+	if purged, err := db.PurgeEntries(EntryFilter{URLs: []string{second.URL}}); err != nil || len(purged) != 1 {
+		t.Errorf("Unexpected purge result: %v (%v)", purged, err)
+	}
+	if entry, err := db.GetEntry(second.URL); err != nil || entry != nil {
+		t.Errorf("Expected purged entry to be gone, got %+v (%v)", entry, err)
+	}
+	if stats, err := db.GetCacheStats(); err != nil || stats.Entries != 0 || stats.OriginalBytes != 0 {
+		t.Errorf("Expected empty cache stats, got %+v (%v)", stats, err)
+	}
+}
+
+// TestSQLiteContract прогоняет общий набор проверок Database на SQLite.
+func TestSQLiteContract(t *testing.T) {
+	dbFile := "test_contract.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	testDatabaseContract(t, db)
+}
+
+// TestConcurrentAccess вставляет, читает и удаляет ресурсы из многих горутин одновременно,
+// как это делает асинхронная обработка ссылок. Ни одна операция не должна завершиться ошибкой
+// "database is locked".
+func TestConcurrentAccess(t *testing.T) {
+	dbFile := "test_concurrent.db"
+	defer os.Remove(dbFile)
+
+	options := DefaultConnectionOptions()
+	options.MaxOpenConns = 8
+	db, err := NewSQLiteDatabaseWithOptions(&MockLogger{}, dbFile, options)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	var journalMode string
+	if err := db.DB.Get(&journalMode, "PRAGMA journal_mode"); err != nil || journalMode != "wal" {
+		t.Errorf("Expected WAL journal mode, got %q (%v)", journalMode, err)
+	}
+
+	const workers, iterations = 16, 25
+	shared := []byte("shared thumbnail")
+	errs := make(chan error, workers*iterations*3)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				url := fmt.Sprintf("https://youtu.be/concurrent-%d-%d", w, i)
+				// Половина ресурсов ссылается на общее содержимое, чтобы писатели конкурировали за счётчик ссылок
+				photo := shared
+				if i%2 == 1 {
+					photo = []byte(url)
+				}
+				if err := db.InsertResource(&Resource{URL: url, Photo: photo}); err != nil {
+					errs <- fmt.Errorf("insert %s: %w", url, err)
+					continue
+				}
+				if res, err := db.GetResourceByUrl(url); err != nil || res == nil || string(res.Photo) != string(photo) {
+					errs <- fmt.Errorf("read %s: %+v, %v", url, res, err)
+				}
+				if i%5 == 0 {
+					if err := db.DeleteResource(url); err != nil {
+						errs <- fmt.Errorf("delete %s: %w", url, err)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var resources, refs int
+	if err := db.DB.Get(&resources, `SELECT COUNT(*) FROM resources`); err != nil {
+		t.Fatalf("Failed to count resources: %v", err)
+	}
+	if err := db.DB.Get(&refs, `SELECT COALESCE(SUM(ref_count), 0) FROM blobs`); err != nil {
+		t.Fatalf("Failed to count blob references: %v", err)
+	}
+	if expected := workers * (iterations - iterations/5); resources != expected || refs != expected {
+		t.Errorf("Expected %d resources and references, got %d and %d", expected, resources, refs)
+	}
+}
+
+// TestResourceUpsert проверяет, что повторное сохранение URL обновляет строку, а не добавляет новую.
+func TestResourceUpsert(t *testing.T) {
+	dbFile := "test_upsert.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	url := "https://youtu.be/upsert"
+	old := &Resource{URL: url, Photo: []byte("old thumbnail"), PHash: 1, HasFeatures: true, DominantColor: "#111111"}
+	if err := db.InsertResource(old); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	if err := db.InsertVariant(url, "overlay", []byte("old variant")); err != nil {
+		t.Fatalf("Failed to insert variant: %v", err)
+	}
+	fresh := &Resource{URL: url, Photo: []byte("new thumbnail"), PHash: 2, HasFeatures: true, DominantColor: "#222222"}
+	if err := db.InsertResource(fresh); err != nil {
+		t.Fatalf("Failed to upsert resource: %v", err)
+	}
+
+	var rows int
+	if err := db.DB.Get(&rows, `SELECT COUNT(*) FROM resources WHERE url = ?`, url); err != nil || rows != 1 {
+		t.Fatalf("Expected a single row after upsert, got %d (%v)", rows, err)
+	}
+	res, err := db.GetResourceByUrl(url)
+	if err != nil || res == nil || string(res.Photo) != "new thumbnail" || res.PHash != 2 || res.DominantColor != "#222222" {
+		t.Errorf("Expected refreshed resource, got %+v (%v)", res, err)
+	}
+	if variant, err := db.GetVariant(url, "overlay"); err != nil || variant != nil {
+		t.Errorf("Expected stale variant to be removed, got %q (%v)", variant, err)
+	}
+	if err := db.DB.Get(&rows, `SELECT COUNT(*) FROM blobs WHERE hash = ?`, old.ContentHash); err != nil || rows != 0 {
+		t.Errorf("Expected previous blob to be released, got %d rows (%v)", rows, err)
+	}
+
+	// Повторное сохранение того же содержимого не меняет счётчик ссылок
+	if err := db.InsertResource(&Resource{URL: url, Photo: []byte("new thumbnail")}); err != nil {
+		t.Fatalf("Failed to upsert resource: %v", err)
+	}
+	var refs int
+	if err := db.DB.Get(&refs, `SELECT ref_count FROM blobs WHERE hash = ?`, fresh.ContentHash); err != nil || refs != 1 {
+		t.Errorf("Expected a single blob reference, got %d (%v)", refs, err)
+	}
+}
+
+// TestConcurrentUpsert сохраняет одни и те же URL из многих горутин и проверяет,
+// что на каждый URL остаётся ровно одна строка, а счётчики ссылок сходятся.
+func TestConcurrentUpsert(t *testing.T) {
+	dbFile := "test_concurrent_upsert.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	const workers, urls = 12, 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*urls)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < urls; i++ {
+				url := fmt.Sprintf("https://youtu.be/race-%d", i)
+				// Горутины записывают разное содержимое, чтобы обновления освобождали прежние ссылки
+				photo := []byte(fmt.Sprintf("thumbnail %d from %d", i, w%3))
+				if err := db.InsertResource(&Resource{URL: url, Photo: photo}); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var perURL []int
+	if err := db.DB.Select(&perURL, `SELECT COUNT(*) FROM resources GROUP BY url`); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if len(perURL) != urls {
+		t.Errorf("Expected %d distinct URLs, got %d", urls, len(perURL))
+	}
+	for _, count := range perURL {
+		if count != 1 {
+			t.Errorf("Expected exactly one row per URL, got %v", perURL)
+			break
+		}
+	}
+	var refs int
+	if err := db.DB.Get(&refs, `SELECT COALESCE(SUM(ref_count), 0) FROM blobs`); err != nil || refs != urls {
+		t.Errorf("Expected %d blob references, got %d (%v)", urls, refs, err)
+	}
+}
+
+// TestDeduplicationMigration проверяет, что миграция удаляет дубликаты URL, накопленные
+// до появления уникального индекса, и поправляет счётчики ссылок.
+func TestDeduplicationMigration(t *testing.T) {
+	dbFile := "test_dedup_migration.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Схема до уникального индекса
+	all := migrations
+	migrations = all[:6]
+	err = db.InitDatabase()
+	migrations = all
+	if err != nil {
+		t.Fatalf("Failed to apply old migrations: %v", err)
+	}
+
+	photos := [][]byte{[]byte("first"), []byte("second"), []byte("first")}
+	for _, photo := range photos {
+		hash := contentHash(photo)
+		statements := []string{
+			`INSERT INTO blob_data (hash, data) VALUES (?, ?) ON CONFLICT (hash) DO NOTHING`,
+			`INSERT INTO blobs (hash, size, ref_count) VALUES (?, 0, 1) ON CONFLICT (hash) DO UPDATE SET ref_count = ref_count + 1`,
+			`INSERT INTO resources (url, blob_hash) VALUES ('https://youtu.be/dup', ?)`,
+		}
+		if _, err := db.DB.Exec(statements[0], hash, photo); err != nil {
+			t.Fatalf("Failed to seed blob data: %v", err)
+		}
+		for _, stmt := range statements[1:] {
+			if _, err := db.DB.Exec(stmt, hash); err != nil {
+				t.Fatalf("Failed to seed duplicates: %v", err)
+			}
+		}
+	}
+
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to apply deduplication migration: %v", err)
+	}
+	var rows int
+	if err := db.DB.Get(&rows, `SELECT COUNT(*) FROM resources`); err != nil || rows != 1 {
+		t.Errorf("Expected a single row after migration, got %d (%v)", rows, err)
+	}
+	if photo, err := db.GetPhotoByUrl("https://youtu.be/dup"); err != nil || string(photo) != "first" {
+		t.Errorf("Expected the latest row to survive, got %q (%v)", photo, err)
+	}
+	var refs, data int
+	if err := db.DB.Get(&refs, `SELECT COALESCE(SUM(ref_count), 0) FROM blobs`); err != nil || refs != 1 {
+		t.Errorf("Expected a single blob reference, got %d (%v)", refs, err)
+	}
+	if err := db.DB.Get(&data, `SELECT COUNT(*) FROM blob_data`); err != nil || data != 1 {
+		t.Errorf("Expected orphaned blob data to be removed, got %d rows (%v)", data, err)
+	}
+	if _, err := db.DB.Exec(`INSERT INTO resources (url) VALUES ('https://youtu.be/dup')`); err == nil {
+		t.Errorf("Expected unique index to reject duplicate URL")
+	}
+}
+
+// TestCacheEntries проверяет постраничный список, фильтры, просмотр и удаление записей кэша.
+func TestCacheEntries(t *testing.T) {
+	dbFile := "test_entries.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	urls := []string{
+		"https://www.youtube.com/watch?v=ab_cd000001",
+		"https://youtu.be/abXcd000002",
+		"https://youtu.be/zz0000000003",
+	}
+	for i, url := range urls {
+		if err := db.InsertResource(&Resource{URL: url, Photo: []byte(url), HasFeatures: true, DominantColor: "#000000", PHash: uint64(i)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	if err := db.InsertVariant(urls[0], "overlay", []byte("variant")); err != nil {
+		t.Fatalf("Failed to insert variant: %v", err)
+	}
+	// Первая запись загружена давно
+	if _, err := db.DB.Exec(`UPDATE resources SET fetched_at = ? WHERE url = ?`, time.Now().Add(-48*time.Hour).Unix(), urls[0]); err != nil {
+		t.Fatalf("Failed to age entry: %v", err)
+	}
+
+	// Постраничный обход по URL
+	page, err := db.ListEntries(EntryFilter{}, "", 2)
+	if err != nil || len(page) != 2 || page[0].URL != urls[0] || page[1].URL != urls[1] {
+		t.Fatalf("Unexpected first page: %+v (%v)", page, err)
+	}
+	page, err = db.ListEntries(EntryFilter{}, page[1].URL, 2)
+	if err != nil || len(page) != 1 || page[0].URL != urls[2] || page[0].VideoID != "zz0000000003" {
+		t.Fatalf("Unexpected second page: %+v (%v)", page, err)
+	}
+
+	// "_" в префиксе сравнивается буквально
+	if found, err := db.ListEntries(EntryFilter{VideoIDPrefix: "ab_"}, "", 0); err != nil || len(found) != 1 || found[0].URL != urls[0] {
+		t.Errorf("Unexpected prefix filter result: %+v (%v)", found, err)
+	}
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	if found, err := db.ListEntries(EntryFilter{FetchedBefore: dayAgo}, "", 0); err != nil || len(found) != 1 || found[0].URL != urls[0] {
+		t.Errorf("Unexpected age filter result: %+v (%v)", found, err)
+	}
+	if found, err := db.ListEntries(EntryFilter{FetchedAfter: dayAgo}, "", 0); err != nil || len(found) != 2 {
+		t.Errorf("Unexpected age filter result: %+v (%v)", found, err)
+	}
+	if oldest, err := db.OldestEntries(time.Now().Add(time.Hour), 2); err != nil || len(oldest) != 2 || oldest[0].URL != urls[0] {
+		t.Errorf("Expected the aged entry first, got %+v (%v)", oldest, err)
+	}
+	if oldest, err := db.OldestEntries(dayAgo.Add(-48*time.Hour), 10); err != nil || len(oldest) != 0 {
+		t.Errorf("Expected no entries older than three days, got %+v (%v)", oldest, err)
+	}
+
+	entry, err := db.GetEntry(urls[0])
+	if err != nil || entry == nil {
+		t.Fatalf("Failed to get entry: %v", err)
+	}
+	if entry.VideoID != "ab_cd000001" || entry.Size != int64(len(urls[0])) || entry.Variants != 1 || entry.ContentHash == "" || time.Since(entry.FetchedAt) < 47*time.Hour {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if missing, err := db.GetEntry("https://youtu.be/missing"); err != nil || missing != nil {
+		t.Errorf("Expected missing entry to be nil, got %+v (%v)", missing, err)
+	}
+
+	purged, err := db.PurgeEntries(EntryFilter{FetchedBefore: dayAgo})
+	if err != nil || len(purged) != 1 || purged[0] != urls[0] {
+		t.Errorf("Unexpected purge by age: %v (%v)", purged, err)
+	}
+	if variant, err := db.GetVariant(urls[0], "overlay"); err != nil || variant != nil {
+		t.Errorf("Expected purged variant to be gone, got %q (%v)", variant, err)
+	}
+	purged, err = db.PurgeEntries(EntryFilter{})
+	if err != nil || len(purged) != 2 {
+		t.Errorf("Expected all remaining entries to be purged, got %v (%v)", purged, err)
+	}
+	var blobs int
+	if err := db.DB.Get(&blobs, `SELECT COUNT(*) FROM blobs`); err != nil || blobs != 0 {
+		t.Errorf("Expected purged blobs to be released, got %d (%v)", blobs, err)
+	}
+}
+
+// TestVideoIDMigration проверяет заполнение идентификаторов видео и времени загрузки
+// у записей, созданных до появления этих колонок.
+func TestVideoIDMigration(t *testing.T) {
+	dbFile := "test_video_id_migration.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	all := migrations
+	migrations = all[:7]
+	err = db.InitDatabase()
+	migrations = all
+	if err != nil {
+		t.Fatalf("Failed to apply old migrations: %v", err)
+	}
+	for _, url := range []string{"https://youtu.be/legacy00001", "not a youtube link"} {
+		if _, err := db.DB.Exec(`INSERT INTO resources (url) VALUES (?)`, url); err != nil {
+			t.Fatalf("Failed to seed resource: %v", err)
+		}
+	}
+
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to apply video id migration: %v", err)
+	}
+	entries, err := db.ListEntries(EntryFilter{}, "", 0)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Unexpected entries: %+v (%v)", entries, err)
+	}
+	if entries[0].VideoID != "legacy00001" || entries[1].VideoID != "" {
+		t.Errorf("Unexpected video ids: %q, %q", entries[0].VideoID, entries[1].VideoID)
+	}
+	for _, entry := range entries {
+		if time.Since(entry.FetchedAt) > time.Minute {
+			t.Errorf("Expected fetch time to be set by migration, got %v", entry.FetchedAt)
+		}
+	}
+}
+
+// TestCacheStats проверяет агрегированную статистику кэша.
+func TestCacheStats(t *testing.T) {
+	dbFile := "test_cache_stats.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	if stats, err := db.GetCacheStats(); err != nil || *stats != (CacheStats{}) {
+		t.Errorf("Expected empty stats, got %+v (%v)", stats, err)
+	}
+
+	// Две ссылки с одинаковым содержимым занимают место один раз
+	for _, url := range []string{"https://youtu.be/stats1", "https://youtu.be/stats2"} {
+		if err := db.InsertResource(&Resource{URL: url, Photo: make([]byte, 100)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	if err := db.InsertResource(&Resource{URL: "https://youtu.be/stats3", Photo: make([]byte, 50)}); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	if err := db.InsertVariant("https://youtu.be/stats1", "overlay", make([]byte, 30)); err != nil {
+		t.Fatalf("Failed to insert variant: %v", err)
+	}
+	now := time.Now().Unix()
+	for url, age := range map[string]int64{"https://youtu.be/stats1": 300, "https://youtu.be/stats2": 100, "https://youtu.be/stats3": 10} {
+		if _, err := db.DB.Exec(`UPDATE resources SET fetched_at = ? WHERE url = ?`, now-age, url); err != nil {
+			t.Fatalf("Failed to set fetch time: %v", err)
+		}
+	}
+
+	stats, err := db.GetCacheStats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	expected := CacheStats{
+		Entries:         3,
+		OriginalBytes:   150,
+		Variants:        1,
+		VariantBytes:    30,
+		OldestURL:       "https://youtu.be/stats1",
+		OldestFetchedAt: time.Unix(now-300, 0),
+		NewestURL:       "https://youtu.be/stats3",
+		NewestFetchedAt: time.Unix(now-10, 0),
+	}
+	if *stats != expected {
+		t.Errorf("Unexpected stats:\n got %+v\nwant %+v", *stats, expected)
+	}
+}
+
+// TestInsertResourceFetchedAt проверяет сохранение заданного времени загрузки (используется при импорте архива).
+func TestInsertResourceFetchedAt(t *testing.T) {
+	dbFile := "test_fetched_at.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	fetchedAt := time.Date(2023, 6, 1, 8, 30, 0, 0, time.UTC)
+	if err := db.InsertResource(&Resource{URL: "https://youtu.be/imported", Photo: []byte("img"), FetchedAt: fetchedAt}); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	entry, err := db.GetEntry("https://youtu.be/imported")
+	if err != nil || entry == nil || !entry.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("Expected fetched_at %v, got %+v (%v)", fetchedAt, entry, err)
+	}
+
+	fresh := &Resource{URL: "https://youtu.be/fresh", Photo: []byte("img")}
+	if err := db.InsertResource(fresh); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	if time.Since(fresh.FetchedAt) > time.Minute {
+		t.Errorf("Expected current fetched_at, got %v", fresh.FetchedAt)
+	}
+}
+
+// TestJobStore проверяет сохранение заданий, учёт обработанных ссылок и постраничный вывод заданий
+// с отбором по состоянию и владельцу.
+func TestJobStore(t *testing.T) {
+	dbFile := "test_jobs.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	owners := map[string]string{"a": "dashboard", "b": "reporter", "c": "dashboard"}
+	for i, id := range []string{"a", "b", "c"} {
+		job := &Job{ID: id, Kind: "warm_cache", State: "queued", Total: 3, CreatedAt: created.Add(time.Duration(i) * time.Minute), Owner: owners[id]}
+		if err := db.CreateJob(job, []string{"https://youtu.be/1", "https://youtu.be/2", "https://youtu.be/3"}); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+
+	completions := []JobLink{
+		{JobID: "a", Position: 0, Status: JobLinkFetched},
+		{JobID: "a", Position: 1, Status: JobLinkFailed, Error: "not found"},
+		{JobID: "a", Position: 1, Status: JobLinkFetched}, // повторное завершение не учитывается
+	}
+	for _, link := range completions {
+		if err := db.CompleteJobLink(link); err != nil {
+			t.Fatalf("Failed to complete job link: %v", err)
+		}
+	}
+	if err := db.CompleteJobLink(JobLink{JobID: "a", Position: 2, Status: JobLinkPending}); err == nil {
+		t.Errorf("Expected error for pending status")
+	}
+
+	job, err := db.GetJob("a")
+	if err != nil || job == nil || job.Fetched != 1 || job.Failed != 1 || job.Cached != 0 || !job.CreatedAt.Equal(created) || job.Owner != "dashboard" {
+		t.Fatalf("Unexpected job: %+v (%v)", job, err)
+	}
+	pending, err := db.PendingJobLinks("a")
+	if err != nil || len(pending) != 1 || pending[0].URL != "https://youtu.be/3" || pending[0].Position != 2 {
+		t.Errorf("Unexpected pending links: %+v (%v)", pending, err)
+	}
+	failures, err := db.JobFailures("a", 10)
+	if err != nil || len(failures) != 1 || failures[0].Error != "not found" {
+		t.Errorf("Unexpected failures: %+v (%v)", failures, err)
+	}
+
+	job.State, job.StartedAt, job.FinishedAt = "completed", created, created.Add(time.Hour)
+	if err := db.UpdateJobState(job); err != nil {
+		t.Fatalf("Failed to update job: %v", err)
+	}
+	if job, _ := db.GetJob("a"); job.State != "completed" || !job.FinishedAt.Equal(created.Add(time.Hour)) {
+		t.Errorf("Expected completed job, got %+v", job)
+	}
+	if job, err := db.GetJob("missing"); err != nil || job != nil {
+		t.Errorf("Expected missing job to be nil, got %+v (%v)", job, err)
+	}
+
+	page, err := db.ListJobs("", "", "", 2)
+	if err != nil || len(page) != 2 || page[0].ID != "c" || page[1].ID != "b" {
+		t.Fatalf("Unexpected first page: %+v (%v)", page, err)
+	}
+	page, err = db.ListJobs("", "", page[1].ID, 2)
+	if err != nil || len(page) != 1 || page[0].ID != "a" {
+		t.Errorf("Unexpected second page: %+v (%v)", page, err)
+	}
+	if page, _ := db.ListJobs("", "completed", "", 0); len(page) != 1 || page[0].ID != "a" {
+		t.Errorf("Expected only completed job, got %+v", page)
+	}
+	if page, _ := db.ListJobs("dashboard", "", "", 1); len(page) != 1 || page[0].ID != "c" {
+		t.Errorf("Expected newest job of the owner, got %+v", page)
+	}
+	if page, _ := db.ListJobs("reporter", "", "", 0); len(page) != 1 || page[0].ID != "b" {
+		t.Errorf("Expected only the owner's job, got %+v", page)
+	}
+	if page, _ := db.ListJobs("dashboard", "queued", "", 0); len(page) != 1 || page[0].ID != "c" {
+		t.Errorf("Expected queued job of the owner, got %+v", page)
+	}
+	unfinished, err := db.ListJobsInStates([]string{"queued", "running"})
+	if err != nil || len(unfinished) != 2 || unfinished[0].ID != "b" || unfinished[1].ID != "c" {
+		t.Errorf("Unexpected unfinished jobs: %+v (%v)", unfinished, err)
+	}
+}
+
+// TestJobLinksAndDeliveries проверяет адрес уведомления задания, размеры обложек ссылок
+// и журнал попыток доставки уведомлений.
+func TestJobLinksAndDeliveries(t *testing.T) {
+	dbFile := "test_job_deliveries.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+
+	job := &Job{ID: "hooked", Kind: "warm_cache", State: "queued", Total: 2, CreatedAt: time.Now(), CallbackURL: "https://example.com/hook", Priority: "interactive"}
+	if err := db.CreateJob(job, []string{"https://youtu.be/cached", "https://youtu.be/missing"}); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if err := db.InsertResource(&Resource{URL: "https://youtu.be/cached", Photo: []byte("12345")}); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	if stored, _ := db.GetJob("hooked"); stored == nil || stored.CallbackURL != job.CallbackURL || stored.Priority != job.Priority {
+		t.Errorf("Expected callback URL and priority to be stored, got %+v", stored)
+	}
+
+	links, err := db.JobLinks("hooked")
+	if err != nil || len(links) != 2 {
+		t.Fatalf("Unexpected job links: %+v (%v)", links, err)
+	}
+	if links[0].Size != 5 || links[1].Size != 0 || links[1].Status != JobLinkPending {
+		t.Errorf("Unexpected link sizes: %+v", links)
+	}
+
+	attempted := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, delivery := range []WebhookDelivery{
+		{JobID: "hooked", Attempt: 1, AttemptedAt: attempted, StatusCode: 503, Error: "unexpected status code 503"},
+		{JobID: "hooked", Attempt: 2, AttemptedAt: attempted.Add(time.Second), StatusCode: 200},
+	} {
+		if err := db.RecordWebhookDelivery(delivery); err != nil {
+			t.Fatalf("Failed to record delivery: %v", err)
+		}
+	}
+	deliveries, err := db.WebhookDeliveries("hooked")
+	if err != nil || len(deliveries) != 2 {
+		t.Fatalf("Unexpected deliveries: %+v (%v)", deliveries, err)
+	}
+	if deliveries[0].Error == "" || deliveries[1].StatusCode != 200 || deliveries[1].Error != "" || !deliveries[0].AttemptedAt.Equal(attempted) {
+		t.Errorf("Unexpected deliveries: %+v", deliveries)
+	}
+}
+
+// blockingDatabase задерживает чтение ресурса, пока тест не закроет release.
+type blockingDatabase struct {
+	Database
+	reading chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingDatabase) GetResourceByUrl(url string) (*Resource, error) {
+	resource, err := b.Database.GetResourceByUrl(url)
+	close(b.reading)
+	<-b.release
+	return resource, err
+}
+
+// TestMemoryCacheFillRace проверяет, что ресурс, прочитанный из базы до удаления,
+// не попадает в память после него.
+func TestMemoryCacheFillRace(t *testing.T) {
+	dbFile := "test_memory_cache_race.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	url := "https://youtu.be/race"
+	if err := db.InsertResource(&Resource{URL: url, Photo: []byte("stale")}); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+
+	blocking := &blockingDatabase{Database: db, reading: make(chan struct{}), release: make(chan struct{})}
+	cache := NewMemoryCachedDatabase(&MockLogger{}, blocking, 1024)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if res, err := cache.GetResourceByUrl(url); err != nil || res == nil {
+			t.Errorf("Expected the read started before deletion to see the resource, got %v (%v)", res, err)
+		}
+	}()
+
+	<-blocking.reading
+	if err := cache.DeleteResource(url); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	close(blocking.release)
+	<-done
+
+	if stats := cache.Stats(); stats.Entries != 0 {
+		t.Fatalf("Expected the stale read not to be cached, got %+v", stats)
+	}
+	blocking.reading, blocking.release = make(chan struct{}), make(chan struct{})
+	close(blocking.release)
+	if res, err := cache.GetResourceByUrl(url); err != nil || res != nil {
+		t.Errorf("Expected deleted resource to be gone, got %+v (%v)", res, err)
+	}
+	if len(cache.fills) != 0 || len(cache.generations) != 0 {
+		t.Errorf("Expected no generations to be kept without fills, got %v %v", cache.fills, cache.generations)
+	}
+}
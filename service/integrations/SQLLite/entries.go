@@ -0,0 +1,264 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+)
+
+// entryRow отражает строку выборки записей кэша с допускающими NULL колонками.
+type entryRow struct {
+	URL           string         `db:"url"`
+	VideoID       sql.NullString `db:"video_id"`
+	ContentHash   sql.NullString `db:"blob_hash"`
+	Size          int64          `db:"size"`
+	FetchedAt     sql.NullInt64  `db:"fetched_at"`
+	PHash         sql.NullInt64  `db:"phash"`
+	DominantColor sql.NullString `db:"dominant_color"`
+	BlurHash      sql.NullString `db:"blurhash"`
+	Variants      int            `db:"variants"`
+}
+
+// toEntry преобразует строку выборки в структуру Entry.
+func (r *entryRow) toEntry() Entry {
+	entry := Entry{
+		URL:           r.URL,
+		VideoID:       r.VideoID.String,
+		ContentHash:   r.ContentHash.String,
+		Size:          r.Size,
+		PHash:         uint64(r.PHash.Int64),
+		DominantColor: r.DominantColor.String,
+		BlurHash:      r.BlurHash.String,
+		Variants:      r.Variants,
+	}
+	if r.FetchedAt.Valid {
+		entry.FetchedAt = time.Unix(r.FetchedAt.Int64, 0)
+	}
+	return entry
+}
+
+// selectEntries возвращает построитель запроса метаданных записей: размер берётся из blobs,
+// количество вариантов — из variants. Содержимое изображений не читается.
+func (s *SQLDatabase) selectEntries() squirrel.SelectBuilder {
+	return s.Builder.
+		Select(
+			"r.url", "r.video_id", "r.blob_hash", "COALESCE(b.size, 0) AS size", "r.fetched_at",
+			"r.phash", "r.dominant_color", "r.blurhash",
+			"(SELECT COUNT(*) FROM variants v WHERE v.url = r.url) AS variants",
+		).
+		From("resources r").
+		LeftJoin("blobs b ON b.hash = r.blob_hash")
+}
+
+// entryConditions преобразует фильтр в условие WHERE.
+func entryConditions(filter EntryFilter) squirrel.And {
+	conditions := squirrel.And{}
+	if len(filter.URLs) > 0 {
+		conditions = append(conditions, squirrel.Eq{"r.url": filter.URLs})
+	}
+	if filter.VideoIDPrefix != "" {
+		// substr вместо LIKE: в идентификаторах YouTube встречается "_", который LIKE считает шаблоном
+		conditions = append(conditions, squirrel.Expr("substr(r.video_id, 1, ?) = ?", len(filter.VideoIDPrefix), filter.VideoIDPrefix))
+	}
+	if !filter.FetchedBefore.IsZero() {
+		conditions = append(conditions, squirrel.Lt{"r.fetched_at": filter.FetchedBefore.Unix()})
+	}
+	if !filter.FetchedAfter.IsZero() {
+		conditions = append(conditions, squirrel.GtOrEq{"r.fetched_at": filter.FetchedAfter.Unix()})
+	}
+	return conditions
+}
+
+// ListEntries возвращает страницу записей кэша, отсортированных по URL.
+// after — URL последней записи предыдущей страницы (пусто для первой страницы).
+// limit ограничивает размер страницы (0 — без ограничения).
+func (s *SQLDatabase) ListEntries(filter EntryFilter, after string, limit int) ([]Entry, error) {
+	conditions := entryConditions(filter)
+	if after != "" {
+		conditions = append(conditions, squirrel.Gt{"r.url": after})
+	}
+	builder := s.selectEntries().Where(conditions).OrderBy("r.url")
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build entries query", zap.Error(err))
+		return nil, err
+	}
+	var rows []entryRow
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute entries query", zap.Error(err))
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(rows))
+	for i := range rows {
+		entries = append(entries, rows[i].toEntry())
+	}
+	return entries, nil
+}
+
+// OldestEntries возвращает до limit записей, загруженных раньше fetchedBefore,
+// начиная с самых давних.
+func (s *SQLDatabase) OldestEntries(fetchedBefore time.Time, limit int) ([]Entry, error) {
+	query, args, err := s.selectEntries().
+		Where(entryConditions(EntryFilter{FetchedBefore: fetchedBefore})).
+		OrderBy("r.fetched_at", "r.url").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build oldest entries query", zap.Error(err))
+		return nil, err
+	}
+	var rows []entryRow
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute oldest entries query", zap.Error(err))
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(rows))
+	for i := range rows {
+		entries = append(entries, rows[i].toEntry())
+	}
+	return entries, nil
+}
+
+// GetEntry возвращает метаданные одной записи кэша без содержимого изображения.
+// Возвращает nil, если запись не найдена.
+func (s *SQLDatabase) GetEntry(url string) (*Entry, error) {
+	query, args, err := s.selectEntries().Where(squirrel.Eq{"r.url": url}).ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build entry query", zap.Error(err))
+		return nil, err
+	}
+	var row entryRow
+	err = s.DB.Get(&row, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		s.Logger.Error("Failed to execute entry query", zap.Error(err))
+		return nil, err
+	}
+	entry := row.toEntry()
+	return &entry, nil
+}
+
+// PurgeEntries удаляет записи, подходящие под фильтр, вместе с вариантами и освобождает содержимое.
+// Пустой фильтр удаляет все записи. Возвращает URL удалённых записей.
+func (s *SQLDatabase) PurgeEntries(filter EntryFilter) ([]string, error) {
+	query, args, err := s.Builder.
+		Select("r.url").
+		From("resources r").
+		Where(entryConditions(filter)).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build purge query", zap.Error(err))
+		return nil, err
+	}
+	var urls []string
+	if err := s.DB.Select(&urls, query, args...); err != nil {
+		s.Logger.Error("Failed to select entries to purge", zap.Error(err))
+		return nil, err
+	}
+
+	// Удаление идёт по одной записи, чтобы переиспользовать учёт ссылок на содержимое
+	purged := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if err := s.DeleteResource(url); err != nil {
+			return purged, err
+		}
+		purged = append(purged, url)
+	}
+	s.Logger.Info("Entries purged", zap.Int("count", len(purged)))
+	return purged, nil
+}
+
+// GetCacheStats возвращает агрегированную статистику кэша: количество записей и вариантов,
+// их объём и самые старую и свежую записи по времени загрузки.
+func (s *SQLDatabase) GetCacheStats() (*CacheStats, error) {
+	stats := &CacheStats{}
+	counters := []struct {
+		dest  interface{}
+		query squirrel.SelectBuilder
+	}{
+		{&stats.Entries, s.Builder.Select("COUNT(*)").From("resources")},
+		{&stats.OriginalBytes, s.Builder.Select("CAST(COALESCE(SUM(size), 0) AS BIGINT)").From("blobs")},
+		{&stats.Variants, s.Builder.Select("COUNT(*)").From("variants")},
+		{&stats.VariantBytes, s.Builder.Select("CAST(COALESCE(SUM(LENGTH(photo)), 0) AS BIGINT)").From("variants")},
+	}
+	for _, counter := range counters {
+		query, args, err := counter.query.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.DB.Get(counter.dest, query, args...); err != nil {
+			s.Logger.Error("Failed to execute stats query", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	var err error
+	if stats.OldestURL, stats.OldestFetchedAt, err = s.edgeEntry("fetched_at ASC"); err != nil {
+		return nil, err
+	}
+	if stats.NewestURL, stats.NewestFetchedAt, err = s.edgeEntry("fetched_at DESC"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// edgeEntry возвращает ссылку и время загрузки первой записи в указанном порядке сортировки.
+func (s *SQLDatabase) edgeEntry(order string) (string, time.Time, error) {
+	query, args, err := s.Builder.
+		Select("url", "fetched_at").
+		From("resources").
+		Where(squirrel.NotEq{"fetched_at": nil}).
+		OrderBy(order, "url").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var row struct {
+		URL       string `db:"url"`
+		FetchedAt int64  `db:"fetched_at"`
+	}
+	err = s.DB.Get(&row, query, args...)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, nil
+	} else if err != nil {
+		s.Logger.Error("Failed to execute stats query", zap.Error(err))
+		return "", time.Time{}, err
+	}
+	return row.URL, time.Unix(row.FetchedAt, 0), nil
+}
+
+/*
+toEntry преобразует строку выборки в структуру Entry.
+
+selectEntries возвращает построитель запроса метаданных записей без содержимого изображений.
+
+entryConditions преобразует фильтр в условие WHERE.
+filter: условия отбора записей.
+
+ListEntries возвращает страницу записей кэша, отсортированных по URL.
+filter: условия отбора записей.
+after: URL последней записи предыдущей страницы.
+limit: размер страницы (0 — без ограничения).
+
+OldestEntries возвращает до limit записей, загруженных раньше fetchedBefore, начиная с самых давних.
+fetchedBefore: граница времени загрузки.
+limit: максимум записей.
+
+GetEntry возвращает метаданные одной записи кэша без содержимого изображения.
+url: URL ресурса.
+
+PurgeEntries удаляет записи, подходящие под фильтр, и возвращает их URL.
+filter: условия отбора записей (пустой — все записи).
+
+GetCacheStats возвращает агрегированную статистику кэша.
+
+edgeEntry возвращает ссылку и время загрузки первой записи в указанном порядке сортировки.
+order: выражение сортировки по fetched_at.
+*/
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"shelon_server/utilss/logger"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// FallbackBlobStore переносит содержимое из прежнего хранилища в новое при смене backend.
+// Запись идёт только в основное хранилище; содержимое, которого там нет, читается из прежнего
+// и при первом чтении переносится: сохраняется в основном и удаляется из прежнего.
+type FallbackBlobStore struct {
+	Logger   logger.Logger
+	Primary  BlobStore // Хранилище выбранного backend.
+	Previous BlobStore // Хранилище, в котором содержимое лежало до смены backend.
+	// mu упорядочивает перенос и удаление одного содержимого, чтобы удалённое содержимое
+	// не вернулось в основное хранилище из переноса, начатого раньше удаления.
+	mu sync.Mutex
+}
+
+// NewFallbackBlobStore создает хранилище, читающее недостающее содержимое из прежнего хранилища.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// primary: хранилище выбранного backend.
+// previous: хранилище прежнего backend.
+func NewFallbackBlobStore(logger logger.Logger, primary, previous BlobStore) *FallbackBlobStore {
+	return &FallbackBlobStore{Logger: logger, Primary: primary, Previous: previous}
+}
+
+// Put сохраняет содержимое в основном хранилище.
+func (fs *FallbackBlobStore) Put(hash string, data []byte) error {
+	return fs.Primary.Put(hash, data)
+}
+
+// Get возвращает содержимое из основного хранилища, а при его отсутствии переносит
+// содержимое из прежнего. Возвращает nil, если содержимого нет ни в одном из них.
+func (fs *FallbackBlobStore) Get(hash string) ([]byte, error) {
+	data, err := fs.Primary.Get(hash)
+	if err != nil || data != nil {
+		return data, err
+	}
+	return fs.move(hash)
+}
+
+// Delete удаляет содержимое из обоих хранилищ.
+func (fs *FallbackBlobStore) Delete(hash string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.Primary.Delete(hash); err != nil {
+		return err
+	}
+	return fs.Previous.Delete(hash)
+}
+
+// move переносит содержимое из прежнего хранилища в основное. Прежнее хранилище читается
+// под блокировкой: содержимое, удалённое за это время, не переносится.
+func (fs *FallbackBlobStore) move(hash string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	data, err := fs.Previous.Get(hash)
+	if err != nil || data == nil {
+		return data, err
+	}
+	if err := fs.Primary.Put(hash, data); err != nil {
+		fs.Logger.Warn("Failed to move blob to the new store", zap.String("hash", hash), zap.Error(err))
+		return data, nil
+	}
+	if err := fs.Previous.Delete(hash); err != nil {
+		fs.Logger.Warn("Failed to delete moved blob from the previous store", zap.String("hash", hash), zap.Error(err))
+	}
+	return data, nil
+}
+
+/*
+NewFallbackBlobStore создает хранилище, читающее недостающее содержимое из прежнего хранилища.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+primary: хранилище выбранного backend.
+previous: хранилище прежнего backend.
+
+Put сохраняет содержимое в основном хранилище.
+hash: SHA-256 содержимого.
+data: содержимое изображения.
+
+Get возвращает содержимое из основного хранилища или переносит его из прежнего.
+hash: SHA-256 содержимого.
+
+Delete удаляет содержимое из обоих хранилищ.
+hash: SHA-256 содержимого.
+
+move переносит содержимое из прежнего хранилища в основное.
+hash: SHA-256 содержимого.
+*/
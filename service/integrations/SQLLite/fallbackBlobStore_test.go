@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestFallbackBlobStore проверяет смену backend с таблицы blob_data на файловую систему:
+// прежние изображения читаются и переносятся при обращении и фоновым проходом MigrateBlobs.
+func TestFallbackBlobStore(t *testing.T) {
+	dbFile := "test_fallback_blobs.db"
+	defer os.Remove(dbFile)
+
+	db, err := NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	resources := []*Resource{
+		{URL: "https://youtu.be/read", Photo: []byte("read on request")},
+		{URL: "https://youtu.be/migrated", Photo: []byte("migrated in background")},
+		{URL: "https://youtu.be/deleted", Photo: []byte("deleted before migration")},
+	}
+	for _, resource := range resources {
+		if err := db.InsertResource(resource); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+
+	// Смена backend: прежнее хранилище — таблица blob_data
+	previous := db.Blobs
+	files, err := NewFileBlobStore(&MockLogger{}, t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create file blob store: %v", err)
+	}
+	db.Blobs = NewFallbackBlobStore(&MockLogger{}, files, previous)
+	blobRows := func() int {
+		var rows int
+		if err := db.DB.Get(&rows, `SELECT COUNT(*) FROM blob_data`); err != nil {
+			t.Fatalf("Failed to count blob data: %v", err)
+		}
+		return rows
+	}
+
+	photo, err := db.GetPhotoByUrl(resources[0].URL)
+	if err != nil || string(photo) != "read on request" {
+		t.Fatalf("Expected photo from the previous store, got %q (%v)", photo, err)
+	}
+	if data, _ := files.Get(resources[0].ContentHash); string(data) != "read on request" {
+		t.Errorf("Expected photo to be moved to the new store, got %q", data)
+	}
+	if rows := blobRows(); rows != 2 {
+		t.Errorf("Expected moved photo to be removed from blob_data, got %d rows", rows)
+	}
+
+	if err := db.DeleteResource(resources[2].URL); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	found, err := db.MigrateBlobs(context.Background())
+	if err != nil || found != 2 {
+		t.Fatalf("Expected two blobs to be migrated, got %d (%v)", found, err)
+	}
+	if rows := blobRows(); rows != 0 {
+		t.Errorf("Expected blob_data to be empty after migration, got %d rows", rows)
+	}
+	if data, _ := files.Get(resources[2].ContentHash); data != nil {
+		t.Errorf("Expected deleted photo not to be migrated, got %q", data)
+	}
+
+	// После переноса прежнее хранилище можно отключить
+	db.Blobs = files
+	if photo, err := db.GetPhotoByUrl(resources[1].URL); err != nil || string(photo) != "migrated in background" {
+		t.Errorf("Expected migrated photo from the new store, got %q (%v)", photo, err)
+	}
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+)
+
+// FileBlobStore хранит содержимое изображений в файловой системе.
+// Файлы раскладываются по двухуровневому дереву каталогов по первым символам хеша
+// (ab/cd/abcd...), чтобы в одном каталоге не накапливалось слишком много файлов.
+type FileBlobStore struct {
+	Logger logger.Logger
+	Root   string
+}
+
+// NewFileBlobStore создает файловое хранилище содержимого с корнем в указанном каталоге.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// root: корневой каталог хранилища (создается при необходимости).
+func NewFileBlobStore(logger logger.Logger, root string) (*FileBlobStore, error) {
+	if root == "" {
+		return nil, errors.New("blob directory is not configured")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		logger.Error("Failed to create blob directory", zap.String("root", root), zap.Error(err))
+		return nil, err
+	}
+	return &FileBlobStore{Logger: logger, Root: root}, nil
+}
+
+// Put записывает содержимое в файл атомарно: сначала во временный файл в том же каталоге,
+// затем переименованием на итоговое имя. Если файл с таким хешем уже есть, запись пропускается.
+func (fs *FileBlobStore) Put(hash string, data []byte) error {
+	path, err := fs.path(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fs.Logger.Error("Failed to create blob shard directory", zap.String("dir", dir), zap.Error(err))
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, hash+".tmp-*")
+	if err != nil {
+		fs.Logger.Error("Failed to create temporary blob file", zap.Error(err))
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // После успешного переименования файла уже нет
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		fs.Logger.Error("Failed to write blob file", zap.String("hash", hash), zap.Error(err))
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		fs.Logger.Error("Failed to rename blob file", zap.String("hash", hash), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Get читает содержимое по хешу. Возвращает nil, если файла нет.
+func (fs *FileBlobStore) Get(hash string) ([]byte, error) {
+	path, err := fs.path(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		fs.Logger.Error("Failed to read blob file", zap.String("hash", hash), zap.Error(err))
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete удаляет файл содержимого. Отсутствие файла ошибкой не считается.
+func (fs *FileBlobStore) Delete(hash string) error {
+	path, err := fs.path(hash)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fs.Logger.Error("Failed to delete blob file", zap.String("hash", hash), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// path возвращает путь к файлу содержимого. Хеш проверяется, чтобы исключить выход за пределы корня.
+func (fs *FileBlobStore) path(hash string) (string, error) {
+	if err := validateBlobHash(hash); err != nil {
+		return "", err
+	}
+	return filepath.Join(fs.Root, hash[0:2], hash[2:4], hash), nil
+}
+
+/*
+NewFileBlobStore создает файловое хранилище содержимого с корнем в указанном каталоге.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+root: корневой каталог хранилища (создается при необходимости).
+
+Put записывает содержимое в файл атомарно через временный файл и переименование.
+hash: SHA-256 содержимого.
+data: содержимое изображения.
+
+Get читает содержимое по хешу. Возвращает nil, если файла нет.
+hash: SHA-256 содержимого.
+
+Delete удаляет файл содержимого.
+hash: SHA-256 содержимого.
+
+path возвращает путь к файлу содержимого в дереве каталогов.
+*/
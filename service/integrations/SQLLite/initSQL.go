@@ -2,7 +2,13 @@ package database
 
 import (
 	"database/sql"
+	"shelon_server/imaging"
+	youtubeclient "shelon_server/integrations/youtubeCLient"
 	"shelon_server/utilss/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -10,38 +16,63 @@ import (
 	"go.uber.org/zap"
 )
 
-// SQLiteDatabase структура для работы с базой данных SQLite.
-type SQLiteDatabase struct {
+// SQLDatabase реализует Database поверх реляционной базы: SQLite или PostgreSQL (см. PostgresDatabase).
+// Запросы строятся squirrel-построителем с плейсхолдерами диалекта, вариант миграций выбирается по dialect.
+// Содержимое изображений хранится в BlobStore: по умолчанию в таблице blob_data той же базы.
+type SQLDatabase struct {
 	Logger  logger.Logger
 	DB      *sqlx.DB
 	Builder squirrel.StatementBuilderType
+	Blobs   BlobStore
+	dialect string // Диалект SQL, определяющий вариант миграций схемы.
+	// statements кэширует подготовленные запросы чтения; nil, если они отключены.
+	statements *statementCache
+	// blobMu не даёт удалить содержимое, пока вставка между Put и фиксацией транзакции
+	// ещё не зарегистрировала на него ссылку: вставки берут блокировку на чтение, сборка — на запись.
+	// Между процессами, которые делят базу PostgreSQL, то же обеспечивает lockBlob.
+	blobMu sync.RWMutex
 }
 
-// NewSQLiteDatabase создает новый экземпляр базы данных.
+// Поддерживаемые диалекты SQL.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// NewSQLiteDatabase создает новый экземпляр базы данных с параметрами подключения по умолчанию.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// dbName: имя файла базы данных.
+func NewSQLiteDatabase(logger logger.Logger, dbName string) (*SQLDatabase, error) {
+	return NewSQLiteDatabaseWithOptions(logger, dbName, DefaultConnectionOptions())
+}
+
+// NewSQLiteDatabaseWithOptions создает новый экземпляр базы данных с заданными параметрами подключения.
 // logger: экземпляр интерфейса logger.Logger для логирования действий.
 // dbName: имя файла базы данных.
-func NewSQLiteDatabase(logger logger.Logger, dbName string) (*SQLiteDatabase, error) {
-	db, err := sqlx.Open("sqlite3", dbName)
+// options: режим журнала, busy timeout, уровень синхронизации, пул соединений и подготовленные запросы.
+func NewSQLiteDatabaseWithOptions(logger logger.Logger, dbName string, options ConnectionOptions) (*SQLDatabase, error) {
+	db, err := sqlx.Open("sqlite3", sqliteDSN(dbName, options))
 	if err != nil {
 		return nil, err
 	}
-	return &SQLiteDatabase{
+	configurePool(db, options)
+	builder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question)
+	s := &SQLDatabase{
 		Logger:  logger,
 		DB:      db,
-		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question),
-	}, nil
+		Builder: builder,
+		Blobs:   &SQLBlobStore{Logger: logger, DB: db, Builder: builder},
+		dialect: DialectSQLite,
+	}
+	if options.PreparedStatements {
+		s.statements = newStatementCache(db)
+	}
+	return s, nil
 }
 
-// InitDatabase инициализирует базу данных, создавая таблицы при необходимости.
-func (s *SQLiteDatabase) InitDatabase() error {
-	createTableQuery := `
-    CREATE TABLE IF NOT EXISTS resources (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        url TEXT NOT NULL,
-        photo BLOB
-    );`
-	_, err := s.DB.Exec(createTableQuery)
-	if err != nil {
+// InitDatabase инициализирует базу данных, применяя недостающие миграции схемы.
+func (s *SQLDatabase) InitDatabase() error {
+	if err := s.migrate(); err != nil {
 		s.Logger.Error("Failed to initialize database", zap.Error(err))
 		return err
 	}
@@ -49,28 +80,252 @@ func (s *SQLiteDatabase) InitDatabase() error {
 	return nil
 }
 
-// InsertResource добавляет новый ресурс в базу данных.
-func (s *SQLiteDatabase) InsertResource(url string, photo []byte) error {
+// InsertResource сохраняет ресурс вместе с характеристиками изображения.
+// URL уникален: если ресурс уже есть, его строка обновляется (INSERT ... ON CONFLICT DO UPDATE),
+// ссылка на прежнее содержимое освобождается, а производные варианты удаляются, так как построены
+// по старому изображению. Изображение сохраняется в хранилище по хешу SHA-256 содержимого:
+// одинаковые изображения хранятся один раз, а ресурс ссылается на хеш. Вычисленный хеш
+// записывается в resource.ContentHash.
+// Если характеристики не вычислены (HasFeatures не установлен), соответствующие колонки остаются NULL.
+// Время загрузки (fetched_at) берётся из resource.FetchedAt (например, при импорте архива),
+// а если оно не задано, выставляется текущим.
+func (s *SQLDatabase) InsertResource(resource *Resource) error {
+	hash := contentHash(resource.Photo)
+	videoID := resource.VideoID
+	if videoID == "" {
+		videoID, _ = youtubeclient.ExtractVideoID(resource.URL)
+	}
+	fetchedAt := resource.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	values := map[string]interface{}{
+		"url":            resource.URL,
+		"blob_hash":      hash,
+		"phash":          nil,
+		"dominant_color": nil,
+		"blurhash":       nil,
+		"preview":        resource.Preview,
+		"video_id":       nil,
+		"fetched_at":     fetchedAt.Unix(),
+	}
+	if resource.HasFeatures {
+		values["phash"] = int64(resource.PHash)
+		values["dominant_color"] = resource.DominantColor
+	}
+	if resource.BlurHash != "" {
+		values["blurhash"] = resource.BlurHash
+	}
+	if videoID != "" {
+		values["video_id"] = videoID
+	}
+
+	orphaned, err := s.upsertResource(resource, hash, values)
+	s.collectBlobs(orphaned)
+	if err != nil {
+		return err
+	}
+	resource.ContentHash = hash
+	resource.VideoID = videoID
+	resource.FetchedAt = fetchedAt
+	s.Logger.Info("Resource with URL saved successfully", zap.String("url", resource.URL))
+	return nil
+}
+
+// upsertResource записывает содержимое в хранилище и сохраняет строку ресурса в одной транзакции.
+// Возвращает хеши содержимого, на которое больше никто не ссылается; при ошибке после записи
+// содержимого среди них оказывается и hash, чтобы не оставить в хранилище файл без ссылок.
+// Содержимое адресуется хешем, поэтому повторная запись безопасна. В SQLite транзакция сразу
+// берёт блокировку всей базы на запись, и запись в blob_data с другого соединения ждала бы её
+// фиксации, поэтому содержимое записывается до транзакции. В PostgreSQL оно записывается внутри
+// транзакции под разделяемой блокировкой хеша, чтобы сборка другой реплики не удалила его
+// до фиксации ссылки.
+func (s *SQLDatabase) upsertResource(resource *Resource, hash string, values map[string]interface{}) (orphaned []string, err error) {
+	s.blobMu.RLock()
+	defer s.blobMu.RUnlock()
+
+	stored := false
+	defer func() {
+		if err != nil && stored {
+			orphaned = []string{hash}
+		}
+	}()
+
+	putInTx := s.dialect == DialectPostgres
+	if !putInTx {
+		if err := s.Blobs.Put(hash, resource.Photo); err != nil {
+			s.Logger.Error("Failed to store blob", zap.Error(err))
+			return nil, err
+		}
+		stored = true
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		s.Logger.Error("Failed to begin insert transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if putInTx {
+		if err := s.lockBlob(tx, hash, false); err != nil {
+			s.Logger.Error("Failed to lock blob", zap.Error(err))
+			return nil, err
+		}
+		if err := s.Blobs.Put(hash, resource.Photo); err != nil {
+			s.Logger.Error("Failed to store blob", zap.Error(err))
+			return nil, err
+		}
+		stored = true
+	}
+	if err := s.lockResource(tx, resource.URL); err != nil {
+		s.Logger.Error("Failed to lock resource", zap.Error(err))
+		return nil, err
+	}
+	previous, err := s.resourceBlobHash(tx, resource.URL)
+	if err != nil {
+		s.Logger.Error("Failed to select previous blob reference", zap.Error(err))
+		return nil, err
+	}
+	if err := s.acquireBlob(tx, hash, len(resource.Photo)); err != nil {
+		s.Logger.Error("Failed to register blob reference", zap.Error(err))
+		return nil, err
+	}
+
+	// При конфликте обновляются все переданные колонки, кроме самого URL
+	updates := make([]string, 0, len(values))
+	for column := range values {
+		if column != "url" {
+			updates = append(updates, column+" = excluded."+column)
+		}
+	}
+	sort.Strings(updates)
 	query, args, err := s.Builder.
 		Insert("resources").
-		Columns("url", "photo").
-		Values(url, photo).
+		SetMap(values).
+		Suffix("ON CONFLICT (url) DO UPDATE SET " + strings.Join(updates, ", ")).
 		ToSql()
 	if err != nil {
 		s.Logger.Error("Failed to build insert query", zap.Error(err))
-		return err
+		return nil, err
 	}
-	_, execErr := s.DB.Exec(query, args...)
+	_, execErr := tx.Exec(query, args...)
 	if execErr != nil {
 		s.Logger.Error("Failed to execute insert query", zap.Error(execErr))
-		return execErr
+		return nil, execErr
+	}
+
+	if previous != "" {
+		deleted, err := s.releaseBlob(tx, previous)
+		if err != nil {
+			s.Logger.Error("Failed to release previous blob", zap.String("hash", previous), zap.Error(err))
+			return nil, err
+		}
+		if deleted {
+			orphaned = append(orphaned, previous)
+		}
+		if previous != hash {
+			query, args, err = s.Builder.Delete("variants").Where(squirrel.Eq{"url": resource.URL}).ToSql()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				s.Logger.Error("Failed to delete stale variants", zap.Error(err))
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit insert transaction", zap.Error(err))
+		return nil, err
+	}
+	return orphaned, nil
+}
+
+// lockResource сериализует изменения одного URL в PostgreSQL с помощью транзакционной
+// advisory-блокировки: иначе две транзакции, одновременно вставляющие новый ресурс, не увидели бы
+// друг друга и счётчик ссылок разошёлся бы. В SQLite транзакции открываются как BEGIN IMMEDIATE
+// и уже выполняются по одной, поэтому блокировка не нужна.
+func (s *SQLDatabase) lockResource(tx *sqlx.Tx, url string) error {
+	if s.dialect != DialectPostgres {
+		return nil
+	}
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, url)
+	return err
+}
+
+// resourceBlobHash возвращает хеш содержимого, на которое ссылается ресурс, или пустую строку,
+// если ресурса нет.
+func (s *SQLDatabase) resourceBlobHash(tx *sqlx.Tx, url string) (string, error) {
+	query, args, err := s.Builder.
+		Select("blob_hash").
+		From("resources").
+		Where(squirrel.And{squirrel.Eq{"url": url}, squirrel.NotEq{"blob_hash": nil}}).
+		ToSql()
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	err = tx.Get(&hash, query, args...)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// DeleteResource удаляет ресурс и его производные варианты.
+// Счётчик ссылок на содержимое уменьшается; содержимое, на которое больше никто не ссылается, удаляется.
+func (s *SQLDatabase) DeleteResource(url string) error {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		s.Logger.Error("Failed to begin delete transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.lockResource(tx, url); err != nil {
+		s.Logger.Error("Failed to lock resource", zap.Error(err))
+		return err
+	}
+	hash, err := s.resourceBlobHash(tx, url)
+	if err != nil {
+		s.Logger.Error("Failed to select blob reference", zap.Error(err))
+		return err
+	}
+
+	for _, table := range []string{"resources", "variants"} {
+		query, args, err := s.Builder.Delete(table).Where(squirrel.Eq{"url": url}).ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			s.Logger.Error("Failed to delete resource", zap.String("table", table), zap.Error(err))
+			return err
+		}
+	}
+	var orphaned []string
+	if hash != "" {
+		deleted, err := s.releaseBlob(tx, hash)
+		if err != nil {
+			s.Logger.Error("Failed to release blob", zap.String("hash", hash), zap.Error(err))
+			return err
+		}
+		if deleted {
+			orphaned = append(orphaned, hash)
+		}
 	}
-	s.Logger.Info("Resource with URL added successfully", zap.String("url", url))
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit delete transaction", zap.Error(err))
+		return err
+	}
+	s.collectBlobs(orphaned)
+	s.Logger.Info("Resource deleted successfully", zap.String("url", url))
 	return nil
 }
 
 // ResourceExists проверяет, существует ли ресурс с заданным URL.
-func (s *SQLiteDatabase) ResourceExists(url string) (bool, error) {
+func (s *SQLDatabase) ResourceExists(url string) (bool, error) {
 	query, args, err := s.Builder.
 		Select("COUNT(*)").
 		From("resources").
@@ -81,7 +336,7 @@ func (s *SQLiteDatabase) ResourceExists(url string) (bool, error) {
 		return false, err
 	}
 	var count int
-	err = s.DB.Get(&count, query, args...)
+	err = s.get(&count, query, args...)
 	if err != nil {
 		s.Logger.Error("Failed to execute existence check query", zap.Error(err))
 		return false, err
@@ -90,7 +345,10 @@ func (s *SQLiteDatabase) ResourceExists(url string) (bool, error) {
 }
 
 // Close закрывает соединение с базой данных.
-func (s *SQLiteDatabase) Close() error {
+func (s *SQLDatabase) Close() error {
+	if s.statements != nil {
+		s.statements.close()
+	}
 	err := s.DB.Close()
 	if err != nil {
 		s.Logger.Error("Failed to close database connection", zap.Error(err))
@@ -101,18 +359,19 @@ func (s *SQLiteDatabase) Close() error {
 }
 
 // GetPhotoByUrl получает фото по URL из базы данных.
-func (s *SQLiteDatabase) GetPhotoByUrl(url string) ([]byte, error) {
+func (s *SQLDatabase) GetPhotoByUrl(url string) ([]byte, error) {
 	query, args, err := s.Builder.
-		Select("photo").
+		Select("blob_hash").
 		From("resources").
-		Where(squirrel.Eq{"url": url}).
+		Where(squirrel.And{squirrel.Eq{"url": url}, squirrel.NotEq{"blob_hash": nil}}).
+		Limit(1).
 		ToSql()
 	if err != nil {
 		s.Logger.Error("Failed to build select query", zap.Error(err))
 		return nil, err
 	}
-	var photo []byte
-	err = s.DB.Get(&photo, query, args...)
+	var hash string
+	err = s.get(&hash, query, args...)
 	if err == sql.ErrNoRows {
 		s.Logger.Info("No photo found for the given URL", zap.String("url", url))
 		return nil, nil // Если фото не найдено, возвращаем nil
@@ -120,20 +379,279 @@ func (s *SQLiteDatabase) GetPhotoByUrl(url string) ([]byte, error) {
 		s.Logger.Error("Failed to execute select query", zap.Error(err))
 		return nil, err
 	}
+	photo, err := s.Blobs.Get(hash)
+	if err != nil {
+		return nil, err
+	}
 	s.Logger.Info("Photo retrieved successfully", zap.String("url", url))
 	return photo, nil
 }
 
+// resourceRow отражает строку таблицы resources с допускающими NULL колонками.
+type resourceRow struct {
+	URL           string         `db:"url"`
+	ContentHash   sql.NullString `db:"blob_hash"`
+	PHash         sql.NullInt64  `db:"phash"`
+	DominantColor sql.NullString `db:"dominant_color"`
+	BlurHash      sql.NullString `db:"blurhash"`
+	Preview       []byte         `db:"preview"`
+}
+
+// toResource преобразует строку таблицы в структуру Resource.
+func (r *resourceRow) toResource() *Resource {
+	return &Resource{
+		URL:           r.URL,
+		ContentHash:   r.ContentHash.String,
+		PHash:         uint64(r.PHash.Int64),
+		DominantColor: r.DominantColor.String,
+		BlurHash:      r.BlurHash.String,
+		Preview:       r.Preview,
+		HasFeatures:   r.PHash.Valid,
+	}
+}
+
+// GetResourceByUrl получает ресурс вместе с характеристиками изображения по URL.
+// Возвращает nil, если ресурс не найден.
+func (s *SQLDatabase) GetResourceByUrl(url string) (*Resource, error) {
+	query, args, err := s.Builder.
+		Select("url", "blob_hash", "phash", "dominant_color", "blurhash", "preview").
+		From("resources").
+		Where(squirrel.Eq{"url": url}).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build select query", zap.Error(err))
+		return nil, err
+	}
+	var row resourceRow
+	err = s.get(&row, query, args...)
+	if err == sql.ErrNoRows {
+		s.Logger.Info("No resource found for the given URL", zap.String("url", url))
+		return nil, nil
+	} else if err != nil {
+		s.Logger.Error("Failed to execute select query", zap.Error(err))
+		return nil, err
+	}
+	resource := row.toResource()
+	// Если содержимое отсутствует в хранилище, Photo остаётся nil и обложка будет загружена заново
+	if resource.ContentHash != "" {
+		resource.Photo, err = s.Blobs.Get(resource.ContentHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resource, nil
+}
+
+// FindSimilar возвращает закэшированные ресурсы, перцептивный хеш которых отличается
+// от заданного не более чем на maxDistance бит. Результат упорядочен по возрастанию расстояния
+// и ограничен limit записями (0 — без ограничения).
+func (s *SQLDatabase) FindSimilar(hash uint64, maxDistance int, limit int) ([]SimilarResource, error) {
+	query, args, err := s.Builder.
+		Select("url", "phash", "dominant_color").
+		From("resources").
+		Where(squirrel.NotEq{"phash": nil}).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build similarity query", zap.Error(err))
+		return nil, err
+	}
+	var rows []resourceRow
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute similarity query", zap.Error(err))
+		return nil, err
+	}
+
+	// SQLite не умеет считать количество единичных битов, поэтому расстояние вычисляется на стороне приложения
+	var similar []SimilarResource
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if seen[row.URL] {
+			continue
+		}
+		seen[row.URL] = true
+		distance := imaging.HammingDistance(hash, uint64(row.PHash.Int64))
+		if distance > maxDistance {
+			continue
+		}
+		similar = append(similar, SimilarResource{
+			URL:           row.URL,
+			PHash:         uint64(row.PHash.Int64),
+			DominantColor: row.DominantColor.String,
+			Distance:      distance,
+		})
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Distance < similar[j].Distance
+	})
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+	s.Logger.Info("Similar resources found", zap.Int("count", len(similar)))
+	return similar, nil
+}
+
+// UpdateFeatures сохраняет вычисленные характеристики изображения уже закэшированного ресурса,
+// не трогая его содержимое и время загрузки. Используется для записей, сохранённых до появления
+// характеристик или без них.
+func (s *SQLDatabase) UpdateFeatures(resource *Resource) error {
+	query, args, err := s.Builder.
+		Update("resources").
+		Set("phash", int64(resource.PHash)).
+		Set("dominant_color", resource.DominantColor).
+		Set("blurhash", resource.BlurHash).
+		Set("preview", resource.Preview).
+		Where(squirrel.Eq{"url": resource.URL}).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build features update query", zap.Error(err))
+		return err
+	}
+	if _, err := s.DB.Exec(query, args...); err != nil {
+		s.Logger.Error("Failed to update resource features", zap.String("url", resource.URL), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ResourcesWithoutFeatures возвращает до limit ссылок ресурсов без вычисленных характеристик
+// (phash равен NULL), следующих по алфавиту после after.
+func (s *SQLDatabase) ResourcesWithoutFeatures(after string, limit int) ([]string, error) {
+	query, args, err := s.Builder.
+		Select("url").
+		From("resources").
+		Where(squirrel.Eq{"phash": nil}).
+		Where(squirrel.Gt{"url": after}).
+		OrderBy("url").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build features backfill query", zap.Error(err))
+		return nil, err
+	}
+	var urls []string
+	if err := s.DB.Select(&urls, query, args...); err != nil {
+		s.Logger.Error("Failed to list resources without features", zap.Error(err))
+		return nil, err
+	}
+	return urls, nil
+}
+
+// GetPlaceholders возвращает заглушки для закэшированных обложек по списку URL.
+// Само изображение не читается; ссылки, отсутствующие в кэше, в результат не попадают.
+func (s *SQLDatabase) GetPlaceholders(urls []string) ([]Placeholder, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	query, args, err := s.Builder.
+		Select("url", "blurhash", "preview", "dominant_color").
+		From("resources").
+		Where(squirrel.Eq{"url": urls}).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build placeholders query", zap.Error(err))
+		return nil, err
+	}
+	var rows []resourceRow
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute placeholders query", zap.Error(err))
+		return nil, err
+	}
+
+	placeholders := make([]Placeholder, 0, len(rows))
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if seen[row.URL] {
+			continue
+		}
+		seen[row.URL] = true
+		placeholders = append(placeholders, Placeholder{
+			URL:           row.URL,
+			BlurHash:      row.BlurHash.String,
+			Preview:       row.Preview,
+			DominantColor: row.DominantColor.String,
+		})
+	}
+	return placeholders, nil
+}
+
+// GetVariant получает производный вариант обложки (например, с наложенным логотипом).
+// Возвращает nil, если вариант ещё не сохранён.
+func (s *SQLDatabase) GetVariant(url, variantKey string) ([]byte, error) {
+	query, args, err := s.Builder.
+		Select("photo").
+		From("variants").
+		Where(squirrel.Eq{"url": url, "variant_key": variantKey}).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build variant select query", zap.Error(err))
+		return nil, err
+	}
+	var photo []byte
+	err = s.get(&photo, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		s.Logger.Error("Failed to execute variant select query", zap.Error(err))
+		return nil, err
+	}
+	return photo, nil
+}
+
+// InsertVariant сохраняет производный вариант обложки. Исходное изображение в resources не изменяется.
+// Существующий вариант с тем же ключом перезаписывается.
+func (s *SQLDatabase) InsertVariant(url, variantKey string, photo []byte) error {
+	query, args, err := s.Builder.
+		Insert("variants").
+		Columns("url", "variant_key", "photo").
+		Values(url, variantKey, photo).
+		Suffix("ON CONFLICT (url, variant_key) DO UPDATE SET photo = excluded.photo").
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build variant insert query", zap.Error(err))
+		return err
+	}
+	if _, err := s.DB.Exec(query, args...); err != nil {
+		s.Logger.Error("Failed to execute variant insert query", zap.Error(err))
+		return err
+	}
+	s.Logger.Info("Variant saved successfully", zap.String("url", url), zap.String("variant", variantKey))
+	return nil
+}
+
 /*
-NewSQLiteDatabase создает новый экземпляр базы данных.
+NewSQLiteDatabase создает новый экземпляр базы данных с параметрами подключения по умолчанию.
+Содержимое изображений по умолчанию хранится в таблице blob_data той же базы.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+dbName: имя файла базы данных.
+
+NewSQLiteDatabaseWithOptions создает новый экземпляр базы данных с заданными параметрами подключения.
 logger: экземпляр интерфейса logger.Logger для логирования действий.
 dbName: имя файла базы данных.
+options: параметры журнала, блокировок, пула соединений и подготовленных запросов.
+
+InitDatabase инициализирует базу данных, применяя недостающие миграции схемы.
+
+InsertResource сохраняет ресурс вместе с характеристиками изображения.
+Существующий ресурс с тем же URL обновляется, ссылка на прежнее содержимое освобождается.
+Изображение сохраняется в хранилище по хешу SHA-256 содержимого.
+resource: ресурс с URL, содержимым фотографии и её характеристиками.
+
+upsertResource записывает содержимое в хранилище и сохраняет строку ресурса в одной транзакции.
+resource: сохраняемый ресурс.
+hash: SHA-256 содержимого.
+values: значения колонок строки ресурса (nil — NULL).
+
+lockResource сериализует изменения одного URL в PostgreSQL.
+tx: текущая транзакция.
+url: URL ресурса.
 
-InitDatabase инициализирует базу данных, создавая таблицы при необходимости.
+resourceBlobHash возвращает хеш содержимого, на которое ссылается ресурс.
+tx: текущая транзакция.
+url: URL ресурса.
 
-InsertResource добавляет новый ресурс в базу данных.
+DeleteResource удаляет ресурс и его производные варианты, освобождая ссылки на содержимое.
 url: URL ресурса.
-photo: массив байтов фотографии.
 
 ResourceExists проверяет, существует ли ресурс с заданным URL.
 url: URL ресурса.
@@ -142,4 +660,31 @@ Close закрывает соединение с базой данных.
 
 GetPhotoByUrl получает фото по URL из базы данных.
 url: URL ресурса.
+
+GetResourceByUrl получает ресурс вместе с характеристиками изображения по URL.
+url: URL ресурса.
+
+FindSimilar возвращает закэшированные ресурсы в пределах заданного расстояния Хэмминга.
+hash: перцептивный хеш искомого изображения.
+maxDistance: максимальное расстояние Хэмминга.
+limit: максимальное количество результатов (0 — без ограничения).
+
+UpdateFeatures сохраняет вычисленные характеристики изображения закэшированного ресурса.
+resource: ресурс с URL и характеристиками.
+
+ResourcesWithoutFeatures возвращает ссылки ресурсов без вычисленных характеристик.
+after: ссылка, после которой продолжается выборка (пусто — с начала).
+limit: максимальное количество ссылок.
+
+GetPlaceholders возвращает заглушки для закэшированных обложек по списку URL.
+urls: список URL ресурсов.
+
+GetVariant получает производный вариант обложки по URL и ключу варианта.
+url: URL ресурса.
+variantKey: ключ цепочки преобразований.
+
+InsertVariant сохраняет производный вариант обложки, не изменяя исходное изображение.
+url: URL ресурса.
+variantKey: ключ цепочки преобразований.
+photo: массив байтов преобразованного изображения.
 */
@@ -1,10 +1,158 @@
 package database
 
+import "time"
+
+// Resource описывает закэшированную обложку вместе с вычисленными характеристиками изображения.
+type Resource struct {
+	URL           string    // Ссылка на видео, по которой закэширована обложка.
+	VideoID       string    // Идентификатор видео; если пуст, извлекается из URL при сохранении.
+	Photo         []byte    // Содержимое изображения.
+	ContentHash   string    // SHA-256 содержимого изображения (ключ в таблице blobs).
+	PHash         uint64    // Перцептивный хеш (dHash) изображения.
+	DominantColor string    // Доминирующий цвет в формате #rrggbb.
+	BlurHash      string    // Строка BlurHash для размытой заглушки.
+	Preview       []byte    // Миниатюра шириной 20 пикселей в формате JPEG.
+	HasFeatures   bool      // Характеристики изображения (PHash и остальные) вычислены; в базе — phash не NULL.
+	FetchedAt     time.Time // Время загрузки обложки; если не задано, при сохранении используется текущее.
+}
+
+// Placeholder описывает лёгкую заглушку закэшированной обложки без самого изображения.
+type Placeholder struct {
+	URL           string // Ссылка на видео.
+	BlurHash      string // Строка BlurHash.
+	Preview       []byte // Миниатюра в формате JPEG.
+	DominantColor string // Доминирующий цвет изображения.
+}
+
+// SimilarResource описывает закэшированную обложку, похожую на искомую.
+type SimilarResource struct {
+	URL           string // Ссылка на видео.
+	PHash         uint64 // Перцептивный хеш изображения.
+	DominantColor string // Доминирующий цвет изображения.
+	Distance      int    // Расстояние Хэмминга до искомого хеша.
+}
+
+// Entry описывает закэшированную запись для администрирования: метаданные без содержимого.
+type Entry struct {
+	URL           string    // Ссылка на видео (ключ кэша).
+	VideoID       string    // Идентификатор видео.
+	ContentHash   string    // SHA-256 содержимого изображения.
+	Size          int64     // Размер изображения в байтах.
+	FetchedAt     time.Time // Время последней загрузки обложки.
+	PHash         uint64    // Перцептивный хеш изображения.
+	DominantColor string    // Доминирующий цвет изображения.
+	BlurHash      string    // Строка BlurHash.
+	Variants      int       // Количество сохранённых производных вариантов.
+}
+
+// EntryFilter задает условия отбора записей кэша. Пустые поля не ограничивают выборку,
+// поэтому фильтр без условий соответствует всем записям.
+type EntryFilter struct {
+	URLs          []string  // Конкретные ссылки.
+	VideoIDPrefix string    // Префикс идентификатора видео.
+	FetchedBefore time.Time // Записи, загруженные раньше указанного времени.
+	FetchedAfter  time.Time // Записи, загруженные не раньше указанного времени.
+}
+
+// CacheStats содержит агрегированную статистику содержимого кэша.
+type CacheStats struct {
+	Entries         int       // Количество закэшированных ссылок.
+	OriginalBytes   int64     // Объём уникальных исходных изображений (с учётом дедупликации).
+	Variants        int       // Количество производных вариантов.
+	VariantBytes    int64     // Объём производных вариантов.
+	OldestURL       string    // Ссылка самой давно загруженной записи.
+	OldestFetchedAt time.Time // Время загрузки самой давней записи.
+	NewestURL       string    // Ссылка самой свежей записи.
+	NewestFetchedAt time.Time // Время загрузки самой свежей записи.
+}
+
+// Job описывает фоновое задание. Счётчики обновляются по мере обработки ссылок задания.
+type Job struct {
+	ID         string    // Идентификатор задания.
+	Kind       string    // Вид задания.
+	State      string    // Состояние задания; база данных хранит его как есть.
+	Total      int       // Количество ссылок в задании.
+	Fetched    int       // Ссылки, обложки которых загружены.
+	Cached     int       // Ссылки, обложки которых уже были в кэше.
+	Failed     int       // Ссылки, которые не удалось обработать.
+	CreatedAt  time.Time // Время создания задания.
+	StartedAt  time.Time // Время запуска; нулевое, если задание ещё не запускалось.
+	FinishedAt time.Time // Время завершения; нулевое, если задание не завершено.
+	// CallbackURL — адрес, на который отправляется уведомление о завершении (пусто — без уведомления).
+	CallbackURL string
+	Priority    string // Приоритет загрузок задания; база данных хранит его как есть.
+	Owner       string // Клиент, поставивший задание; пусто для заданий, созданных до учёта владельцев.
+}
+
+// Состояния ссылок фонового задания.
+const (
+	JobLinkPending = "pending" // Ссылка ещё не обработана.
+	JobLinkFetched = "fetched" // Обложка загружена.
+	JobLinkCached  = "cached"  // Обложка уже была в кэше.
+	JobLinkFailed  = "failed"  // Ссылку не удалось обработать.
+)
+
+// JobLink описывает одну ссылку фонового задания.
+type JobLink struct {
+	JobID    string // Идентификатор задания.
+	Position int    // Порядковый номер ссылки в задании.
+	URL      string // Ссылка на видео.
+	Status   string // Состояние ссылки: JobLink*.
+	Error    string // Текст ошибки для ссылок в состоянии JobLinkFailed.
+	Size     int64  // Размер закэшированной обложки в байтах (0, если её нет в кэше).
+}
+
+// WebhookDelivery описывает одну попытку доставки уведомления о завершении задания.
+type WebhookDelivery struct {
+	JobID       string    // Идентификатор задания.
+	Attempt     int       // Номер попытки, начиная с 1.
+	AttemptedAt time.Time // Время попытки.
+	StatusCode  int       // HTTP-статус ответа; 0, если ответ не получен.
+	Error       string    // Текст ошибки; пусто для успешной доставки.
+}
+
+// BlobStore определяет хранилище содержимого изображений, адресуемого хешем SHA-256.
+// Метаданные и счётчики ссылок хранятся в базе данных, хранилище отвечает только за байты.
+type BlobStore interface {
+	Put(hash string, data []byte) error
+	Get(hash string) ([]byte, error)
+	Delete(hash string) error
+}
+
+// JobStore определяет хранилище фоновых заданий, благодаря которому задания
+// переживают перезапуск сервиса и продолжаются с необработанных ссылок.
+type JobStore interface {
+	CreateJob(job *Job, links []string) error
+	GetJob(id string) (*Job, error)
+	ListJobs(owner string, state string, after string, limit int) ([]Job, error)
+	ListJobsInStates(states []string) ([]Job, error)
+	UpdateJobState(job *Job) error
+	PendingJobLinks(id string) ([]JobLink, error)
+	CompleteJobLink(link JobLink) error
+	JobFailures(id string, limit int) ([]JobLink, error)
+	JobLinks(id string) ([]JobLink, error)
+	RecordWebhookDelivery(delivery WebhookDelivery) error
+	WebhookDeliveries(id string) ([]WebhookDelivery, error)
+}
+
 // Database определяет интерфейс для взаимодействия с базой данных.
 type Database interface {
 	InitDatabase() error
-	InsertResource(url string, photo []byte) error
+	InsertResource(resource *Resource) error
+	DeleteResource(url string) error
 	ResourceExists(url string) (bool, error)
 	GetPhotoByUrl(url string) ([]byte, error)
+	GetResourceByUrl(url string) (*Resource, error)
+	FindSimilar(hash uint64, maxDistance int, limit int) ([]SimilarResource, error)
+	UpdateFeatures(resource *Resource) error
+	ResourcesWithoutFeatures(after string, limit int) ([]string, error)
+	GetPlaceholders(urls []string) ([]Placeholder, error)
+	GetVariant(url, variantKey string) ([]byte, error)
+	InsertVariant(url, variantKey string, photo []byte) error
+	ListEntries(filter EntryFilter, after string, limit int) ([]Entry, error)
+	OldestEntries(fetchedBefore time.Time, limit int) ([]Entry, error)
+	GetEntry(url string) (*Entry, error)
+	PurgeEntries(filter EntryFilter) ([]string, error)
+	GetCacheStats() (*CacheStats, error)
 	Close() error
 }
@@ -0,0 +1,416 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+)
+
+// jobCounterColumns сопоставляет итоговое состояние ссылки счётчику задания.
+var jobCounterColumns = map[string]string{
+	JobLinkFetched: "fetched",
+	JobLinkCached:  "cached",
+	JobLinkFailed:  "failed",
+}
+
+// jobRow отражает строку таблицы jobs с допускающими NULL колонками.
+type jobRow struct {
+	ID          string        `db:"id"`
+	Kind        string        `db:"kind"`
+	State       string        `db:"state"`
+	Total       int           `db:"total"`
+	Fetched     int           `db:"fetched"`
+	Cached      int           `db:"cached"`
+	Failed      int           `db:"failed"`
+	CreatedAt   int64         `db:"created_at"`
+	StartedAt   sql.NullInt64 `db:"started_at"`
+	FinishedAt  sql.NullInt64 `db:"finished_at"`
+	CallbackURL string        `db:"callback_url"`
+	Priority    string        `db:"priority"`
+	Owner       string        `db:"owner"`
+}
+
+// toJob преобразует строку таблицы в структуру Job.
+func (r *jobRow) toJob() Job {
+	job := Job{
+		ID:          r.ID,
+		Kind:        r.Kind,
+		State:       r.State,
+		Total:       r.Total,
+		Fetched:     r.Fetched,
+		Cached:      r.Cached,
+		Failed:      r.Failed,
+		CreatedAt:   time.Unix(r.CreatedAt, 0),
+		CallbackURL: r.CallbackURL,
+		Priority:    r.Priority,
+		Owner:       r.Owner,
+	}
+	if r.StartedAt.Valid {
+		job.StartedAt = time.Unix(r.StartedAt.Int64, 0)
+	}
+	if r.FinishedAt.Valid {
+		job.FinishedAt = time.Unix(r.FinishedAt.Int64, 0)
+	}
+	return job
+}
+
+// nullableUnix возвращает время в секундах Unix или NULL для нулевого времени.
+func nullableUnix(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+// selectJobs возвращает построитель запроса заданий.
+func (s *SQLDatabase) selectJobs() squirrel.SelectBuilder {
+	return s.Builder.
+		Select("id", "kind", "state", "total", "fetched", "cached", "failed", "created_at", "started_at", "finished_at",
+			"COALESCE(callback_url, '') AS callback_url", "COALESCE(priority, '') AS priority",
+			"COALESCE(owner, '') AS owner").
+		From("jobs")
+}
+
+// queryJobs выполняет запрос заданий и преобразует строки в структуры Job.
+func (s *SQLDatabase) queryJobs(builder squirrel.SelectBuilder) ([]Job, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build jobs query", zap.Error(err))
+		return nil, err
+	}
+	var rows []jobRow
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute jobs query", zap.Error(err))
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(rows))
+	for i := range rows {
+		jobs = append(jobs, rows[i].toJob())
+	}
+	return jobs, nil
+}
+
+// CreateJob сохраняет задание и его ссылки в одной транзакции. Все ссылки сохраняются
+// в состоянии JobLinkPending в переданном порядке.
+func (s *SQLDatabase) CreateJob(job *Job, links []string) error {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var callbackURL sql.NullString
+	if job.CallbackURL != "" {
+		callbackURL = sql.NullString{String: job.CallbackURL, Valid: true}
+	}
+	_, err = tx.Exec(tx.Rebind(`INSERT INTO jobs (id, kind, state, total, fetched, cached, failed, created_at, started_at, finished_at, callback_url, priority, owner)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		job.ID, job.Kind, job.State, job.Total, job.Fetched, job.Cached, job.Failed,
+		job.CreatedAt.Unix(), nullableUnix(job.StartedAt), nullableUnix(job.FinishedAt), callbackURL, job.Priority, job.Owner)
+	if err != nil {
+		s.Logger.Error("Failed to insert job", zap.String("id", job.ID), zap.Error(err))
+		return err
+	}
+
+	stmt, err := tx.Preparex(tx.Rebind(`INSERT INTO job_links (job_id, position, url, status) VALUES (?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for position, link := range links {
+		if _, err := stmt.Exec(job.ID, position, link, JobLinkPending); err != nil {
+			s.Logger.Error("Failed to insert job link", zap.String("id", job.ID), zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit job", zap.String("id", job.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetJob возвращает задание по идентификатору. Возвращает nil, если задание не найдено.
+func (s *SQLDatabase) GetJob(id string) (*Job, error) {
+	query, args, err := s.selectJobs().Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build job query", zap.Error(err))
+		return nil, err
+	}
+	var row jobRow
+	err = s.DB.Get(&row, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		s.Logger.Error("Failed to execute job query", zap.Error(err))
+		return nil, err
+	}
+	job := row.toJob()
+	return &job, nil
+}
+
+// ListJobs возвращает страницу заданий, начиная с самых новых.
+// owner ограничивает выборку заданиями одного клиента (пусто — задания всех клиентов).
+// state ограничивает выборку одним состоянием (пусто — все задания).
+// after — идентификатор последнего задания предыдущей страницы (пусто для первой страницы).
+// limit ограничивает размер страницы (0 — без ограничения).
+func (s *SQLDatabase) ListJobs(owner string, state string, after string, limit int) ([]Job, error) {
+	conditions := squirrel.And{}
+	if owner != "" {
+		conditions = append(conditions, squirrel.Eq{"owner": owner})
+	}
+	if state != "" {
+		conditions = append(conditions, squirrel.Eq{"state": state})
+	}
+	if after != "" {
+		// Задания, созданные в одну секунду, упорядочиваются по идентификатору
+		conditions = append(conditions, squirrel.Expr(
+			`(created_at < (SELECT created_at FROM jobs WHERE id = ?)
+			OR (created_at = (SELECT created_at FROM jobs WHERE id = ?) AND id < ?))`,
+			after, after, after))
+	}
+	builder := s.selectJobs().Where(conditions).OrderBy("created_at DESC", "id DESC")
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+	return s.queryJobs(builder)
+}
+
+// ListJobsInStates возвращает все задания в указанных состояниях, начиная с самых старых.
+// Используется при запуске сервиса, чтобы продолжить незавершённые задания.
+func (s *SQLDatabase) ListJobsInStates(states []string) ([]Job, error) {
+	return s.queryJobs(s.selectJobs().Where(squirrel.Eq{"state": states}).OrderBy("created_at", "id"))
+}
+
+// UpdateJobState сохраняет состояние задания и время его запуска и завершения.
+// Счётчики ссылок не изменяются: они обновляются в CompleteJobLink.
+func (s *SQLDatabase) UpdateJobState(job *Job) error {
+	query, args, err := s.Builder.
+		Update("jobs").
+		Set("state", job.State).
+		Set("started_at", nullableUnix(job.StartedAt)).
+		Set("finished_at", nullableUnix(job.FinishedAt)).
+		Where(squirrel.Eq{"id": job.ID}).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build job update", zap.Error(err))
+		return err
+	}
+	if _, err := s.DB.Exec(query, args...); err != nil {
+		s.Logger.Error("Failed to update job", zap.String("id", job.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// selectJobLinks возвращает построитель запроса ссылок заданий в исходном порядке.
+// Размер обложки берётся из кэша, если ссылка в нём есть.
+func (s *SQLDatabase) selectJobLinks(conditions squirrel.Eq) squirrel.SelectBuilder {
+	return s.Builder.
+		Select("l.job_id", "l.position", "l.url", "l.status", "COALESCE(l.error, '') AS error", "COALESCE(b.size, 0) AS size").
+		From("job_links l").
+		LeftJoin("resources r ON r.url = l.url").
+		LeftJoin("blobs b ON b.hash = r.blob_hash").
+		Where(conditions).
+		OrderBy("l.position")
+}
+
+// PendingJobLinks возвращает необработанные ссылки задания в исходном порядке.
+func (s *SQLDatabase) PendingJobLinks(id string) ([]JobLink, error) {
+	return s.queryJobLinks(s.selectJobLinks(squirrel.Eq{"l.job_id": id, "l.status": JobLinkPending}))
+}
+
+// JobLinks возвращает все ссылки задания с их состоянием и размером обложки в исходном порядке.
+func (s *SQLDatabase) JobLinks(id string) ([]JobLink, error) {
+	return s.queryJobLinks(s.selectJobLinks(squirrel.Eq{"l.job_id": id}))
+}
+
+// CompleteJobLink сохраняет итоговое состояние ссылки и увеличивает соответствующий счётчик
+// задания в одной транзакции. Повторное завершение уже обработанной ссылки ничего не меняет.
+func (s *SQLDatabase) CompleteJobLink(link JobLink) error {
+	column, ok := jobCounterColumns[link.Status]
+	if !ok {
+		return fmt.Errorf("unsupported job link status %q", link.Status)
+	}
+	var linkError sql.NullString
+	if link.Error != "" {
+		linkError = sql.NullString{String: link.Error, Valid: true}
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(tx.Rebind(`UPDATE job_links SET status = ?, error = ? WHERE job_id = ? AND position = ? AND status = ?`),
+		link.Status, linkError, link.JobID, link.Position, JobLinkPending)
+	if err != nil {
+		s.Logger.Error("Failed to update job link", zap.String("id", link.JobID), zap.Error(err))
+		return err
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return err
+	}
+	// Имя колонки берётся из jobCounterColumns, а не из входных данных
+	if _, err := tx.Exec(tx.Rebind(fmt.Sprintf(`UPDATE jobs SET %[1]s = %[1]s + 1 WHERE id = ?`, column)), link.JobID); err != nil {
+		s.Logger.Error("Failed to update job counters", zap.String("id", link.JobID), zap.Error(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+// JobFailures возвращает до limit ссылок задания, которые не удалось обработать, в исходном порядке.
+func (s *SQLDatabase) JobFailures(id string, limit int) ([]JobLink, error) {
+	builder := s.selectJobLinks(squirrel.Eq{"l.job_id": id, "l.status": JobLinkFailed})
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+	return s.queryJobLinks(builder)
+}
+
+// queryJobLinks выполняет запрос ссылок заданий.
+func (s *SQLDatabase) queryJobLinks(builder squirrel.SelectBuilder) ([]JobLink, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build job links query", zap.Error(err))
+		return nil, err
+	}
+	var rows []struct {
+		JobID    string `db:"job_id"`
+		Position int    `db:"position"`
+		URL      string `db:"url"`
+		Status   string `db:"status"`
+		Error    string `db:"error"`
+		Size     int64  `db:"size"`
+	}
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute job links query", zap.Error(err))
+		return nil, err
+	}
+	links := make([]JobLink, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, JobLink{JobID: row.JobID, Position: row.Position, URL: row.URL, Status: row.Status, Error: row.Error, Size: row.Size})
+	}
+	return links, nil
+}
+
+// RecordWebhookDelivery сохраняет попытку доставки уведомления о завершении задания.
+func (s *SQLDatabase) RecordWebhookDelivery(delivery WebhookDelivery) error {
+	var deliveryError sql.NullString
+	if delivery.Error != "" {
+		deliveryError = sql.NullString{String: delivery.Error, Valid: true}
+	}
+	query, args, err := s.Builder.
+		Insert("webhook_deliveries").
+		Columns("job_id", "attempt", "attempted_at", "status_code", "error").
+		Values(delivery.JobID, delivery.Attempt, delivery.AttemptedAt.Unix(), delivery.StatusCode, deliveryError).
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build webhook delivery insert", zap.Error(err))
+		return err
+	}
+	if _, err := s.DB.Exec(query, args...); err != nil {
+		s.Logger.Error("Failed to record webhook delivery", zap.String("id", delivery.JobID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// WebhookDeliveries возвращает попытки доставки уведомления о завершении задания по порядку.
+func (s *SQLDatabase) WebhookDeliveries(id string) ([]WebhookDelivery, error) {
+	query, args, err := s.Builder.
+		Select("job_id", "attempt", "attempted_at", "status_code", "COALESCE(error, '') AS error").
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"job_id": id}).
+		OrderBy("attempt").
+		ToSql()
+	if err != nil {
+		s.Logger.Error("Failed to build webhook deliveries query", zap.Error(err))
+		return nil, err
+	}
+	var rows []struct {
+		JobID       string `db:"job_id"`
+		Attempt     int    `db:"attempt"`
+		AttemptedAt int64  `db:"attempted_at"`
+		StatusCode  int    `db:"status_code"`
+		Error       string `db:"error"`
+	}
+	if err := s.DB.Select(&rows, query, args...); err != nil {
+		s.Logger.Error("Failed to execute webhook deliveries query", zap.Error(err))
+		return nil, err
+	}
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, WebhookDelivery{
+			JobID:       row.JobID,
+			Attempt:     row.Attempt,
+			AttemptedAt: time.Unix(row.AttemptedAt, 0),
+			StatusCode:  row.StatusCode,
+			Error:       row.Error,
+		})
+	}
+	return deliveries, nil
+}
+
+/*
+toJob преобразует строку таблицы в структуру Job.
+
+nullableUnix возвращает время в секундах Unix или NULL для нулевого времени.
+t: время.
+
+selectJobs возвращает построитель запроса заданий.
+
+queryJobs выполняет запрос заданий и преобразует строки в структуры Job.
+builder: построитель запроса.
+
+CreateJob сохраняет задание и его ссылки в одной транзакции.
+job: задание.
+links: ссылки задания в порядке обработки.
+
+GetJob возвращает задание по идентификатору или nil, если задание не найдено.
+id: идентификатор задания.
+
+ListJobs возвращает страницу заданий, начиная с самых новых.
+owner: клиент, поставивший задания (пусто — все клиенты).
+state: состояние заданий (пусто — все задания).
+after: идентификатор последнего задания предыдущей страницы.
+limit: размер страницы (0 — без ограничения).
+
+ListJobsInStates возвращает все задания в указанных состояниях, начиная с самых старых.
+states: состояния заданий.
+
+UpdateJobState сохраняет состояние задания и время его запуска и завершения.
+job: задание.
+
+selectJobLinks возвращает построитель запроса ссылок заданий в исходном порядке.
+conditions: условия отбора ссылок.
+
+PendingJobLinks возвращает необработанные ссылки задания в исходном порядке.
+id: идентификатор задания.
+
+JobLinks возвращает все ссылки задания с их состоянием и размером обложки.
+id: идентификатор задания.
+
+CompleteJobLink сохраняет итоговое состояние ссылки и увеличивает счётчик задания.
+link: ссылка задания с итоговым состоянием.
+
+JobFailures возвращает ссылки задания, которые не удалось обработать.
+id: идентификатор задания.
+limit: максимум ссылок (0 — без ограничения).
+
+queryJobLinks выполняет запрос ссылок заданий.
+builder: построитель запроса.
+
+RecordWebhookDelivery сохраняет попытку доставки уведомления о завершении задания.
+delivery: попытка доставки.
+
+WebhookDeliveries возвращает попытки доставки уведомления о завершении задания.
+id: идентификатор задания.
+*/
@@ -0,0 +1,369 @@
+package database
+
+import (
+	"container/list"
+	"shelon_server/utilss/logger"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Названия уровней кэша в статистике.
+const (
+	TierMemory   = "memory"
+	TierDatabase = "database"
+)
+
+// TierStats содержит счётчики попаданий и промахов одного уровня кэша.
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// MemoryCacheStats содержит статистику in-memory кэша.
+type MemoryCacheStats struct {
+	Tiers    map[string]TierStats // Счётчики по уровням (memory, database).
+	Entries  int                  // Количество записей в памяти.
+	Bytes    int64                // Объём данных в памяти.
+	MaxBytes int64                // Ограничение объёма.
+}
+
+// memoryEntry элемент LRU-списка.
+type memoryEntry struct {
+	key      string
+	url      string
+	resource *Resource // Ресурс (для записей обложек).
+	variant  []byte    // Производный вариант (для записей вариантов).
+	size     int64
+}
+
+// MemoryCachedDatabase добавляет ограниченный по объёму LRU-кэш в памяти перед реализацией Database.
+// Чтение идёт сквозь кэш (read-through), запись — одновременно в кэш и в базу (write-through).
+// Записи по URL сбрасываются при удалении или перезаписи ресурса. Чтобы значение, прочитанное
+// из базы до сброса, не вернулось в память после него, заполнение сверяет поколение URL.
+// Остальные методы делегируются нижележащей базе без изменений.
+type MemoryCachedDatabase struct {
+	Database
+	logger   logger.Logger
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List               // Начало списка — недавно использованные записи.
+	entries map[string]*list.Element // Записи по ключу.
+	byURL   map[string]map[string]bool
+	bytes   int64
+	// fills — количество незавершённых заполнений по URL, generations — поколения URL,
+	// увеличиваемые каждым сбросом. Поколения хранятся, только пока есть заполнения URL.
+	fills       map[string]int
+	generations map[string]uint64
+
+	memoryHits, memoryMisses     atomic.Uint64
+	databaseHits, databaseMisses atomic.Uint64
+}
+
+// NewMemoryCachedDatabase создает LRU-кэш в памяти поверх базы данных.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// inner: нижележащая реализация Database.
+// maxBytes: максимальный объём изображений, хранимых в памяти.
+func NewMemoryCachedDatabase(logger logger.Logger, inner Database, maxBytes int64) *MemoryCachedDatabase {
+	return &MemoryCachedDatabase{
+		Database: inner,
+		logger:   logger,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		byURL:    make(map[string]map[string]bool),
+
+		fills:       make(map[string]int),
+		generations: make(map[string]uint64),
+	}
+}
+
+// GetResourceByUrl возвращает ресурс из памяти, а при промахе читает его из базы и кэширует.
+func (mc *MemoryCachedDatabase) GetResourceByUrl(url string) (*Resource, error) {
+	if entry := mc.get(resourceKey(url)); entry != nil {
+		mc.memoryHits.Add(1)
+		resource := *entry.resource
+		return &resource, nil
+	}
+	mc.memoryMisses.Add(1)
+
+	generation := mc.beginFill(url)
+	resource, err := mc.Database.GetResourceByUrl(url)
+	if err != nil || resource == nil || resource.Photo == nil {
+		mc.endFill(url, generation, false, nil)
+		if err == nil {
+			mc.databaseMisses.Add(1)
+		}
+		return resource, err
+	}
+	mc.databaseHits.Add(1)
+	mc.endFill(url, generation, false, resourceEntry(resource))
+	return resource, nil
+}
+
+// GetPhotoByUrl возвращает содержимое обложки через кэш ресурсов.
+func (mc *MemoryCachedDatabase) GetPhotoByUrl(url string) ([]byte, error) {
+	resource, err := mc.GetResourceByUrl(url)
+	if err != nil || resource == nil {
+		return nil, err
+	}
+	return resource.Photo, nil
+}
+
+// InsertResource сохраняет ресурс в базе и затем в памяти.
+// Ранее закэшированные варианты этого URL сбрасываются, так как исходное изображение обновилось.
+func (mc *MemoryCachedDatabase) InsertResource(resource *Resource) error {
+	generation := mc.beginFill(resource.URL)
+	if err := mc.Database.InsertResource(resource); err != nil {
+		mc.endFill(resource.URL, generation, false, nil)
+		return err
+	}
+	mc.endFill(resource.URL, generation, true, resourceEntry(resource))
+	return nil
+}
+
+// DeleteResource удаляет ресурс из базы и сбрасывает все его записи в памяти.
+func (mc *MemoryCachedDatabase) DeleteResource(url string) error {
+	if err := mc.Database.DeleteResource(url); err != nil {
+		return err
+	}
+	mc.Invalidate(url)
+	return nil
+}
+
+// PurgeEntries удаляет записи из базы и сбрасывает их в памяти.
+// Записи, удалённые до ошибки, тоже сбрасываются.
+func (mc *MemoryCachedDatabase) PurgeEntries(filter EntryFilter) ([]string, error) {
+	purged, err := mc.Database.PurgeEntries(filter)
+	for _, url := range purged {
+		mc.Invalidate(url)
+	}
+	return purged, err
+}
+
+// UpdateFeatures сохраняет характеристики изображения в базе и сбрасывает ресурс в памяти,
+// чтобы следующее чтение получило их из базы.
+func (mc *MemoryCachedDatabase) UpdateFeatures(resource *Resource) error {
+	if err := mc.Database.UpdateFeatures(resource); err != nil {
+		return err
+	}
+	mc.Invalidate(resource.URL)
+	return nil
+}
+
+// GetVariant возвращает производный вариант из памяти, а при промахе читает его из базы и кэширует.
+func (mc *MemoryCachedDatabase) GetVariant(url, variantKey string) ([]byte, error) {
+	key := variantCacheKey(url, variantKey)
+	if entry := mc.get(key); entry != nil {
+		mc.memoryHits.Add(1)
+		return entry.variant, nil
+	}
+	mc.memoryMisses.Add(1)
+
+	generation := mc.beginFill(url)
+	variant, err := mc.Database.GetVariant(url, variantKey)
+	if err != nil || variant == nil {
+		mc.endFill(url, generation, false, nil)
+		if err == nil {
+			mc.databaseMisses.Add(1)
+		}
+		return nil, err
+	}
+	mc.databaseHits.Add(1)
+	mc.endFill(url, generation, false, &memoryEntry{key: key, url: url, variant: variant, size: int64(len(variant))})
+	return variant, nil
+}
+
+// InsertVariant сохраняет производный вариант в базе и затем в памяти.
+func (mc *MemoryCachedDatabase) InsertVariant(url, variantKey string, photo []byte) error {
+	generation := mc.beginFill(url)
+	if err := mc.Database.InsertVariant(url, variantKey, photo); err != nil {
+		mc.endFill(url, generation, false, nil)
+		return err
+	}
+	mc.endFill(url, generation, false, &memoryEntry{key: variantCacheKey(url, variantKey), url: url, variant: photo, size: int64(len(photo))})
+	return nil
+}
+
+// Invalidate удаляет из памяти ресурс и все производные варианты указанного URL.
+// Заполнения, начатые до сброса, своих значений в память уже не добавят.
+func (mc *MemoryCachedDatabase) Invalidate(url string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.invalidate(url)
+}
+
+// Stats возвращает счётчики попаданий и промахов по уровням и заполненность памяти.
+func (mc *MemoryCachedDatabase) Stats() MemoryCacheStats {
+	mc.mu.Lock()
+	entries, bytes := len(mc.entries), mc.bytes
+	mc.mu.Unlock()
+	return MemoryCacheStats{
+		Tiers: map[string]TierStats{
+			TierMemory:   {Hits: mc.memoryHits.Load(), Misses: mc.memoryMisses.Load()},
+			TierDatabase: {Hits: mc.databaseHits.Load(), Misses: mc.databaseMisses.Load()},
+		},
+		Entries:  entries,
+		Bytes:    bytes,
+		MaxBytes: mc.maxBytes,
+	}
+}
+
+// resourceEntry формирует запись памяти с копией ресурса.
+func resourceEntry(resource *Resource) *memoryEntry {
+	copied := *resource
+	return &memoryEntry{
+		key:      resourceKey(resource.URL),
+		url:      resource.URL,
+		resource: &copied,
+		size:     int64(len(resource.Photo) + len(resource.Preview)),
+	}
+}
+
+// beginFill регистрирует заполнение записей url значением из базы и возвращает текущее поколение url.
+// Каждый вызов должен завершаться вызовом endFill.
+func (mc *MemoryCachedDatabase) beginFill(url string) uint64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.fills[url]++
+	return mc.generations[url]
+}
+
+// endFill завершает заполнение, начатое beginFill. Если invalidate, записи url сначала сбрасываются.
+// Запись entry добавляется, только если записи url не сбрасывались с начала заполнения;
+// проверка и добавление выполняются под одной блокировкой со сбросом.
+func (mc *MemoryCachedDatabase) endFill(url string, generation uint64, invalidate bool, entry *memoryEntry) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	fresh := mc.generations[url] == generation
+	if invalidate {
+		mc.invalidate(url)
+	}
+	if entry != nil && fresh {
+		mc.put(entry)
+	}
+	if mc.fills[url]--; mc.fills[url] == 0 {
+		delete(mc.fills, url)
+		delete(mc.generations, url)
+	}
+}
+
+// invalidate удаляет записи url и увеличивает поколение url, если есть незавершённые заполнения.
+// Вызывается под блокировкой.
+func (mc *MemoryCachedDatabase) invalidate(url string) {
+	if mc.fills[url] > 0 {
+		mc.generations[url]++
+	}
+	for key := range mc.byURL[url] {
+		if elem, ok := mc.entries[key]; ok {
+			mc.removeElement(elem)
+		}
+	}
+}
+
+// get возвращает запись по ключу и помечает её как недавно использованную.
+func (mc *MemoryCachedDatabase) get(key string) *memoryEntry {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	elem, ok := mc.entries[key]
+	if !ok {
+		return nil
+	}
+	mc.lru.MoveToFront(elem)
+	return elem.Value.(*memoryEntry)
+}
+
+// put добавляет запись и вытесняет давно не использованные записи сверх ограничения объёма.
+// Записи крупнее всего ограничения не кэшируются. Вызывается под блокировкой.
+func (mc *MemoryCachedDatabase) put(entry *memoryEntry) {
+	if entry.size > mc.maxBytes {
+		return
+	}
+
+	if elem, ok := mc.entries[entry.key]; ok {
+		mc.removeElement(elem)
+	}
+	mc.entries[entry.key] = mc.lru.PushFront(entry)
+	if mc.byURL[entry.url] == nil {
+		mc.byURL[entry.url] = make(map[string]bool)
+	}
+	mc.byURL[entry.url][entry.key] = true
+	mc.bytes += entry.size
+
+	for mc.bytes > mc.maxBytes {
+		oldest := mc.lru.Back()
+		if oldest == nil {
+			break
+		}
+		mc.logger.Info("Evicting entry from memory cache", zap.String("key", oldest.Value.(*memoryEntry).key))
+		mc.removeElement(oldest)
+	}
+}
+
+// removeElement удаляет запись из всех индексов. Вызывается под блокировкой.
+func (mc *MemoryCachedDatabase) removeElement(elem *list.Element) {
+	entry := elem.Value.(*memoryEntry)
+	mc.lru.Remove(elem)
+	delete(mc.entries, entry.key)
+	if keys := mc.byURL[entry.url]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(mc.byURL, entry.url)
+		}
+	}
+	mc.bytes -= entry.size
+}
+
+// resourceKey возвращает ключ записи обложки.
+func resourceKey(url string) string {
+	return "resource\x00" + url
+}
+
+// variantCacheKey возвращает ключ записи производного варианта.
+func variantCacheKey(url, variantKey string) string {
+	return "variant\x00" + url + "\x00" + variantKey
+}
+
+/*
+NewMemoryCachedDatabase создает LRU-кэш в памяти поверх базы данных.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+inner: нижележащая реализация Database.
+maxBytes: максимальный объём изображений, хранимых в памяти.
+
+GetResourceByUrl возвращает ресурс из памяти, а при промахе читает его из базы и кэширует.
+url: URL ресурса.
+
+GetPhotoByUrl возвращает содержимое обложки через кэш ресурсов.
+url: URL ресурса.
+
+InsertResource сохраняет ресурс в базе и затем в памяти.
+resource: ресурс с содержимым и характеристиками.
+
+DeleteResource удаляет ресурс из базы и сбрасывает все его записи в памяти.
+url: URL ресурса.
+
+PurgeEntries удаляет записи из базы и сбрасывает их в памяти.
+filter: условия отбора записей.
+
+UpdateFeatures сохраняет характеристики изображения в базе и сбрасывает ресурс в памяти.
+resource: ресурс с вычисленными характеристиками.
+
+GetVariant возвращает производный вариант из памяти или из базы.
+InsertVariant сохраняет производный вариант в базе и затем в памяти.
+
+Invalidate удаляет из памяти ресурс и все производные варианты указанного URL.
+Заполнения, начатые до сброса, своих значений в память уже не добавят.
+
+Stats возвращает счётчики попаданий и промахов по уровням и заполненность памяти.
+
+beginFill регистрирует заполнение записей URL и возвращает текущее поколение URL.
+url: URL ресурса.
+
+endFill завершает заполнение и добавляет запись, только если записи URL не сбрасывались с его начала.
+url: URL ресурса.
+generation: поколение, полученное от beginFill.
+invalidate: сбросить записи URL перед добавлением.
+entry: добавляемая запись (nil — ничего не добавлять).
+*/
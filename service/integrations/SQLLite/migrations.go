@@ -0,0 +1,398 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	youtubeclient "shelon_server/integrations/youtubeCLient"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// migrationLockID — ключ advisory-блокировки PostgreSQL, под которой применяются миграции.
+const migrationLockID = 0x7468756d62 // "thumb"
+
+// migration описывает одно изменение схемы базы данных.
+type migration struct {
+	version    int                     // Порядковый номер миграции.
+	name       string                  // Краткое описание изменения.
+	statements []string                // SQL-выражения, выполняемые в одной транзакции.
+	postgres   []string                // Варианты statements для PostgreSQL, если типы или синтаксис отличаются.
+	apply      func(tx *sqlx.Tx) error // Дополнительный шаг на Go после выполнения statements (может быть nil).
+}
+
+// migrations содержит упорядоченный список миграций схемы.
+// Новые миграции добавляются только в конец списка.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create resources table",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS resources (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				url TEXT NOT NULL,
+				photo BLOB
+			)`,
+		},
+		postgres: []string{
+			`CREATE TABLE IF NOT EXISTS resources (
+				id BIGSERIAL PRIMARY KEY,
+				url TEXT NOT NULL,
+				photo BYTEA
+			)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "add perceptual hash and dominant color",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN phash INTEGER`,
+			`ALTER TABLE resources ADD COLUMN dominant_color TEXT`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_url ON resources (url)`,
+		},
+		postgres: []string{
+			`ALTER TABLE resources ADD COLUMN phash BIGINT`,
+			`ALTER TABLE resources ADD COLUMN dominant_color TEXT`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_url ON resources (url)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add blurhash and preview placeholders",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN blurhash TEXT`,
+			`ALTER TABLE resources ADD COLUMN preview BLOB`,
+		},
+		postgres: []string{
+			`ALTER TABLE resources ADD COLUMN blurhash TEXT`,
+			`ALTER TABLE resources ADD COLUMN preview BYTEA`,
+		},
+	},
+	{
+		version: 4,
+		name:    "create derived variants table",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS variants (
+				url TEXT NOT NULL,
+				variant_key TEXT NOT NULL,
+				photo BLOB NOT NULL,
+				PRIMARY KEY (url, variant_key)
+			)`,
+		},
+		postgres: []string{
+			`CREATE TABLE IF NOT EXISTS variants (
+				url TEXT NOT NULL,
+				variant_key TEXT NOT NULL,
+				photo BYTEA NOT NULL,
+				PRIMARY KEY (url, variant_key)
+			)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "move photos to content-addressed blobs",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS blobs (
+				hash TEXT PRIMARY KEY,
+				data BLOB,
+				size INTEGER NOT NULL,
+				ref_count INTEGER NOT NULL DEFAULT 0
+			)`,
+			`ALTER TABLE resources ADD COLUMN blob_hash TEXT`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_blob_hash ON resources (blob_hash)`,
+		},
+		postgres: []string{
+			`CREATE TABLE IF NOT EXISTS blobs (
+				hash TEXT PRIMARY KEY,
+				data BYTEA,
+				size BIGINT NOT NULL,
+				ref_count INTEGER NOT NULL DEFAULT 0
+			)`,
+			`ALTER TABLE resources ADD COLUMN blob_hash TEXT`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_blob_hash ON resources (blob_hash)`,
+		},
+		apply: moveResourcePhotosToBlobs,
+	},
+	{
+		version: 6,
+		name:    "split blob metadata and blob data",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS blob_data (
+				hash TEXT PRIMARY KEY,
+				data BLOB NOT NULL
+			)`,
+			`INSERT INTO blob_data (hash, data) SELECT hash, data FROM blobs WHERE data IS NOT NULL`,
+			`ALTER TABLE blobs DROP COLUMN data`,
+		},
+		postgres: []string{
+			`CREATE TABLE IF NOT EXISTS blob_data (
+				hash TEXT PRIMARY KEY,
+				data BYTEA NOT NULL
+			)`,
+			`INSERT INTO blob_data (hash, data) SELECT hash, data FROM blobs WHERE data IS NOT NULL`,
+			`ALTER TABLE blobs DROP COLUMN data`,
+		},
+	},
+	{
+		version: 7,
+		name:    "deduplicate resources and make url unique",
+		// Для каждого URL остаётся самая свежая строка; ссылки удалённых строк на содержимое
+		// освобождаются. Содержимое во внешних хранилищах (файлы, S3), оставшееся без ссылок,
+		// при этом не удаляется.
+		statements: []string{
+			`UPDATE blobs SET ref_count = ref_count - (
+				SELECT COUNT(*) FROM resources r
+				WHERE r.blob_hash = blobs.hash
+				AND r.id NOT IN (SELECT MAX(id) FROM resources GROUP BY url)
+			)`,
+			`DELETE FROM resources WHERE id NOT IN (SELECT MAX(id) FROM resources GROUP BY url)`,
+			`DELETE FROM blobs WHERE ref_count <= 0`,
+			`DELETE FROM blob_data WHERE hash NOT IN (SELECT hash FROM blobs)`,
+			`DROP INDEX IF EXISTS idx_resources_url`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_resources_url_unique ON resources (url)`,
+		},
+	},
+	{
+		version: 8,
+		name:    "add video id and fetch time",
+		// Время загрузки существующих записей неизвестно, поэтому им проставляется время миграции.
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN video_id TEXT`,
+			`ALTER TABLE resources ADD COLUMN fetched_at INTEGER`,
+			`UPDATE resources SET fetched_at = CAST(strftime('%s', 'now') AS INTEGER)`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_video_id ON resources (video_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_fetched_at ON resources (fetched_at)`,
+		},
+		postgres: []string{
+			`ALTER TABLE resources ADD COLUMN video_id TEXT`,
+			`ALTER TABLE resources ADD COLUMN fetched_at BIGINT`,
+			`UPDATE resources SET fetched_at = EXTRACT(EPOCH FROM now())::BIGINT`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_video_id ON resources (video_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_resources_fetched_at ON resources (fetched_at)`,
+		},
+		apply: fillResourceVideoIDs,
+	},
+	{
+		version: 9,
+		name:    "create background jobs tables",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS jobs (
+				id TEXT PRIMARY KEY,
+				kind TEXT NOT NULL,
+				state TEXT NOT NULL,
+				total INTEGER NOT NULL,
+				fetched INTEGER NOT NULL DEFAULT 0,
+				cached INTEGER NOT NULL DEFAULT 0,
+				failed INTEGER NOT NULL DEFAULT 0,
+				created_at INTEGER NOT NULL,
+				started_at INTEGER,
+				finished_at INTEGER
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_jobs_state ON jobs (state)`,
+			`CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at)`,
+			`CREATE TABLE IF NOT EXISTS job_links (
+				job_id TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				url TEXT NOT NULL,
+				status TEXT NOT NULL,
+				error TEXT,
+				PRIMARY KEY (job_id, position)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_job_links_status ON job_links (job_id, status)`,
+		},
+		postgres: []string{
+			`CREATE TABLE IF NOT EXISTS jobs (
+				id TEXT PRIMARY KEY,
+				kind TEXT NOT NULL,
+				state TEXT NOT NULL,
+				total INTEGER NOT NULL,
+				fetched INTEGER NOT NULL DEFAULT 0,
+				cached INTEGER NOT NULL DEFAULT 0,
+				failed INTEGER NOT NULL DEFAULT 0,
+				created_at BIGINT NOT NULL,
+				started_at BIGINT,
+				finished_at BIGINT
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_jobs_state ON jobs (state)`,
+			`CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at)`,
+			`CREATE TABLE IF NOT EXISTS job_links (
+				job_id TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				url TEXT NOT NULL,
+				status TEXT NOT NULL,
+				error TEXT,
+				PRIMARY KEY (job_id, position)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_job_links_status ON job_links (job_id, status)`,
+		},
+	},
+	{
+		version: 10,
+		name:    "add job callbacks and webhook deliveries",
+		statements: []string{
+			`ALTER TABLE jobs ADD COLUMN callback_url TEXT`,
+			`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				job_id TEXT NOT NULL,
+				attempt INTEGER NOT NULL,
+				attempted_at INTEGER NOT NULL,
+				status_code INTEGER NOT NULL,
+				error TEXT,
+				PRIMARY KEY (job_id, attempt)
+			)`,
+		},
+		postgres: []string{
+			`ALTER TABLE jobs ADD COLUMN callback_url TEXT`,
+			`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				job_id TEXT NOT NULL,
+				attempt INTEGER NOT NULL,
+				attempted_at BIGINT NOT NULL,
+				status_code INTEGER NOT NULL,
+				error TEXT,
+				PRIMARY KEY (job_id, attempt)
+			)`,
+		},
+	},
+	{
+		version: 11,
+		name:    "add job priority",
+		statements: []string{
+			`ALTER TABLE jobs ADD COLUMN priority TEXT`,
+		},
+	},
+	{
+		version: 12,
+		name:    "add job owner",
+		statements: []string{
+			`ALTER TABLE jobs ADD COLUMN owner TEXT`,
+			`CREATE INDEX IF NOT EXISTS idx_jobs_owner ON jobs (owner)`,
+		},
+	},
+}
+
+// statementsFor возвращает SQL-выражения миграции для указанного диалекта.
+func (m migration) statementsFor(dialect string) []string {
+	if dialect == DialectPostgres && m.postgres != nil {
+		return m.postgres
+	}
+	return m.statements
+}
+
+// migrate применяет к базе данных все ещё не выполненные миграции.
+// Номера применённых миграций хранятся в таблице schema_migrations.
+// Миграции выполняются на одном соединении. В PostgreSQL оно держит advisory-блокировку
+// migrationLockID: реплики, запущенные одновременно с общей базой, применяют миграции
+// по очереди, и каждая читает номер версии только после получения блокировки.
+func (s *SQLDatabase) migrate() error {
+	ctx := context.Background()
+	conn, err := s.DB.Connx(ctx)
+	if err != nil {
+		s.Logger.Error("Failed to acquire connection for migrations", zap.Error(err))
+		return err
+	}
+	defer conn.Close()
+	if s.dialect == DialectPostgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+			s.Logger.Error("Failed to lock migrations", zap.Error(err))
+			return err
+		}
+		defer func() {
+			if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+				s.Logger.Warn("Failed to unlock migrations", zap.Error(err))
+			}
+		}()
+	}
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL
+	)`)
+	if err != nil {
+		s.Logger.Error("Failed to create schema_migrations table", zap.Error(err))
+		return err
+	}
+
+	var current int
+	if err := conn.GetContext(ctx, &current, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`); err != nil {
+		s.Logger.Error("Failed to read schema version", zap.Error(err))
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range m.statementsFor(s.dialect) {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				s.Logger.Error("Failed to apply migration", zap.Int("version", m.version), zap.Error(err))
+				return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+			}
+		}
+		if m.apply != nil {
+			if err := m.apply(tx); err != nil {
+				tx.Rollback()
+				s.Logger.Error("Failed to apply migration", zap.Int("version", m.version), zap.Error(err))
+				return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+			}
+		}
+		if _, err := tx.Exec(tx.Rebind(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`), m.version, m.name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		s.Logger.Info("Migration applied", zap.Int("version", m.version), zap.String("name", m.name))
+	}
+	return nil
+}
+
+// moveResourcePhotosToBlobs переносит изображения существующих ресурсов в таблицу blobs,
+// проставляя ссылку на хеш содержимого и счётчик ссылок.
+func moveResourcePhotosToBlobs(tx *sqlx.Tx) error {
+	var rows []struct {
+		ID    int64  `db:"id"`
+		Photo []byte `db:"photo"`
+	}
+	if err := tx.Select(&rows, `SELECT id, photo FROM resources WHERE photo IS NOT NULL AND blob_hash IS NULL`); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		hash := contentHash(row.Photo)
+		_, err := tx.Exec(tx.Rebind(`INSERT INTO blobs (hash, data, size, ref_count) VALUES (?, ?, ?, 1)
+			ON CONFLICT (hash) DO UPDATE SET ref_count = blobs.ref_count + 1`), hash, row.Photo, len(row.Photo))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(tx.Rebind(`UPDATE resources SET blob_hash = ?, photo = NULL WHERE id = ?`), hash, row.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillResourceVideoIDs проставляет идентификаторы видео существующим ресурсам по их URL.
+// Ссылки, из которых идентификатор извлечь не удаётся, остаются с пустым video_id.
+func fillResourceVideoIDs(tx *sqlx.Tx) error {
+	var rows []struct {
+		ID  int64  `db:"id"`
+		URL string `db:"url"`
+	}
+	if err := tx.Select(&rows, `SELECT id, url FROM resources`); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		videoID, err := youtubeclient.ExtractVideoID(row.URL)
+		if err != nil {
+			continue
+		}
+		if _, err := tx.Exec(tx.Rebind(`UPDATE resources SET video_id = ? WHERE id = ?`), videoID, row.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"shelon_server/utilss/logger"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// emptyPayloadHash — SHA-256 пустого тела запроса, используется для GET, HEAD и DELETE.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3BlobStore хранит содержимое изображений в бакете S3-совместимого хранилища
+// (AWS S3, MinIO, Ceph и т.п.). Объекты адресуются в path-style: endpoint/bucket/prefix+hash,
+// запросы подписываются AWS Signature Version 4.
+type S3BlobStore struct {
+	Logger    logger.Logger
+	Client    *http.Client
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string
+	now       func() time.Time
+}
+
+// NewS3BlobStore создает хранилище содержимого в бакете S3.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// endpoint: адрес сервиса, например https://s3.eu-central-1.amazonaws.com или http://localhost:9000.
+// region: регион для подписи запросов (для MinIO обычно us-east-1).
+// bucket: имя бакета.
+// accessKey, secretKey: ключи доступа.
+// prefix: префикс ключей объектов (может быть пустым).
+func NewS3BlobStore(logger logger.Logger, endpoint, region, bucket, accessKey, secretKey, prefix string) (*S3BlobStore, error) {
+	if endpoint == "" || bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket must be configured")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("s3 credentials must be configured")
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3BlobStore{
+		Logger:    logger,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		Endpoint:  strings.TrimRight(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Prefix:    prefix,
+		now:       time.Now,
+	}, nil
+}
+
+// Put загружает содержимое объектом в бакет. Если объект уже существует, загрузка пропускается.
+func (s *S3BlobStore) Put(hash string, data []byte) error {
+	if err := validateBlobHash(hash); err != nil {
+		return err
+	}
+	resp, err := s.do(http.MethodHead, hash, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return s.statusError(http.MethodHead, hash, resp)
+	}
+
+	resp, err = s.do(http.MethodPut, hash, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.statusError(http.MethodPut, hash, resp)
+	}
+	return nil
+}
+
+// Get скачивает содержимое по хешу. Возвращает nil, если объекта нет.
+func (s *S3BlobStore) Get(hash string) ([]byte, error) {
+	if err := validateBlobHash(hash); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(http.MethodGet, hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			s.Logger.Error("Failed to read s3 object", zap.String("hash", hash), zap.Error(err))
+			return nil, err
+		}
+		return data, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, s.statusError(http.MethodGet, hash, resp)
+	}
+}
+
+// Delete удаляет объект из бакета. Отсутствие объекта ошибкой не считается.
+func (s *S3BlobStore) Delete(hash string) error {
+	if err := validateBlobHash(hash); err != nil {
+		return err
+	}
+	resp, err := s.do(http.MethodDelete, hash, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.statusError(http.MethodDelete, hash, resp)
+	}
+	return nil
+}
+
+// do выполняет подписанный запрос к объекту с указанным хешем.
+func (s *S3BlobStore) do(method, hash string, body []byte) (*http.Response, error) {
+	objectURL := s.Endpoint + "/" + url.PathEscape(s.Bucket) + "/" + escapeObjectKey(s.Prefix+hash)
+	req, err := http.NewRequest(method, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	s.sign(req, payloadHash, s.now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		s.Logger.Error("S3 request failed", zap.String("method", method), zap.String("hash", hash), zap.Error(err))
+		return nil, err
+	}
+	return resp, nil
+}
+
+// statusError формирует ошибку для неожиданного ответа S3, включая начало тела ответа.
+func (s *S3BlobStore) statusError(method, hash string, resp *http.Response) error {
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	s.Logger.Error("Unexpected s3 response",
+		zap.String("method", method),
+		zap.String("hash", hash),
+		zap.Int("status", resp.StatusCode),
+		zap.ByteString("body", snippet),
+	)
+	return fmt.Errorf("s3 %s %s: unexpected status %d", method, hash, resp.StatusCode)
+}
+
+// sign подписывает запрос по схеме AWS Signature Version 4. Подписываются заголовок Host
+// и все заголовки, уже установленные в запросе, а также x-amz-date и x-amz-content-sha256.
+func (s *S3BlobStore) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// hmacSHA256 вычисляет HMAC-SHA256 от строки данных.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapeObjectKey кодирует ключ объекта для пути URL, сохраняя разделители "/".
+func escapeObjectKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+/*
+NewS3BlobStore создает хранилище содержимого в бакете S3.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+endpoint: адрес сервиса.
+region: регион для подписи запросов.
+bucket: имя бакета.
+accessKey, secretKey: ключи доступа.
+prefix: префикс ключей объектов.
+
+Put загружает содержимое объектом в бакет, пропуская уже существующие объекты.
+hash: SHA-256 содержимого.
+data: содержимое изображения.
+
+Get скачивает содержимое по хешу. Возвращает nil, если объекта нет.
+hash: SHA-256 содержимого.
+
+Delete удаляет объект из бакета.
+hash: SHA-256 содержимого.
+
+do выполняет подписанный запрос к объекту с указанным хешем.
+
+statusError формирует ошибку для неожиданного ответа S3.
+
+sign подписывает запрос по схеме AWS Signature Version 4.
+
+hmacSHA256 вычисляет HMAC-SHA256 от строки данных.
+
+escapeObjectKey кодирует ключ объекта для пути URL.
+*/
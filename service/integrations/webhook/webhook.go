@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+)
+
+// Заголовки запроса с уведомлением.
+const (
+	SignatureHeader = "X-Webhook-Signature" // Подпись: "sha256=" + HMAC-SHA256 строки "<timestamp>.<тело>" в hex
+	TimestampHeader = "X-Webhook-Timestamp" // Время подписи в секундах Unix
+	EventHeader     = "X-Webhook-Event"     // Тип события
+	DeliveryHeader  = "X-Webhook-Delivery"  // Номер попытки доставки, начиная с 1
+)
+
+// DefaultTolerance — рекомендуемое окно Verify: уведомление с временем подписи, отличающимся
+// от текущего больше чем на это значение, считается повтором перехваченного запроса.
+const DefaultTolerance = 5 * time.Minute
+
+// Параметры доставки по умолчанию.
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultTimeout        = 10 * time.Second
+	maxBackoff            = 5 * time.Minute
+	resolveTimeout        = 5 * time.Second
+)
+
+// ErrInvalidURL возвращается для адреса уведомлений, который не является абсолютным http(s) URL
+// или указывает во внутреннюю сеть.
+var ErrInvalidURL = errors.New("invalid callback URL")
+
+// errPrivateAddress возвращается при попытке соединиться с адресом во внутренней сети.
+var errPrivateAddress = errors.New("callback address is not public")
+
+// Attempt описывает одну попытку доставки уведомления.
+type Attempt struct {
+	Number     int       // Номер попытки, начиная с 1.
+	Time       time.Time // Время начала попытки.
+	StatusCode int       // HTTP-статус ответа; 0, если ответ не получен.
+	Err        error     // Ошибка попытки; nil для успешной доставки.
+}
+
+// Sender отправляет подписанные уведомления POST-запросами с JSON-телом.
+// Неудачные попытки (сетевая ошибка, ответ 5xx, 408 или 429) повторяются
+// с экспоненциально растущей паузой.
+// Пока не установлен AllowPrivateNetworks, уведомления отправляются только на публичные адреса:
+// адрес проверяется при постановке задания и повторно при каждом соединении, поэтому ни смена
+// DNS-записи после проверки, ни перенаправление не приводят запрос во внутреннюю сеть.
+type Sender struct {
+	Logger               logger.Logger
+	Client               *http.Client
+	Secret               []byte        // Ключ HMAC-SHA256 для подписи тела
+	MaxAttempts          int           // Максимум попыток доставки
+	InitialBackoff       time.Duration // Пауза перед второй попыткой; каждая следующая вдвое длиннее
+	AllowPrivateNetworks bool          // Разрешить адреса loopback, частных и link-local сетей
+}
+
+// NewSender создает отправителя уведомлений.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// secret: ключ подписи.
+// maxAttempts: максимум попыток доставки (0 — по умолчанию).
+// initialBackoff: пауза перед второй попыткой (0 — по умолчанию).
+// timeout: ограничение времени одной попытки (0 — по умолчанию).
+// allowPrivateNetworks: разрешить уведомления на адреса во внутренней сети.
+func NewSender(logger logger.Logger, secret string, maxAttempts int, initialBackoff, timeout time.Duration, allowPrivateNetworks bool) *Sender {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if initialBackoff <= 0 {
+		initialBackoff = defaultInitialBackoff
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	s := &Sender{
+		Logger:               logger,
+		Secret:               []byte(secret),
+		MaxAttempts:          maxAttempts,
+		InitialBackoff:       initialBackoff,
+		AllowPrivateNetworks: allowPrivateNetworks,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Через прокси checkDial проверил бы только адрес прокси, а соединение с адресом уведомления
+	// устанавливал бы сам прокси, поэтому уведомления отправляются напрямую, без HTTP(S)_PROXY
+	transport.Proxy = nil
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: s.checkDial}
+	transport.DialContext = dialer.DialContext
+	s.Client = &http.Client{Timeout: timeout, Transport: transport}
+	return s
+}
+
+// ValidateURL проверяет, что адрес уведомлений является абсолютным http(s) URL, а его хост,
+// пока не установлен AllowPrivateNetworks, разрешается только в публичные адреса.
+func (s *Sender) ValidateURL(callbackURL string) error {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, callbackURL)
+	}
+	if s.AllowPrivateNetworks {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: failed to resolve %q: %v", ErrInvalidURL, parsed.Hostname(), err)
+	}
+	for _, address := range addresses {
+		if !isPublic(address.IP) {
+			return fmt.Errorf("%w: %q resolves to non-public address %s", ErrInvalidURL, parsed.Hostname(), address.IP)
+		}
+	}
+	return nil
+}
+
+// checkDial отклоняет соединение с непубличным адресом. Вызывается для уже разрешённого адреса
+// перед каждым соединением, в том числе при перенаправлениях.
+func (s *Sender) checkDial(network, address string, _ syscall.RawConn) error {
+	if s.AllowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+// isPublic сообщает, что адрес не относится к loopback, частным, link-local (включая адрес
+// метаданных облака 169.254.169.254), групповым и неуказанным адресам.
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// Sign возвращает подпись тела, сделанную в момент timestamp (секунды Unix),
+// в формате заголовка SignatureHeader. Подписывается строка "<timestamp>.<тело>".
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись тела за постоянное время. Предназначена для получателей уведомлений.
+// timestamp — значение заголовка TimestampHeader; уведомление, подписанное больше чем на tolerance
+// раньше или позже текущего времени, отклоняется, чтобы перехваченный запрос нельзя было повторить.
+// Рекомендуемое окно — DefaultTolerance: его хватает на расхождение часов и время доставки,
+// а каждая повторная попытка подписывается заново.
+func Verify(secret, body []byte, timestamp, signature string, tolerance time.Duration) bool {
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, signedAt, body)), []byte(signature))
+}
+
+// Send доставляет уведомление event с телом body по адресу callbackURL, повторяя неудачные попытки.
+// record вызывается после каждой попытки (может быть nil). Возвращает ошибку последней попытки,
+// если уведомление не доставлено, или ошибку ctx, если ожидание следующей попытки прервано.
+func (s *Sender) Send(ctx context.Context, callbackURL, event string, body []byte, record func(Attempt)) error {
+	backoff := s.InitialBackoff
+	var err error
+	for number := 1; number <= s.MaxAttempts; number++ {
+		if number > 1 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		attempt := Attempt{Number: number, Time: time.Now()}
+		var retry bool
+		attempt.StatusCode, retry, err = s.post(ctx, callbackURL, event, number, body)
+		attempt.Err = err
+		if record != nil {
+			record(attempt)
+		}
+		if err == nil {
+			s.Logger.Info("Webhook delivered", zap.String("url", callbackURL), zap.String("event", event), zap.Int("attempt", number))
+			return nil
+		}
+		s.Logger.Warn("Webhook delivery failed", zap.String("url", callbackURL), zap.Int("attempt", number), zap.Error(err))
+		if !retry {
+			break
+		}
+	}
+	return err
+}
+
+// post выполняет одну попытку доставки, подписывая тело в момент отправки. Возвращает HTTP-статус,
+// признак того, что попытку имеет смысл повторить, и ошибку.
+// Соединение с непубличным адресом не повторяется.
+func (s *Sender) post(ctx context.Context, callbackURL, event string, number int, body []byte) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	timestamp := time.Now().Unix()
+	req.Header.Set(SignatureHeader, Sign(s.Secret, timestamp, body))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, fmt.Sprint(number))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil && !errors.Is(err, errPrivateAddress), fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
+
+/*
+NewSender создает отправителя уведомлений.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+secret: ключ подписи.
+maxAttempts: максимум попыток доставки.
+initialBackoff: пауза перед второй попыткой.
+timeout: ограничение времени одной попытки.
+allowPrivateNetworks: разрешить уведомления на адреса во внутренней сети.
+
+ValidateURL проверяет, что адрес уведомлений является абсолютным http(s) URL с публичным хостом.
+callbackURL: адрес уведомлений.
+
+checkDial отклоняет соединение с непубличным адресом.
+network: сеть соединения.
+address: разрешённый адрес в формате host:port.
+
+isPublic сообщает, что адрес не относится к внутренним сетям.
+ip: проверяемый адрес.
+
+Sign возвращает подпись тела в формате заголовка SignatureHeader.
+secret: ключ подписи.
+timestamp: время подписи в секундах Unix.
+body: тело уведомления.
+
+Verify проверяет подпись и время подписи тела.
+secret: ключ подписи.
+body: тело уведомления.
+timestamp: значение заголовка TimestampHeader.
+signature: значение заголовка SignatureHeader.
+tolerance: допустимое расхождение времени подписи с текущим.
+
+Send доставляет уведомление, повторяя неудачные попытки.
+ctx: контекст доставки.
+callbackURL: адрес получателя.
+event: тип события.
+body: JSON-тело уведомления.
+record: получатель сведений о каждой попытке.
+
+post выполняет одну попытку доставки.
+*/
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// MockLogger заглушка для логирования в тестах
+type MockLogger struct{}
+
+func (m *MockLogger) Info(message string, fields ...interface{})  {}
+func (m *MockLogger) Warn(message string, fields ...interface{})  {}
+func (m *MockLogger) Error(message string, fields ...interface{}) {}
+
+// TestSendRetries проверяет подпись уведомления и повтор после ответов 5xx.
+func TestSendRetries(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify([]byte("secret"), body, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), DefaultTolerance) {
+			t.Errorf("Invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get(EventHeader) != "job.finished" {
+			t.Errorf("Unexpected event %q", r.Header.Get(EventHeader))
+		}
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(&MockLogger{}, "secret", 5, time.Millisecond, time.Second, true)
+	var attempts []Attempt
+	err := sender.Send(context.Background(), receiver.URL, "job.finished", []byte(`{"ok":true}`), func(a Attempt) {
+		attempts = append(attempts, a)
+	})
+	if err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(attempts))
+	}
+	if attempts[0].StatusCode != http.StatusServiceUnavailable || attempts[0].Err == nil {
+		t.Errorf("Unexpected first attempt: %+v", attempts[0])
+	}
+	if attempts[2].Number != 3 || attempts[2].StatusCode != http.StatusNoContent || attempts[2].Err != nil {
+		t.Errorf("Unexpected last attempt: %+v", attempts[2])
+	}
+}
+
+// TestSendGivesUp проверяет, что ответ 4xx не повторяется, а недоступный получатель — не более MaxAttempts раз.
+func TestSendGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	sender := NewSender(&MockLogger{}, "secret", 3, time.Millisecond, time.Second, true)
+	if err := sender.Send(context.Background(), receiver.URL, "job.finished", []byte("{}"), nil); err == nil {
+		t.Errorf("Expected error for rejected delivery")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected a single attempt for 400, got %d", got)
+	}
+
+	// После закрытия получателя каждая попытка завершается сетевой ошибкой
+	receiver.Close()
+	attempts := 0
+	err := sender.Send(context.Background(), receiver.URL, "job.finished", []byte("{}"), func(Attempt) { attempts++ })
+	if err == nil || attempts != 3 {
+		t.Errorf("Expected 3 failed attempts, got %d (%v)", attempts, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewSender(&MockLogger{}, "secret", 3, time.Hour, time.Second, true).Send(ctx, receiver.URL, "job.finished", []byte("{}"), nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context error, got %v", err)
+	}
+}
+
+// TestValidateURL проверяет допустимые адреса уведомлений и отказ от адресов во внутренней сети.
+func TestValidateURL(t *testing.T) {
+	sender := NewSender(&MockLogger{}, "secret", 0, 0, 0, false)
+	for _, valid := range []string{"https://8.8.8.8/hook", "http://[2001:4860:4860::8888]:8080/jobs"} {
+		if err := sender.ValidateURL(valid); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", valid, err)
+		}
+	}
+	invalid := []string{
+		"", "example.com/hook", "ftp://example.com", "https://",
+		"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://[::1]/hook", "http://10.1.2.3/hook",
+		"http://172.16.0.1/hook", "http://192.168.1.1/hook", "http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook", "http://0.0.0.0/hook", "http://[::ffff:127.0.0.1]/hook",
+	}
+	for _, callbackURL := range invalid {
+		if err := sender.ValidateURL(callbackURL); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Expected %q to be invalid, got %v", callbackURL, err)
+		}
+	}
+
+	sender.AllowPrivateNetworks = true
+	if err := sender.ValidateURL("http://localhost:8080/hook"); err != nil {
+		t.Errorf("Expected private address to be allowed, got %v", err)
+	}
+}
+
+// TestSendRejectsPrivateAddress проверяет, что соединение с непубличным адресом отклоняется
+// при отправке без повторов, даже если адрес не проверялся при постановке задания.
+func TestSendRejectsPrivateAddress(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer receiver.Close()
+
+	attempts := 0
+	sender := NewSender(&MockLogger{}, "secret", 3, time.Millisecond, time.Second, false)
+	err := sender.Send(context.Background(), receiver.URL, "job.finished", []byte("{}"), func(Attempt) { attempts++ })
+	if !errors.Is(err, errPrivateAddress) || attempts != 1 {
+		t.Errorf("Expected a single rejected attempt, got %d (%v)", attempts, err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("Expected no request to reach the private address, got %d", got)
+	}
+}
+
+// TestSendIgnoresProxy проверяет, что уведомления не отправляются через прокси из окружения:
+// адрес уведомления проверяется и соединение с ним устанавливается самим отправителем.
+func TestSendIgnoresProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("HTTPS_PROXY", proxy.URL)
+
+	sender := NewSender(&MockLogger{}, "secret", 1, time.Millisecond, time.Second, false)
+	if sender.Client.Transport.(*http.Transport).Proxy != nil {
+		t.Fatal("Expected webhook transport not to use a proxy")
+	}
+	err := sender.Send(context.Background(), "http://10.255.255.1/hook", "job.finished", []byte("{}"), func(Attempt) {})
+	if !errors.Is(err, errPrivateAddress) || !strings.Contains(err.Error(), "10.255.255.1") {
+		t.Errorf("Expected the callback address itself to be rejected, got %v", err)
+	}
+	if got := proxied.Load(); got != 0 {
+		t.Errorf("Expected no request to reach the proxy, got %d", got)
+	}
+}
+
+// TestVerify проверяет отказ от подписи с изменённым телом или временем и от устаревшей подписи.
+func TestVerify(t *testing.T) {
+	secret, body := []byte("secret"), []byte(`{"ok":true}`)
+	now := time.Now().Unix()
+	timestamp := strconv.FormatInt(now, 10)
+	signature := Sign(secret, now, body)
+
+	if !Verify(secret, body, timestamp, signature, DefaultTolerance) {
+		t.Error("Expected fresh signature to be valid")
+	}
+	if Verify(secret, []byte(`{"ok":false}`), timestamp, signature, DefaultTolerance) {
+		t.Error("Expected signature of another body to be rejected")
+	}
+	if Verify(secret, body, strconv.FormatInt(now+1, 10), signature, DefaultTolerance) {
+		t.Error("Expected signature with another timestamp to be rejected")
+	}
+	if Verify(secret, body, "", signature, DefaultTolerance) {
+		t.Error("Expected signature without timestamp to be rejected")
+	}
+	stale := now - int64((DefaultTolerance + time.Minute).Seconds())
+	if Verify(secret, body, strconv.FormatInt(stale, 10), Sign(secret, stale, body), DefaultTolerance) {
+		t.Error("Expected stale signature to be rejected")
+	}
+}
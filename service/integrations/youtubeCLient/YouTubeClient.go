@@ -97,18 +97,18 @@ func (ys *YouTubeService) FetchThumbnail(link string) ([]byte, error) {
 
 // GenerateThumbnailURL генерирует URL обложки для указанной ссылки YouTube.
 func (ys *YouTubeService) GenerateThumbnailURL(videoURL string) (string, error) {
-	videoID, err := extractVideoID(videoURL)
+	videoID, err := ExtractVideoID(videoURL)
 	if err != nil {
 		ys.Logger.Error("Failed to extract video ID", zap.String("url", videoURL), zap.Error(err))
 		return "", err
 	}
-	thumbnailURL := fmt.Sprintf("https://img.youtube.com/vi/%s/maxresdefault.jpg", videoID)
+	thumbnailURL := fmt.Sprintf("https://img.youtube.com/vi/%s/%s.jpg", videoID, ThumbnailQuality)
 	ys.Logger.Info("Generated thumbnail URL", zap.String("videoID", videoID), zap.String("thumbnailURL", thumbnailURL))
 	return thumbnailURL, nil
 }
 
-// extractVideoID извлекает идентификатор видео из стандартной ссылки YouTube.
-func extractVideoID(videoURL string) (string, error) {
+// ExtractVideoID извлекает идентификатор видео из стандартной ссылки YouTube.
+func ExtractVideoID(videoURL string) (string, error) {
 	// Парсим URL
 	parsedURL, err := url.Parse(videoURL)
 	if err != nil {
@@ -159,5 +159,5 @@ link: URL изображения для загрузки.
 
 GenerateThumbnailURL генерирует URL обложки для указанной ссылки YouTube.
 
-extractVideoID извлекает идентификатор видео из стандартной ссылки YouTube.
+ExtractVideoID извлекает идентификатор видео из стандартной ссылки YouTube.
 */
@@ -1,5 +1,8 @@
 package youtubeclient
 
+// ThumbnailQuality — качество обложек, которые загружает клиент (имя файла превью YouTube).
+const ThumbnailQuality = "maxresdefault"
+
 // YouTubeClient определяет интерфейс клиента для обработки ссылок YouTube.
 type YouTubeClient interface {
 	ProcessLinks(links []string) error
@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log"
 	"os"
 	"shelon_server/handlers"
+	"shelon_server/imaging"
 	database "shelon_server/integrations/SQLLite"
+	"shelon_server/integrations/webhook"
 	youtubeclient "shelon_server/integrations/youtubeCLient"
 	"shelon_server/transport"
 	"shelon_server/usecase"
+	"shelon_server/utilss/auth"
 	"shelon_server/utilss/config"
 	"shelon_server/utilss/logger"
 	"shelon_server/utilss/server"
+	"shelon_server/utilss/urlsign"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -29,16 +36,54 @@ func main() {
 	}
 
 	// Инициализация базы данных
-	sqliteDB, err := database.NewSQLiteDatabase(loggerInstance, config.Database.DataSourceName)
+	var sqlDB *database.SQLDatabase
+	connectionOptions := newConnectionOptions(config.Database)
+	switch config.Database.Driver {
+	case "", database.DialectSQLite:
+		sqlDB, err = database.NewSQLiteDatabaseWithOptions(loggerInstance, config.Database.DataSourceName, connectionOptions)
+	case database.DialectPostgres:
+		var postgresDB *database.PostgresDatabase
+		postgresDB, err = database.NewPostgresDatabaseWithOptions(loggerInstance, config.Database.DataSourceName, connectionOptions)
+		if err == nil {
+			sqlDB = postgresDB.SQLDatabase
+		}
+	default:
+		err = fmt.Errorf("unknown database driver %q", config.Database.Driver)
+	}
 	if err != nil {
 		loggerInstance.Error("Error initializing database", zap.Error(err))
 		os.Exit(1)
 	}
-	if err := sqliteDB.InitDatabase(); err != nil {
+	blobStore, err := newBlobStore(loggerInstance, config.Database.Backend, config.Database, sqlDB)
+	if err != nil {
+		loggerInstance.Error("Error initializing blob store", zap.Error(err))
+		os.Exit(1)
+	}
+	sqlDB.Blobs = blobStore
+	// После смены backend недостающие изображения читаются из прежнего хранилища и переносятся
+	if config.Database.PreviousBackend != "" {
+		if blobBackend(config.Database.PreviousBackend) == blobBackend(config.Database.Backend) {
+			loggerInstance.Error("Previous database backend must differ from the current one", zap.String("backend", config.Database.Backend))
+			os.Exit(1)
+		}
+		previousStore, err := newBlobStore(loggerInstance, config.Database.PreviousBackend, config.Database, sqlDB)
+		if err != nil {
+			loggerInstance.Error("Error initializing previous blob store", zap.Error(err))
+			os.Exit(1)
+		}
+		sqlDB.Blobs = database.NewFallbackBlobStore(loggerInstance, blobStore, previousStore)
+	}
+	if err := sqlDB.InitDatabase(); err != nil {
 		loggerInstance.Error("Error creating tables", zap.Error(err))
 		os.Exit(1)
 	}
 
+	// LRU-кэш в памяти перед базой данных
+	var cacheDB database.Database = sqlDB
+	if config.Database.MemoryCacheBytes > 0 {
+		cacheDB = database.NewMemoryCachedDatabase(loggerInstance, sqlDB, config.Database.MemoryCacheBytes)
+	}
+
 	// Инициализация клиента YouTube
 	youtubeConnect, err := youtubeclient.NewYouTubeService(
 		loggerInstance,
@@ -51,8 +96,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Инициализация преобразований обложек
+	var transforms []imaging.Transform
+	if config.Overlay.Enabled {
+		opacity := 1.0
+		if config.Overlay.Opacity != nil {
+			opacity = *config.Overlay.Opacity
+		}
+		overlay, err := imaging.NewOverlay(
+			config.Overlay.ImagePath,
+			config.Overlay.Position,
+			opacity,
+			config.Overlay.Margin,
+		)
+		if err != nil {
+			loggerInstance.Error("Error loading overlay", zap.Error(err))
+			os.Exit(1)
+		}
+		transforms = append(transforms, overlay)
+	}
+
 	// Инициализация бизнес-логики
-	businessLogic := usecase.NewBusinessLogic(loggerInstance, sqliteDB, youtubeConnect)
+	businessLogic := usecase.NewBusinessLogic(loggerInstance, cacheDB, youtubeConnect, transforms, config.Jobs.FetchWorkers)
+	if config.Jobs.LaneWeights != nil {
+		businessLogic.Lanes = usecase.NewFetchScheduler(config.Jobs.FetchWorkers, config.Jobs.LaneWeights)
+	}
+	businessLogic.Limiter = usecase.NewRateLimiter(config.YoutubeClient.RequestsPerSecond, config.YoutubeClient.Burst)
 
 	// Инициализация обработчиков
 	dataHandler := handlers.NewDataHandler(loggerInstance, businessLogic)
@@ -60,12 +129,200 @@ func main() {
 	// Инициализация транспортного сервиса
 	transportService := transport.NewTransportService(dataHandler)
 
+	// Подписанные ссылки на обложки в HTTP-шлюзе
+	var urlSigner *urlsign.Signer
+	if len(config.HTTP.SignedURLs.Keys) > 0 {
+		keys := make([]urlsign.Key, 0, len(config.HTTP.SignedURLs.Keys))
+		for _, key := range config.HTTP.SignedURLs.Keys {
+			keys = append(keys, urlsign.Key{ID: key.ID, Secret: key.Secret})
+		}
+		urlSigner, err = urlsign.NewSigner(keys)
+		if err != nil {
+			loggerInstance.Error("Invalid url signing keys", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// Учётные данные клиентов проверяются перехватчиками gRPC и HTTP-шлюзом
+	apiKeys := make([]auth.APIKey, 0, len(config.Auth.APIKeys))
+	for _, key := range config.Auth.APIKeys {
+		apiKeys = append(apiKeys, auth.APIKey{Key: key.Key, Client: key.Client, Scopes: key.Scopes})
+	}
+	jwtOptions := auth.JWTOptions{Secret: config.Auth.JWT.Secret, Issuer: config.Auth.JWT.Issuer, Audience: config.Auth.JWT.Audience}
+	authenticator, err := auth.NewAuthenticator(apiKeys, jwtOptions, config.Admin.Token, config.Auth.AllowAnonymous)
+	if err != nil {
+		loggerInstance.Error("Invalid client credentials configuration", zap.Error(err))
+		os.Exit(1)
+	}
+	switch {
+	case !authenticator.Required():
+		loggerInstance.Warn("Anonymous access is allowed, TransportService and JobService accept calls without credentials")
+	case !authenticator.HasCredentials():
+		loggerInstance.Warn("Client credentials are not configured and anonymous access is disabled, all calls will be rejected")
+	}
+
+	// Сервис администрирования кэша доступен только при заданном токене или учётных данных клиентов
+	var adminService *transport.AdminService
+	if authenticator.HasCredentials() {
+		adminHandler := handlers.NewAdminHandler(loggerInstance, businessLogic)
+		if urlSigner != nil {
+			adminHandler.SignedURLs = &handlers.SignedURLs{
+				Signer:     urlSigner,
+				PublicURL:  config.HTTP.SignedURLs.PublicURL,
+				DefaultTTL: time.Duration(config.HTTP.SignedURLs.DefaultTTLSeconds) * time.Second,
+				MaxTTL:     time.Duration(config.HTTP.SignedURLs.MaxTTLSeconds) * time.Second,
+			}
+		}
+		adminService = transport.NewAdminService(adminHandler)
+	} else {
+		loggerInstance.Warn("Admin credentials are not configured, AdminService is disabled")
+	}
+
+	// Фоновые задания выполняются в течение всей жизни сервиса; незавершённые продолжаются после перезапуска
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobManager := usecase.NewJobManager(loggerInstance, businessLogic, sqlDB, config.Jobs.QueueSize, config.Jobs.MaxLinks)
+	if config.Webhooks.Secret != "" {
+		jobManager.Webhooks = webhook.NewSender(loggerInstance, config.Webhooks.Secret, config.Webhooks.MaxAttempts,
+			time.Duration(config.Webhooks.InitialBackoffMs)*time.Millisecond, time.Duration(config.Webhooks.TimeoutSeconds)*time.Second,
+			config.Webhooks.AllowPrivateNetworks)
+	} else {
+		loggerInstance.Warn("Webhook secret is not configured, job callbacks are disabled")
+	}
+	go jobManager.Run(ctx)
+
+	// Изображения прежнего хранилища переносятся в фоне, не дожидаясь обращений к ним
+	if config.Database.PreviousBackend != "" {
+		go func() {
+			moved, err := sqlDB.MigrateBlobs(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					loggerInstance.Error("Failed to migrate blobs from the previous backend", zap.Error(err))
+				}
+				return
+			}
+			loggerInstance.Info("Blob migration finished, previousBackend can be removed from the configuration", zap.Int("blobs", moved))
+		}()
+	}
+
+	// Обложки, закэшированные до вычисления характеристик, дополняются в фоне
+	go func() {
+		if _, err := businessLogic.BackfillFeatures(ctx); err != nil && ctx.Err() == nil {
+			loggerInstance.Error("Failed to backfill thumbnail features", zap.Error(err))
+		}
+	}()
+
+	// Плановое обновление популярных и устаревающих обложек
+	if config.Refresh.Enabled {
+		var tasks []usecase.RefreshTask
+		for _, schedule := range config.Refresh.Schedules {
+			task, err := usecase.NewRefreshTask(schedule.Name, schedule.Cron, schedule.Source, schedule.Limit, time.Duration(schedule.MaxAgeHours)*time.Hour)
+			if err != nil {
+				loggerInstance.Error("Invalid refresh schedule", zap.Error(err))
+				os.Exit(1)
+			}
+			tasks = append(tasks, task)
+		}
+		go usecase.NewRefreshScheduler(loggerInstance, businessLogic, tasks).Run(ctx)
+	}
+	jobService := transport.NewJobService(handlers.NewJobHandler(loggerInstance, jobManager))
+
+	// TLS и проверка клиентских сертификатов общие для gRPC-сервера и обоих шлюзов;
+	// изменённые сертификаты подхватываются без перезапуска
+	var tlsConfig, httpTLSConfig *tls.Config
+	if config.TLS.CertFile != "" {
+		certReloader, err := server.NewCertReloader(loggerInstance, config.TLS.CertFile, config.TLS.KeyFile, config.TLS.ClientCAFile)
+		if err != nil {
+			loggerInstance.Error("Error loading TLS certificates", zap.Error(err))
+			os.Exit(1)
+		}
+		go certReloader.Watch(ctx, time.Duration(config.TLS.ReloadIntervalSeconds)*time.Second)
+		tlsConfig, httpTLSConfig = certReloader.TLSConfig(), certReloader.HTTPTLSConfig()
+	} else {
+		loggerInstance.Warn("TLS certificate is not configured, gRPC, gRPC-Web and HTTP connections are not encrypted")
+	}
+
+	// HTTP-шлюз использует тот же обработчик данных, что и gRPC-сервер
+	if config.HTTP.Address != "" {
+		httpServer := server.NewHTTPServer(config.HTTP.Address, loggerInstance, dataHandler, time.Duration(config.HTTP.CacheMaxAgeSeconds)*time.Second, urlSigner, authenticator, httpTLSConfig)
+		go func() {
+			if err := httpServer.Start(ctx); err != nil {
+				loggerInstance.Error("Error starting HTTP server", zap.Error(err))
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Инициализация gRPC сервера
-	serverInstance := server.NewGRPCServer(config.GRPCServerAddress, loggerInstance, transportService)
-	if err := serverInstance.Start(context.TODO()); err != nil {
+	serverInstance := server.NewGRPCServer(config.GRPCServerAddress, loggerInstance, transportService, adminService, jobService, authenticator, tlsConfig)
+
+	// Шлюз gRPC-Web для браузерных клиентов передаёт вызовы тому же gRPC-серверу
+	if config.GRPCWeb.Address != "" {
+		grpcWebServer := server.NewGRPCWebServer(config.GRPCWeb.Address, loggerInstance, serverInstance, config.GRPCWeb.AllowedOrigins, httpTLSConfig)
+		go func() {
+			if err := grpcWebServer.Start(ctx); err != nil {
+				loggerInstance.Error("Error starting gRPC-Web server", zap.Error(err))
+				os.Exit(1)
+			}
+		}()
+	}
+	if err := serverInstance.Start(ctx); err != nil {
 		loggerInstance.Error("Error starting gRPC server", zap.Error(err))
 		os.Exit(1)
 	}
 
 	loggerInstance.Info("Server started successfully")
 }
+
+// blobBackend приводит название хранилища изображений к каноническому виду.
+func blobBackend(backend string) string {
+	if backend == "" || backend == "database" {
+		return "sqlite"
+	}
+	return backend
+}
+
+// newBlobStore создает хранилище изображений backend с параметрами из конфигурации.
+// Для хранения в самой реляционной базе возвращается хранилище на таблице blob_data базы db.
+func newBlobStore(logger logger.Logger, backend string, cfg config.DatabaseConfig, db *database.SQLDatabase) (database.BlobStore, error) {
+	switch blobBackend(backend) {
+	case "sqlite":
+		return &database.SQLBlobStore{Logger: logger, DB: db.DB, Builder: db.Builder}, nil
+	case "filesystem":
+		return database.NewFileBlobStore(logger, cfg.BlobDir)
+	case "s3":
+		return database.NewS3BlobStore(
+			logger,
+			cfg.S3.Endpoint,
+			cfg.S3.Region,
+			cfg.S3.Bucket,
+			cfg.S3.AccessKeyID,
+			cfg.S3.SecretAccessKey,
+			cfg.S3.Prefix,
+		)
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", backend)
+	}
+}
+
+// newConnectionOptions собирает параметры подключения к базе из конфигурации.
+// Незаданные в конфигурации значения остаются значениями по умолчанию.
+func newConnectionOptions(cfg config.DatabaseConfig) database.ConnectionOptions {
+	options := database.DefaultConnectionOptions()
+	if cfg.JournalMode != "" {
+		options.JournalMode = cfg.JournalMode
+	}
+	if cfg.BusyTimeoutMs > 0 {
+		options.BusyTimeout = time.Duration(cfg.BusyTimeoutMs) * time.Millisecond
+	}
+	if cfg.Synchronous != "" {
+		options.Synchronous = cfg.Synchronous
+	}
+	options.MaxOpenConns = cfg.MaxOpenConns
+	options.MaxIdleConns = cfg.MaxIdleConns
+	options.ConnMaxLifetime = time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second
+	if cfg.PreparedStatements != nil {
+		options.PreparedStatements = *cfg.PreparedStatements
+	}
+	return options
+}
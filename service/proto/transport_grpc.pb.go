@@ -19,7 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TransportService_SendData_FullMethodName = "/transport.TransportService/SendData"
+	TransportService_SendData_FullMethodName          = "/transport.TransportService/SendData"
+	TransportService_FindSimilar_FullMethodName       = "/transport.TransportService/FindSimilar"
+	TransportService_GetPlaceholders_FullMethodName   = "/transport.TransportService/GetPlaceholders"
+	TransportService_BuildContactSheet_FullMethodName = "/transport.TransportService/BuildContactSheet"
+	TransportService_GetCacheStats_FullMethodName     = "/transport.TransportService/GetCacheStats"
 )
 
 // TransportServiceClient is the client API for TransportService service.
@@ -30,6 +34,14 @@ const (
 type TransportServiceClient interface {
 	// RPC метод для отправки данных на сервер
 	SendData(ctx context.Context, in *SendDataRequest, opts ...grpc.CallOption) (*SendDataResponse, error)
+	// RPC метод для поиска похожих закэшированных обложек
+	FindSimilar(ctx context.Context, in *FindSimilarRequest, opts ...grpc.CallOption) (*FindSimilarResponse, error)
+	// RPC метод для получения заглушек (BlurHash и миниатюр) без передачи изображений
+	GetPlaceholders(ctx context.Context, in *GetPlaceholdersRequest, opts ...grpc.CallOption) (*GetPlaceholdersResponse, error)
+	// RPC метод для сборки контактного листа (мозаики) из обложек нескольких видео
+	BuildContactSheet(ctx context.Context, in *ContactSheetRequest, opts ...grpc.CallOption) (*ContactSheetResponse, error)
+	// RPC метод для получения статистики кэша и счётчиков обращений с момента запуска
+	GetCacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (*CacheStatsResponse, error)
 }
 
 type transportServiceClient struct {
@@ -50,6 +62,46 @@ func (c *transportServiceClient) SendData(ctx context.Context, in *SendDataReque
 	return out, nil
 }
 
+func (c *transportServiceClient) FindSimilar(ctx context.Context, in *FindSimilarRequest, opts ...grpc.CallOption) (*FindSimilarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindSimilarResponse)
+	err := c.cc.Invoke(ctx, TransportService_FindSimilar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transportServiceClient) GetPlaceholders(ctx context.Context, in *GetPlaceholdersRequest, opts ...grpc.CallOption) (*GetPlaceholdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPlaceholdersResponse)
+	err := c.cc.Invoke(ctx, TransportService_GetPlaceholders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transportServiceClient) BuildContactSheet(ctx context.Context, in *ContactSheetRequest, opts ...grpc.CallOption) (*ContactSheetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ContactSheetResponse)
+	err := c.cc.Invoke(ctx, TransportService_BuildContactSheet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transportServiceClient) GetCacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (*CacheStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CacheStatsResponse)
+	err := c.cc.Invoke(ctx, TransportService_GetCacheStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TransportServiceServer is the server API for TransportService service.
 // All implementations must embed UnimplementedTransportServiceServer
 // for forward compatibility.
@@ -58,6 +110,14 @@ func (c *transportServiceClient) SendData(ctx context.Context, in *SendDataReque
 type TransportServiceServer interface {
 	// RPC метод для отправки данных на сервер
 	SendData(context.Context, *SendDataRequest) (*SendDataResponse, error)
+	// RPC метод для поиска похожих закэшированных обложек
+	FindSimilar(context.Context, *FindSimilarRequest) (*FindSimilarResponse, error)
+	// RPC метод для получения заглушек (BlurHash и миниатюр) без передачи изображений
+	GetPlaceholders(context.Context, *GetPlaceholdersRequest) (*GetPlaceholdersResponse, error)
+	// RPC метод для сборки контактного листа (мозаики) из обложек нескольких видео
+	BuildContactSheet(context.Context, *ContactSheetRequest) (*ContactSheetResponse, error)
+	// RPC метод для получения статистики кэша и счётчиков обращений с момента запуска
+	GetCacheStats(context.Context, *CacheStatsRequest) (*CacheStatsResponse, error)
 	mustEmbedUnimplementedTransportServiceServer()
 }
 
@@ -71,6 +131,18 @@ type UnimplementedTransportServiceServer struct{}
 func (UnimplementedTransportServiceServer) SendData(context.Context, *SendDataRequest) (*SendDataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendData not implemented")
 }
+func (UnimplementedTransportServiceServer) FindSimilar(context.Context, *FindSimilarRequest) (*FindSimilarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSimilar not implemented")
+}
+func (UnimplementedTransportServiceServer) GetPlaceholders(context.Context, *GetPlaceholdersRequest) (*GetPlaceholdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlaceholders not implemented")
+}
+func (UnimplementedTransportServiceServer) BuildContactSheet(context.Context, *ContactSheetRequest) (*ContactSheetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuildContactSheet not implemented")
+}
+func (UnimplementedTransportServiceServer) GetCacheStats(context.Context, *CacheStatsRequest) (*CacheStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCacheStats not implemented")
+}
 func (UnimplementedTransportServiceServer) mustEmbedUnimplementedTransportServiceServer() {}
 func (UnimplementedTransportServiceServer) testEmbeddedByValue()                          {}
 
@@ -110,6 +182,78 @@ func _TransportService_SendData_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TransportService_FindSimilar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSimilarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransportServiceServer).FindSimilar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransportService_FindSimilar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransportServiceServer).FindSimilar(ctx, req.(*FindSimilarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransportService_GetPlaceholders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlaceholdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransportServiceServer).GetPlaceholders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransportService_GetPlaceholders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransportServiceServer).GetPlaceholders(ctx, req.(*GetPlaceholdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransportService_BuildContactSheet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContactSheetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransportServiceServer).BuildContactSheet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransportService_BuildContactSheet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransportServiceServer).BuildContactSheet(ctx, req.(*ContactSheetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransportService_GetCacheStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CacheStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransportServiceServer).GetCacheStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransportService_GetCacheStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransportServiceServer).GetCacheStats(ctx, req.(*CacheStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TransportService_ServiceDesc is the grpc.ServiceDesc for TransportService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -121,7 +265,683 @@ var TransportService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendData",
 			Handler:    _TransportService_SendData_Handler,
 		},
+		{
+			MethodName: "FindSimilar",
+			Handler:    _TransportService_FindSimilar_Handler,
+		},
+		{
+			MethodName: "GetPlaceholders",
+			Handler:    _TransportService_GetPlaceholders_Handler,
+		},
+		{
+			MethodName: "BuildContactSheet",
+			Handler:    _TransportService_BuildContactSheet_Handler,
+		},
+		{
+			MethodName: "GetCacheStats",
+			Handler:    _TransportService_GetCacheStats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "transport.proto",
 }
+
+const (
+	JobService_WarmCache_FullMethodName = "/transport.JobService/WarmCache"
+	JobService_SubmitJob_FullMethodName = "/transport.JobService/SubmitJob"
+	JobService_GetJob_FullMethodName    = "/transport.JobService/GetJob"
+	JobService_ListJobs_FullMethodName  = "/transport.JobService/ListJobs"
+	JobService_CancelJob_FullMethodName = "/transport.JobService/CancelJob"
+	JobService_WatchJob_FullMethodName  = "/transport.JobService/WatchJob"
+)
+
+// JobServiceClient is the client API for JobService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Сервис фоновых заданий: задания выполняются сервером без удержания соединения клиента,
+// прогресс опрашивается по идентификатору задания. Клиент видит и отменяет только поставленные
+// им задания; клиенту с областью доступа admin доступны все задания
+type JobServiceClient interface {
+	// RPC метод для постановки в очередь предварительной загрузки обложек. Ссылки передаются
+	// полем links и/или текстовым файлом (по ссылке в строке), загружаемым частями
+	WarmCache(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WarmCacheRequest, WarmCacheResponse], error)
+	// RPC метод для постановки в очередь задания загрузки обложек по списку ссылок.
+	// Возвращает задание сразу, не дожидаясь загрузки
+	SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error)
+	// RPC метод для получения состояния и прогресса задания
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error)
+	// RPC метод для постраничного списка заданий, начиная с самых новых
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// RPC метод для отмены задания из очереди или выполняющегося задания
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	// RPC метод для наблюдения за прогрессом задания: поток завершается вместе с заданием
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Job], error)
+}
+
+type jobServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJobServiceClient(cc grpc.ClientConnInterface) JobServiceClient {
+	return &jobServiceClient{cc}
+}
+
+func (c *jobServiceClient) WarmCache(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WarmCacheRequest, WarmCacheResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JobService_ServiceDesc.Streams[0], JobService_WarmCache_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WarmCacheRequest, WarmCacheResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobService_WarmCacheClient = grpc.ClientStreamingClient[WarmCacheRequest, WarmCacheResponse]
+
+func (c *jobServiceClient) SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitJobResponse)
+	err := c.cc.Invoke(ctx, JobService_SubmitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetJobResponse)
+	err := c.cc.Invoke(ctx, JobService_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, JobService_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, JobService_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Job], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JobService_ServiceDesc.Streams[1], JobService_WatchJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobRequest, Job]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobService_WatchJobClient = grpc.ServerStreamingClient[Job]
+
+// JobServiceServer is the server API for JobService service.
+// All implementations must embed UnimplementedJobServiceServer
+// for forward compatibility.
+//
+// Сервис фоновых заданий: задания выполняются сервером без удержания соединения клиента,
+// прогресс опрашивается по идентификатору задания. Клиент видит и отменяет только поставленные
+// им задания; клиенту с областью доступа admin доступны все задания
+type JobServiceServer interface {
+	// RPC метод для постановки в очередь предварительной загрузки обложек. Ссылки передаются
+	// полем links и/или текстовым файлом (по ссылке в строке), загружаемым частями
+	WarmCache(grpc.ClientStreamingServer[WarmCacheRequest, WarmCacheResponse]) error
+	// RPC метод для постановки в очередь задания загрузки обложек по списку ссылок.
+	// Возвращает задание сразу, не дожидаясь загрузки
+	SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error)
+	// RPC метод для получения состояния и прогресса задания
+	GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error)
+	// RPC метод для постраничного списка заданий, начиная с самых новых
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// RPC метод для отмены задания из очереди или выполняющегося задания
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	// RPC метод для наблюдения за прогрессом задания: поток завершается вместе с заданием
+	WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[Job]) error
+	mustEmbedUnimplementedJobServiceServer()
+}
+
+// UnimplementedJobServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJobServiceServer struct{}
+
+func (UnimplementedJobServiceServer) WarmCache(grpc.ClientStreamingServer[WarmCacheRequest, WarmCacheResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WarmCache not implemented")
+}
+func (UnimplementedJobServiceServer) SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedJobServiceServer) GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedJobServiceServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedJobServiceServer) CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedJobServiceServer) WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[Job]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (UnimplementedJobServiceServer) mustEmbedUnimplementedJobServiceServer() {}
+func (UnimplementedJobServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeJobServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JobServiceServer will
+// result in compilation errors.
+type UnsafeJobServiceServer interface {
+	mustEmbedUnimplementedJobServiceServer()
+}
+
+func RegisterJobServiceServer(s grpc.ServiceRegistrar, srv JobServiceServer) {
+	// If the following call pancis, it indicates UnimplementedJobServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&JobService_ServiceDesc, srv)
+}
+
+func _JobService_WarmCache_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(JobServiceServer).WarmCache(&grpc.GenericServerStream[WarmCacheRequest, WarmCacheResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobService_WarmCacheServer = grpc.ClientStreamingServer[WarmCacheRequest, WarmCacheResponse]
+
+func _JobService_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_SubmitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).SubmitJob(ctx, req.(*SubmitJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobServiceServer).WatchJob(m, &grpc.GenericServerStream[WatchJobRequest, Job]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobService_WatchJobServer = grpc.ServerStreamingServer[Job]
+
+// JobService_ServiceDesc is the grpc.ServiceDesc for JobService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JobService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transport.JobService",
+	HandlerType: (*JobServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitJob",
+			Handler:    _JobService_SubmitJob_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _JobService_GetJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _JobService_ListJobs_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _JobService_CancelJob_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WarmCache",
+			Handler:       _JobService_WarmCache_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchJob",
+			Handler:       _JobService_WatchJob_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "transport.proto",
+}
+
+const (
+	AdminService_ListEntries_FullMethodName      = "/transport.AdminService/ListEntries"
+	AdminService_GetEntry_FullMethodName         = "/transport.AdminService/GetEntry"
+	AdminService_PurgeEntries_FullMethodName     = "/transport.AdminService/PurgeEntries"
+	AdminService_RefreshEntries_FullMethodName   = "/transport.AdminService/RefreshEntries"
+	AdminService_ExportCache_FullMethodName      = "/transport.AdminService/ExportCache"
+	AdminService_ImportCache_FullMethodName      = "/transport.AdminService/ImportCache"
+	AdminService_SignThumbnailURL_FullMethodName = "/transport.AdminService/SignThumbnailURL"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Сервис администрирования кэша. Требует административного токена или учетных данных
+// с областью доступа admin в метаданных запроса: authorization: Bearer <token>
+type AdminServiceClient interface {
+	// RPC метод для постраничного просмотра записей кэша
+	ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error)
+	// RPC метод для получения метаданных одной записи без изображения
+	GetEntry(ctx context.Context, in *GetEntryRequest, opts ...grpc.CallOption) (*GetEntryResponse, error)
+	// RPC метод для удаления записей по ссылкам, возрасту или всех сразу
+	PurgeEntries(ctx context.Context, in *PurgeEntriesRequest, opts ...grpc.CallOption) (*PurgeEntriesResponse, error)
+	// RPC метод для принудительной повторной загрузки обложек
+	RefreshEntries(ctx context.Context, in *RefreshEntriesRequest, opts ...grpc.CallOption) (*RefreshEntriesResponse, error)
+	// RPC метод для выгрузки кэша в архив (tar или zip), передаваемый частями
+	ExportCache(ctx context.Context, in *ExportCacheRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArchiveChunk], error)
+	// RPC метод для загрузки архива в кэш; архив передаётся частями
+	ImportCache(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportCacheRequest, ImportCacheResponse], error)
+	// RPC метод для выдачи подписанной ссылки на обложку в HTTP-шлюзе с ограниченным сроком действия
+	SignThumbnailURL(ctx context.Context, in *SignThumbnailURLRequest, opts ...grpc.CallOption) (*SignThumbnailURLResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEntriesResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetEntry(ctx context.Context, in *GetEntryRequest, opts ...grpc.CallOption) (*GetEntryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEntryResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) PurgeEntries(ctx context.Context, in *PurgeEntriesRequest, opts ...grpc.CallOption) (*PurgeEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeEntriesResponse)
+	err := c.cc.Invoke(ctx, AdminService_PurgeEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RefreshEntries(ctx context.Context, in *RefreshEntriesRequest, opts ...grpc.CallOption) (*RefreshEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefreshEntriesResponse)
+	err := c.cc.Invoke(ctx, AdminService_RefreshEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ExportCache(ctx context.Context, in *ExportCacheRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArchiveChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[0], AdminService_ExportCache_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportCacheRequest, ArchiveChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportCacheClient = grpc.ServerStreamingClient[ArchiveChunk]
+
+func (c *adminServiceClient) ImportCache(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportCacheRequest, ImportCacheResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[1], AdminService_ImportCache_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportCacheRequest, ImportCacheResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ImportCacheClient = grpc.ClientStreamingClient[ImportCacheRequest, ImportCacheResponse]
+
+func (c *adminServiceClient) SignThumbnailURL(ctx context.Context, in *SignThumbnailURLRequest, opts ...grpc.CallOption) (*SignThumbnailURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SignThumbnailURLResponse)
+	err := c.cc.Invoke(ctx, AdminService_SignThumbnailURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// Сервис администрирования кэша. Требует административного токена или учетных данных
+// с областью доступа admin в метаданных запроса: authorization: Bearer <token>
+type AdminServiceServer interface {
+	// RPC метод для постраничного просмотра записей кэша
+	ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error)
+	// RPC метод для получения метаданных одной записи без изображения
+	GetEntry(context.Context, *GetEntryRequest) (*GetEntryResponse, error)
+	// RPC метод для удаления записей по ссылкам, возрасту или всех сразу
+	PurgeEntries(context.Context, *PurgeEntriesRequest) (*PurgeEntriesResponse, error)
+	// RPC метод для принудительной повторной загрузки обложек
+	RefreshEntries(context.Context, *RefreshEntriesRequest) (*RefreshEntriesResponse, error)
+	// RPC метод для выгрузки кэша в архив (tar или zip), передаваемый частями
+	ExportCache(*ExportCacheRequest, grpc.ServerStreamingServer[ArchiveChunk]) error
+	// RPC метод для загрузки архива в кэш; архив передаётся частями
+	ImportCache(grpc.ClientStreamingServer[ImportCacheRequest, ImportCacheResponse]) error
+	// RPC метод для выдачи подписанной ссылки на обложку в HTTP-шлюзе с ограниченным сроком действия
+	SignThumbnailURL(context.Context, *SignThumbnailURLRequest) (*SignThumbnailURLResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEntries not implemented")
+}
+func (UnimplementedAdminServiceServer) GetEntry(context.Context, *GetEntryRequest) (*GetEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEntry not implemented")
+}
+func (UnimplementedAdminServiceServer) PurgeEntries(context.Context, *PurgeEntriesRequest) (*PurgeEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeEntries not implemented")
+}
+func (UnimplementedAdminServiceServer) RefreshEntries(context.Context, *RefreshEntriesRequest) (*RefreshEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshEntries not implemented")
+}
+func (UnimplementedAdminServiceServer) ExportCache(*ExportCacheRequest, grpc.ServerStreamingServer[ArchiveChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportCache not implemented")
+}
+func (UnimplementedAdminServiceServer) ImportCache(grpc.ClientStreamingServer[ImportCacheRequest, ImportCacheResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportCache not implemented")
+}
+func (UnimplementedAdminServiceServer) SignThumbnailURL(context.Context, *SignThumbnailURLRequest) (*SignThumbnailURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignThumbnailURL not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_ListEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListEntries(ctx, req.(*ListEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetEntry(ctx, req.(*GetEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_PurgeEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).PurgeEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_PurgeEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).PurgeEntries(ctx, req.(*PurgeEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RefreshEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RefreshEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RefreshEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RefreshEntries(ctx, req.(*RefreshEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ExportCache_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportCacheRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).ExportCache(m, &grpc.GenericServerStream[ExportCacheRequest, ArchiveChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportCacheServer = grpc.ServerStreamingServer[ArchiveChunk]
+
+func _AdminService_ImportCache_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServiceServer).ImportCache(&grpc.GenericServerStream[ImportCacheRequest, ImportCacheResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ImportCacheServer = grpc.ClientStreamingServer[ImportCacheRequest, ImportCacheResponse]
+
+func _AdminService_SignThumbnailURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignThumbnailURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SignThumbnailURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SignThumbnailURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SignThumbnailURL(ctx, req.(*SignThumbnailURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transport.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListEntries",
+			Handler:    _AdminService_ListEntries_Handler,
+		},
+		{
+			MethodName: "GetEntry",
+			Handler:    _AdminService_GetEntry_Handler,
+		},
+		{
+			MethodName: "PurgeEntries",
+			Handler:    _AdminService_PurgeEntries_Handler,
+		},
+		{
+			MethodName: "RefreshEntries",
+			Handler:    _AdminService_RefreshEntries_Handler,
+		},
+		{
+			MethodName: "SignThumbnailURL",
+			Handler:    _AdminService_SignThumbnailURL_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportCache",
+			Handler:       _AdminService_ExportCache_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportCache",
+			Handler:       _AdminService_ImportCache_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "transport.proto",
+}
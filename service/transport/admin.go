@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+
+	"shelon_server/handlers"
+	pb "shelon_server/proto"
+)
+
+// AdminService представляет реализацию gRPC-сервиса администрирования кэша.
+type AdminService struct {
+	pb.UnimplementedAdminServiceServer
+	handler *handlers.AdminHandler
+}
+
+// NewAdminService создает новый экземпляр AdminService с предоставленным обработчиком.
+// handler: экземпляр обработчика запросов администрирования.
+func NewAdminService(handler *handlers.AdminHandler) *AdminService {
+	return &AdminService{handler: handler}
+}
+
+// ListEntries обрабатывает запрос на просмотр записей кэша через gRPC.
+// ctx: контекст выполнения.
+// req: запрос на просмотр записей в формате proto.
+// Возвращает страницу записей в формате proto и ошибку, если она возникла.
+func (as *AdminService) ListEntries(ctx context.Context, req *pb.ListEntriesRequest) (*pb.ListEntriesResponse, error) {
+	return as.handler.HandleListEntries(ctx, req)
+}
+
+// GetEntry обрабатывает запрос метаданных записи кэша через gRPC.
+// ctx: контекст выполнения.
+// req: запрос записи в формате proto.
+// Возвращает метаданные записи в формате proto и ошибку, если она возникла.
+func (as *AdminService) GetEntry(ctx context.Context, req *pb.GetEntryRequest) (*pb.GetEntryResponse, error) {
+	return as.handler.HandleGetEntry(ctx, req)
+}
+
+// PurgeEntries обрабатывает запрос на удаление записей кэша через gRPC.
+// ctx: контекст выполнения.
+// req: запрос на удаление в формате proto.
+// Возвращает результат удаления в формате proto и ошибку, если она возникла.
+func (as *AdminService) PurgeEntries(ctx context.Context, req *pb.PurgeEntriesRequest) (*pb.PurgeEntriesResponse, error) {
+	return as.handler.HandlePurgeEntries(ctx, req)
+}
+
+// RefreshEntries обрабатывает запрос на повторную загрузку обложек через gRPC.
+// ctx: контекст выполнения.
+// req: запрос на обновление в формате proto.
+// Возвращает результаты обновления в формате proto и ошибку, если она возникла.
+func (as *AdminService) RefreshEntries(ctx context.Context, req *pb.RefreshEntriesRequest) (*pb.RefreshEntriesResponse, error) {
+	return as.handler.HandleRefreshEntries(ctx, req)
+}
+
+// ExportCache обрабатывает запрос на выгрузку кэша в архив через gRPC.
+// req: запрос на выгрузку в формате proto.
+// stream: поток, в который архив отправляется частями.
+func (as *AdminService) ExportCache(req *pb.ExportCacheRequest, stream pb.AdminService_ExportCacheServer) error {
+	return as.handler.HandleExportCache(req, stream)
+}
+
+// ImportCache обрабатывает загрузку архива в кэш через gRPC.
+// stream: поток частей архива.
+func (as *AdminService) ImportCache(stream pb.AdminService_ImportCacheServer) error {
+	return as.handler.HandleImportCache(stream)
+}
+
+// SignThumbnailURL обрабатывает запрос подписанной ссылки на обложку через gRPC.
+// ctx: контекст выполнения.
+// req: запрос подписанной ссылки в формате proto.
+// Возвращает подписанную ссылку в формате proto и ошибку, если она возникла.
+func (as *AdminService) SignThumbnailURL(ctx context.Context, req *pb.SignThumbnailURLRequest) (*pb.SignThumbnailURLResponse, error) {
+	return as.handler.HandleSignThumbnailURL(ctx, req)
+}
+
+/*
+NewAdminService создает новый экземпляр AdminService с предоставленным обработчиком.
+handler: экземпляр обработчика запросов администрирования.
+
+ListEntries обрабатывает запрос на просмотр записей кэша через gRPC.
+ctx: контекст выполнения.
+req: запрос на просмотр записей в формате proto.
+Возвращает страницу записей в формате proto и ошибку, если она возникла.
+
+GetEntry обрабатывает запрос метаданных записи кэша через gRPC.
+ctx: контекст выполнения.
+req: запрос записи в формате proto.
+Возвращает метаданные записи в формате proto и ошибку, если она возникла.
+
+PurgeEntries обрабатывает запрос на удаление записей кэша через gRPC.
+ctx: контекст выполнения.
+req: запрос на удаление в формате proto.
+Возвращает результат удаления в формате proto и ошибку, если она возникла.
+
+RefreshEntries обрабатывает запрос на повторную загрузку обложек через gRPC.
+ctx: контекст выполнения.
+req: запрос на обновление в формате proto.
+Возвращает результаты обновления в формате proto и ошибку, если она возникла.
+
+ExportCache обрабатывает запрос на выгрузку кэша в архив через gRPC.
+req: запрос на выгрузку в формате proto.
+stream: поток, в который архив отправляется частями.
+
+ImportCache обрабатывает загрузку архива в кэш через gRPC.
+stream: поток частей архива.
+
+SignThumbnailURL обрабатывает запрос подписанной ссылки на обложку через gRPC.
+ctx: контекст выполнения.
+req: запрос подписанной ссылки в формате proto.
+Возвращает подписанную ссылку в формате proto и ошибку, если она возникла.
+*/
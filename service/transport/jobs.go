@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"context"
+
+	"shelon_server/handlers"
+	pb "shelon_server/proto"
+)
+
+// JobService представляет реализацию gRPC-сервиса фоновых заданий.
+type JobService struct {
+	pb.UnimplementedJobServiceServer
+	handler *handlers.JobHandler
+}
+
+// NewJobService создает новый экземпляр JobService с предоставленным обработчиком.
+// handler: экземпляр обработчика запросов фоновых заданий.
+func NewJobService(handler *handlers.JobHandler) *JobService {
+	return &JobService{handler: handler}
+}
+
+// WarmCache обрабатывает запрос на предварительную загрузку обложек через gRPC.
+// stream: поток частей запроса со ссылками.
+func (js *JobService) WarmCache(stream pb.JobService_WarmCacheServer) error {
+	return js.handler.HandleWarmCache(stream)
+}
+
+// GetJob обрабатывает запрос состояния задания через gRPC.
+// ctx: контекст выполнения.
+// req: запрос состояния в формате proto.
+// Возвращает задание в формате proto и ошибку, если она возникла.
+func (js *JobService) GetJob(ctx context.Context, req *pb.GetJobRequest) (*pb.GetJobResponse, error) {
+	return js.handler.HandleGetJob(ctx, req)
+}
+
+// SubmitJob обрабатывает запрос на постановку задания загрузки обложек через gRPC.
+// ctx: контекст выполнения.
+// req: запрос со ссылками в формате proto.
+// Возвращает поставленное задание и ошибку, если она возникла.
+func (js *JobService) SubmitJob(ctx context.Context, req *pb.SubmitJobRequest) (*pb.SubmitJobResponse, error) {
+	return js.handler.HandleSubmitJob(ctx, req)
+}
+
+// ListJobs обрабатывает запрос списка заданий через gRPC.
+// ctx: контекст выполнения.
+// req: фильтр и параметры страницы в формате proto.
+// Возвращает страницу заданий и ошибку, если она возникла.
+func (js *JobService) ListJobs(ctx context.Context, req *pb.ListJobsRequest) (*pb.ListJobsResponse, error) {
+	return js.handler.HandleListJobs(ctx, req)
+}
+
+// CancelJob обрабатывает запрос отмены задания через gRPC.
+// ctx: контекст выполнения.
+// req: запрос отмены в формате proto.
+// Возвращает задание и ошибку, если она возникла.
+func (js *JobService) CancelJob(ctx context.Context, req *pb.CancelJobRequest) (*pb.CancelJobResponse, error) {
+	return js.handler.HandleCancelJob(ctx, req)
+}
+
+// WatchJob обрабатывает запрос наблюдения за прогрессом задания через gRPC.
+// req: запрос наблюдения в формате proto.
+// stream: поток снимков задания.
+func (js *JobService) WatchJob(req *pb.WatchJobRequest, stream pb.JobService_WatchJobServer) error {
+	return js.handler.HandleWatchJob(req, stream)
+}
+
+/*
+NewJobService создает новый экземпляр JobService с предоставленным обработчиком.
+handler: экземпляр обработчика запросов фоновых заданий.
+
+WarmCache обрабатывает запрос на предварительную загрузку обложек через gRPC.
+stream: поток частей запроса со ссылками.
+
+GetJob обрабатывает запрос состояния задания через gRPC.
+ctx: контекст выполнения.
+req: запрос состояния в формате proto.
+Возвращает задание в формате proto и ошибку, если она возникла.
+
+SubmitJob обрабатывает запрос на постановку задания загрузки обложек через gRPC.
+ctx: контекст выполнения.
+req: запрос со ссылками в формате proto.
+Возвращает поставленное задание и ошибку, если она возникла.
+
+ListJobs обрабатывает запрос списка заданий через gRPC.
+ctx: контекст выполнения.
+req: фильтр и параметры страницы в формате proto.
+Возвращает страницу заданий и ошибку, если она возникла.
+
+CancelJob обрабатывает запрос отмены задания через gRPC.
+ctx: контекст выполнения.
+req: запрос отмены в формате proto.
+Возвращает задание и ошибку, если она возникла.
+
+WatchJob обрабатывает запрос наблюдения за прогрессом задания через gRPC.
+req: запрос наблюдения в формате proto.
+stream: поток снимков задания.
+*/
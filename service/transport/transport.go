@@ -27,6 +27,38 @@ func (ts *TransportService) SendData(ctx context.Context, req *pb.SendDataReques
 	return ts.handler.HandleSendData(ctx, req)
 }
 
+// FindSimilar обрабатывает запрос на поиск похожих обложек через gRPC.
+// ctx: контекст выполнения.
+// req: запрос на поиск в формате proto.
+// Возвращает найденные обложки в формате proto и ошибку, если она возникла.
+func (ts *TransportService) FindSimilar(ctx context.Context, req *pb.FindSimilarRequest) (*pb.FindSimilarResponse, error) {
+	return ts.handler.HandleFindSimilar(ctx, req)
+}
+
+// GetPlaceholders обрабатывает запрос на получение заглушек обложек через gRPC.
+// ctx: контекст выполнения.
+// req: запрос на получение заглушек в формате proto.
+// Возвращает заглушки в формате proto и ошибку, если она возникла.
+func (ts *TransportService) GetPlaceholders(ctx context.Context, req *pb.GetPlaceholdersRequest) (*pb.GetPlaceholdersResponse, error) {
+	return ts.handler.HandleGetPlaceholders(ctx, req)
+}
+
+// BuildContactSheet обрабатывает запрос на сборку контактного листа через gRPC.
+// ctx: контекст выполнения.
+// req: запрос на сборку контактного листа в формате proto.
+// Возвращает изображение мозаики в формате proto и ошибку, если она возникла.
+func (ts *TransportService) BuildContactSheet(ctx context.Context, req *pb.ContactSheetRequest) (*pb.ContactSheetResponse, error) {
+	return ts.handler.HandleBuildContactSheet(ctx, req)
+}
+
+// GetCacheStats обрабатывает запрос на получение статистики кэша через gRPC.
+// ctx: контекст выполнения.
+// req: запрос статистики в формате proto.
+// Возвращает статистику кэша в формате proto и ошибку, если она возникла.
+func (ts *TransportService) GetCacheStats(ctx context.Context, req *pb.CacheStatsRequest) (*pb.CacheStatsResponse, error) {
+	return ts.handler.HandleGetCacheStats(ctx, req)
+}
+
 /*
 NewTransportService создает новый экземпляр TransportService с предоставленным обработчиком данных.
 handler: экземпляр обработчика данных.
@@ -35,4 +67,24 @@ SendData обрабатывает запрос на отправку данны
 ctx: контекст выполнения.
 req: запрос на отправку данных в формате proto.
 Возвращает ответ на отправку данных в формате proto и ошибку, если она возникла.
+
+FindSimilar обрабатывает запрос на поиск похожих обложек через gRPC.
+ctx: контекст выполнения.
+req: запрос на поиск в формате proto.
+Возвращает найденные обложки в формате proto и ошибку, если она возникла.
+
+GetPlaceholders обрабатывает запрос на получение заглушек обложек через gRPC.
+ctx: контекст выполнения.
+req: запрос на получение заглушек в формате proto.
+Возвращает заглушки в формате proto и ошибку, если она возникла.
+
+BuildContactSheet обрабатывает запрос на сборку контактного листа через gRPC.
+ctx: контекст выполнения.
+req: запрос на сборку контактного листа в формате proto.
+Возвращает изображение мозаики в формате proto и ошибку, если она возникла.
+
+GetCacheStats обрабатывает запрос на получение статистики кэша через gRPC.
+ctx: контекст выполнения.
+req: запрос статистики в формате proto.
+Возвращает статистику кэша в формате proto и ошибку, если она возникла.
 */
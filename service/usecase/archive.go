@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"shelon_server/archive"
+	database "shelon_server/integrations/SQLLite"
+	youtubeclient "shelon_server/integrations/youtubeCLient"
+
+	"go.uber.org/zap"
+)
+
+// exportPageSize — количество записей, читаемых из базы за один запрос при экспорте.
+const exportPageSize = 500
+
+// ExportCache записывает записи кэша, подходящие под фильтр, в архив указанного формата (tar или zip):
+// изображения и JSON-манифест с идентификатором видео, качеством, временем загрузки, хешем и ETag.
+// Записи, содержимое которых отсутствует в хранилище, пропускаются. Возвращает количество
+// экспортированных записей.
+func (bl *BusinessLogic) ExportCache(w io.Writer, format string, filter database.EntryFilter) (int, error) {
+	writer, err := archive.NewWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	bl.Logger.Info("Exporting cache", zap.String("Format", format))
+
+	after := ""
+	for {
+		entries, err := bl.Sqlite.ListEntries(filter, after, exportPageSize)
+		if err != nil {
+			bl.Logger.Error("Error listing entries to export", zap.Error(err))
+			return writer.Len(), err
+		}
+		for _, entry := range entries {
+			photo, err := bl.Sqlite.GetPhotoByUrl(entry.URL)
+			if err != nil {
+				bl.Logger.Error("Error loading photo to export", zap.String("Link", entry.URL), zap.Error(err))
+				return writer.Len(), err
+			}
+			if photo == nil {
+				bl.Logger.Warn("Photo content is missing, skipping export", zap.String("Link", entry.URL))
+				continue
+			}
+			err = writer.Add(archive.Entry{
+				Link:      entry.URL,
+				VideoID:   entry.VideoID,
+				Quality:   youtubeclient.ThumbnailQuality,
+				FetchedAt: entry.FetchedAt.UTC(),
+			}, photo)
+			if err != nil {
+				return writer.Len(), fmt.Errorf("failed to write archive: %w", err)
+			}
+		}
+		if len(entries) < exportPageSize {
+			break
+		}
+		after = entries[len(entries)-1].URL
+	}
+
+	if err := writer.Close(); err != nil {
+		return writer.Len(), fmt.Errorf("failed to finish archive: %w", err)
+	}
+	bl.Logger.Info("Cache exported", zap.Int("Entries", writer.Len()))
+	return writer.Len(), nil
+}
+
+// ImportCache загружает записи из архива в кэш через настроенное хранилище.
+// Содержимое каждой записи проверяется по размеру и хешу из манифеста. Записи, уже имеющиеся
+// в кэше, пропускаются (ConflictSkip) или заменяются (ConflictOverwrite).
+// Ошибки отдельных записей не прерывают импорт и сохраняются в результатах в порядке манифеста.
+func (bl *BusinessLogic) ImportCache(r io.ReaderAt, size int64, format string, policy string) ([]ImportResult, error) {
+	if policy != ConflictSkip && policy != ConflictOverwrite {
+		return nil, fmt.Errorf("unsupported conflict policy %q", policy)
+	}
+	reader, err := archive.NewReader(r, size, format)
+	if err != nil {
+		bl.Logger.Error("Error opening cache archive", zap.Error(err))
+		return nil, err
+	}
+	entries := reader.Manifest().Entries
+	bl.Logger.Info("Importing cache", zap.String("Format", format), zap.String("Policy", policy), zap.Int("Entries", len(entries)))
+
+	// Записи, ожидающие своё изображение, группируются по файлу: одинаковое содержимое хранится в архиве один раз
+	results := make([]ImportResult, len(entries))
+	pending := make(map[string][]int)
+	for i, entry := range entries {
+		results[i].Link = entry.Link
+		if entry.Link == "" {
+			results[i].Status, results[i].Err = ImportStatusError, errors.New("manifest entry has no link")
+			continue
+		}
+		if policy == ConflictSkip {
+			exists, err := bl.Sqlite.ResourceExists(entry.Link)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				results[i].Status = ImportStatusSkipped
+				continue
+			}
+		}
+		pending[entry.File] = append(pending[entry.File], i)
+	}
+
+	err = reader.Walk(func(name string, data []byte) error {
+		for _, i := range pending[name] {
+			results[i] = bl.importEntry(entries[i], data)
+		}
+		delete(pending, name)
+		return nil
+	})
+	if err != nil {
+		bl.Logger.Error("Error reading cache archive", zap.Error(err))
+		return nil, err
+	}
+	for name, indexes := range pending {
+		for _, i := range indexes {
+			results[i].Status, results[i].Err = ImportStatusError, fmt.Errorf("image %s is missing from archive", name)
+		}
+	}
+	return results, nil
+}
+
+// importEntry проверяет целостность содержимого записи архива и сохраняет её в кэш
+// с исходным временем загрузки.
+func (bl *BusinessLogic) importEntry(entry archive.Entry, data []byte) ImportResult {
+	if err := archive.Verify(entry, data); err != nil {
+		bl.Logger.Warn("Archive entry failed integrity check", zap.String("Link", entry.Link), zap.Error(err))
+		return ImportResult{Link: entry.Link, Status: ImportStatusError, Err: err}
+	}
+
+	resource := &database.Resource{URL: entry.Link, VideoID: entry.VideoID, Photo: data, FetchedAt: entry.FetchedAt}
+	if err := applyFeatures(resource); err != nil {
+		bl.Logger.Warn("Failed to analyze imported thumbnail", zap.String("Link", entry.Link), zap.Error(err))
+	}
+	if err := bl.Sqlite.InsertResource(resource); err != nil {
+		bl.Logger.Error("Error saving imported photo", zap.String("Link", entry.Link), zap.Error(err))
+		return ImportResult{Link: entry.Link, Status: ImportStatusError, Err: err}
+	}
+	return ImportResult{Link: entry.Link, Status: ImportStatusImported}
+}
+
+/*
+ExportCache записывает записи кэша, подходящие под фильтр, в архив указанного формата.
+w: получатель архива.
+format: формат контейнера (tar или zip).
+filter: условия отбора записей.
+Возвращает количество экспортированных записей.
+
+ImportCache загружает записи из архива в кэш с проверкой целостности.
+r: содержимое архива с произвольным доступом.
+size: размер архива в байтах.
+format: формат контейнера (tar или zip).
+policy: политика разрешения конфликтов (skip или overwrite).
+Возвращает результаты в порядке манифеста.
+
+importEntry проверяет целостность содержимого записи архива и сохраняет её в кэш.
+entry: запись манифеста.
+data: содержимое изображения из архива.
+*/
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"shelon_server/archive"
+)
+
+// buildTarArchive собирает архив tar с манифестом entries и файлами files как есть,
+// без пересчёта размеров и хешей, чтобы можно было подделать содержимое записей.
+func buildTarArchive(t *testing.T, entries []archive.Entry, files map[string][]byte) *bytes.Reader {
+	t.Helper()
+	manifest, err := json.Marshal(archive.Manifest{Version: archive.ManifestVersion, Entries: entries})
+	if err != nil {
+		t.Fatalf("Failed to encode manifest: %v", err)
+	}
+	files[archive.ManifestName] = manifest
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+// archiveEntry описывает запись манифеста с размером и хешем, вычисленными по data.
+func archiveEntry(link, file string, data []byte) archive.Entry {
+	return archive.Entry{
+		Link:        link,
+		FetchedAt:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ContentHash: archive.ContentHash(data),
+		Size:        int64(len(data)),
+		File:        file,
+	}
+}
+
+// importStatuses импортирует архив и возвращает статусы записей по ссылкам.
+func importStatuses(t *testing.T, logic *BusinessLogic, r *bytes.Reader, policy string) map[string]string {
+	t.Helper()
+	results, err := logic.ImportCache(r, r.Size(), archive.FormatTar, policy)
+	if err != nil {
+		t.Fatalf("Failed to import cache: %v", err)
+	}
+	statuses := make(map[string]string, len(results))
+	for _, result := range results {
+		statuses[result.Link] = result.Status
+	}
+	return statuses
+}
+
+// TestImportCachePolicies проверяет, что при политике skip имеющиеся записи остаются,
+// а при overwrite заменяются содержимым архива с исходным временем загрузки.
+func TestImportCachePolicies(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_import_policies.db")
+	cached, fresh := "https://youtu.be/cached", "https://youtu.be/fresh"
+	if _, err := logic.ProcessData(context.Background(), false, []string{cached}, ProcessOptions{}); err != nil {
+		t.Fatalf("Failed to cache %s: %v", cached, err)
+	}
+	archived := []byte("archived image")
+	newArchive := func() *bytes.Reader {
+		return buildTarArchive(t, []archive.Entry{
+			archiveEntry(cached, "images/a.jpg", archived),
+			archiveEntry(fresh, "images/a.jpg", archived),
+		}, map[string][]byte{"images/a.jpg": archived})
+	}
+	photo := func(link string) string {
+		t.Helper()
+		data, err := logic.Sqlite.GetPhotoByUrl(link)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", link, err)
+		}
+		return string(data)
+	}
+
+	if _, err := logic.ImportCache(newArchive(), newArchive().Size(), archive.FormatTar, "merge"); err == nil {
+		t.Error("Expected unknown conflict policy to be rejected")
+	}
+
+	statuses := importStatuses(t, logic, newArchive(), ConflictSkip)
+	if statuses[cached] != ImportStatusSkipped || statuses[fresh] != ImportStatusImported {
+		t.Errorf("Unexpected skip statuses: %v", statuses)
+	}
+	if photo(cached) != "image of "+cached || photo(fresh) != string(archived) {
+		t.Errorf("Expected skip to keep the cached photo, got %q and %q", photo(cached), photo(fresh))
+	}
+
+	statuses = importStatuses(t, logic, newArchive(), ConflictOverwrite)
+	if statuses[cached] != ImportStatusImported || statuses[fresh] != ImportStatusImported {
+		t.Errorf("Unexpected overwrite statuses: %v", statuses)
+	}
+	if photo(cached) != string(archived) {
+		t.Errorf("Expected overwrite to replace the cached photo, got %q", photo(cached))
+	}
+	entry, err := logic.Sqlite.GetEntry(cached)
+	if err != nil || entry == nil || !entry.FetchedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Expected fetch time from the archive, got %+v (%v)", entry, err)
+	}
+}
+
+// TestImportCacheCorruptedEntries проверяет, что записи с несовпадающим размером или хешем
+// и записи без файла отклоняются, не изменяя кэш, а остальные записи архива импортируются.
+func TestImportCacheCorruptedEntries(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_import_corrupted.db")
+	cached := "https://youtu.be/cached"
+	if _, err := logic.ProcessData(context.Background(), false, []string{cached}, ProcessOptions{}); err != nil {
+		t.Fatalf("Failed to cache %s: %v", cached, err)
+	}
+	before, err := logic.GetCacheStats(0)
+	if err != nil {
+		t.Fatalf("Failed to get cache stats: %v", err)
+	}
+
+	good, tampered := []byte("good image"), []byte("tampered image")
+	hashMismatch := archiveEntry(cached, "images/tampered.jpg", []byte("original image"))
+	hashMismatch.Size = int64(len(tampered))
+	sizeMismatch := archiveEntry("https://youtu.be/truncated", "images/tampered.jpg", tampered)
+	sizeMismatch.Size++
+	r := buildTarArchive(t, []archive.Entry{
+		hashMismatch,
+		sizeMismatch,
+		archiveEntry("https://youtu.be/missing", "images/missing.jpg", good),
+		archiveEntry("https://youtu.be/good", "images/good.jpg", good),
+	}, map[string][]byte{"images/tampered.jpg": tampered, "images/good.jpg": good})
+
+	results, err := logic.ImportCache(r, r.Size(), archive.FormatTar, ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("Failed to import cache: %v", err)
+	}
+	expected := []string{ImportStatusError, ImportStatusError, ImportStatusError, ImportStatusImported}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %v", len(expected), results)
+	}
+	for i, result := range results {
+		if result.Status != expected[i] || (result.Status == ImportStatusError) != (result.Err != nil) {
+			t.Errorf("Unexpected result for %s: %s (%v)", result.Link, result.Status, result.Err)
+		}
+	}
+
+	if data, err := logic.Sqlite.GetPhotoByUrl(cached); err != nil || string(data) != "image of "+cached {
+		t.Errorf("Expected corrupted entry to leave the cached photo unchanged, got %q (%v)", data, err)
+	}
+	for _, link := range []string{"https://youtu.be/truncated", "https://youtu.be/missing"} {
+		if exists, err := logic.Sqlite.ResourceExists(link); err != nil || exists {
+			t.Errorf("Expected %s not to be imported, got %v (%v)", link, exists, err)
+		}
+	}
+	after, err := logic.GetCacheStats(0)
+	if err != nil {
+		t.Fatalf("Failed to get cache stats: %v", err)
+	}
+	if after.Entries != before.Entries+1 || after.OriginalBytes != before.OriginalBytes+int64(len(good)) {
+		t.Errorf("Expected only the valid entry to be added, got %+v before and %+v after", before.CacheStats, after.CacheStats)
+	}
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+)
+
+// waitForResource ждёт, пока обложка ссылки появится в кэше.
+func waitForResource(t *testing.T, logic *BusinessLogic, link string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if exists, _ := logic.Sqlite.ResourceExists(link); exists {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be cached", link)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestProcessDataDeadline проверяет, что по истечении срока запроса возвращаются готовые результаты
+// и отметки для необработанных ссылок, а догрузка в фоне выполняется только по запросу.
+func TestProcessDataDeadline(t *testing.T) {
+	for _, background := range []bool{false, true} {
+		logic, youtube := newTestLogic(t, fmt.Sprintf("test_deadline_%t.db", background))
+		cached, slow, queued := "https://youtu.be/cached", "https://youtu.be/slow", "https://youtu.be/queued"
+		if err := logic.Sqlite.InsertResource(&database.Resource{URL: cached, Photo: []byte("image of " + cached)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+		youtube.gate = make(chan struct{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		results, err := logic.ProcessData(ctx, false, []string{cached, slow, queued}, ProcessOptions{Background: background})
+		cancel()
+		if err != nil {
+			t.Fatalf("Expected partial results, got error %v", err)
+		}
+		if len(results) != 3 || results[0].Err != nil || string(results[0].Photo) != "image of "+cached {
+			t.Fatalf("Expected cached result to be returned, got %+v", results)
+		}
+		for _, result := range results[1:] {
+			if !errors.Is(result.Err, context.DeadlineExceeded) {
+				t.Errorf("Expected deadline marker for %s, got %v", result.Link, result.Err)
+			}
+		}
+
+		close(youtube.gate)
+		waitForResource(t, logic, slow)
+		if background {
+			waitForResource(t, logic, queued)
+			continue
+		}
+		if exists, _ := logic.Sqlite.ResourceExists(queued); exists || youtube.fetches.Load() != 1 {
+			t.Errorf("Expected no fetches after deadline, got %d", youtube.fetches.Load())
+		}
+	}
+}
+
+// expiringContext сообщает об истёкшем сроке, как только expired возвращает true, но не закрывает Done:
+// обработка ссылок останавливается раньше, чем ProcessData замечает срок.
+type expiringContext struct {
+	context.Context
+	expired func() bool
+}
+
+func (c expiringContext) Err() error {
+	if c.expired() {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// TestProcessDataDeadlineBeforeDone проверяет, что ссылки, не начатые до истечения срока, получают
+// отметку и тогда, когда обработка завершилась раньше, чем был замечен срок, а асинхронный запрос
+// не превращает отметки в ошибку всего вызова.
+func TestProcessDataDeadlineBeforeDone(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		logic, youtube := newTestLogic(t, fmt.Sprintf("test_deadline_before_done_%t.db", async))
+		logic.FetchWorkers = 1
+		links := []string{"https://youtu.be/a", "https://youtu.be/b", "https://youtu.be/c"}
+		// Срок истекает сразу после первой загрузки
+		ctx := expiringContext{Context: context.Background(), expired: func() bool { return youtube.fetches.Load() > 0 }}
+
+		results, err := logic.ProcessData(ctx, async, links, ProcessOptions{})
+		if err != nil {
+			t.Fatalf("Async %t: expected partial results, got error %v", async, err)
+		}
+		if len(results) != len(links) || results[0].Err != nil || string(results[0].Photo) != "image of "+links[0] {
+			t.Fatalf("Async %t: expected first link to be processed, got %+v", async, results)
+		}
+		for i, result := range results[1:] {
+			if result.Link != links[i+1] || !errors.Is(result.Err, context.DeadlineExceeded) {
+				t.Errorf("Async %t: expected deadline marker for %s, got %+v", async, links[i+1], result)
+			}
+		}
+	}
+}
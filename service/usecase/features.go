@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"shelon_server/imaging"
+	database "shelon_server/integrations/SQLLite"
+
+	"go.uber.org/zap"
+)
+
+// featuresBackfillBatch — количество ссылок, которые фоновое дополнение характеристик читает за раз.
+const featuresBackfillBatch = 100
+
+// applyFeatures вычисляет характеристики изображения ресурса (перцептивный хеш, доминирующий цвет,
+// BlurHash и миниатюру) и отмечает их как вычисленные.
+func applyFeatures(resource *database.Resource) error {
+	features, err := imaging.Analyze(resource.Photo)
+	if err != nil {
+		return err
+	}
+	resource.PHash = features.PHash
+	resource.DominantColor = features.DominantColor
+	resource.BlurHash = features.BlurHash
+	resource.Preview = features.Preview
+	resource.HasFeatures = true
+	return nil
+}
+
+// backfillResource вычисляет характеристики закэшированного ресурса, у которого их нет,
+// и сохраняет их в базе.
+func (bl *BusinessLogic) backfillResource(resource *database.Resource) error {
+	if resource.Photo == nil {
+		return fmt.Errorf("thumbnail of %s is not cached", resource.URL)
+	}
+	if err := applyFeatures(resource); err != nil {
+		return fmt.Errorf("failed to analyze thumbnail: %w", err)
+	}
+	if err := bl.Sqlite.UpdateFeatures(resource); err != nil {
+		return fmt.Errorf("failed to save thumbnail features: %w", err)
+	}
+	return nil
+}
+
+// BackfillFeatures вычисляет характеристики для всех закэшированных записей, у которых их нет
+// (например, сохранённых до появления поиска похожих обложек), чтобы они участвовали в FindSimilar.
+// Записи перебираются по ссылке один раз: изображения, которые не удалось разобрать, пропускаются.
+// ctx: контекст выполнения; отмена прерывает перебор.
+// Возвращает количество дополненных записей.
+func (bl *BusinessLogic) BackfillFeatures(ctx context.Context) (int, error) {
+	updated, after := 0, ""
+	for ctx.Err() == nil {
+		urls, err := bl.Sqlite.ResourcesWithoutFeatures(after, featuresBackfillBatch)
+		if err != nil {
+			return updated, err
+		}
+		for _, url := range urls {
+			if ctx.Err() != nil {
+				break
+			}
+			resource, err := bl.Sqlite.GetResourceByUrl(url)
+			if err != nil {
+				return updated, err
+			}
+			if resource == nil {
+				continue
+			}
+			if err := bl.backfillResource(resource); err != nil {
+				bl.Logger.Warn("Failed to backfill thumbnail features", zap.String("Link", url), zap.Error(err))
+				continue
+			}
+			updated++
+		}
+		if len(urls) < featuresBackfillBatch {
+			break
+		}
+		after = urls[len(urls)-1]
+	}
+	if updated > 0 {
+		bl.Logger.Info("Thumbnail features backfilled", zap.Int("count", updated))
+	}
+	return updated, ctx.Err()
+}
+
+/*
+applyFeatures вычисляет характеристики изображения ресурса и отмечает их как вычисленные.
+resource: ресурс с содержимым изображения.
+
+backfillResource вычисляет и сохраняет характеристики закэшированного ресурса.
+resource: ресурс с содержимым изображения.
+
+BackfillFeatures вычисляет характеристики для всех закэшированных записей, у которых их нет.
+ctx: контекст выполнения.
+*/
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	database "shelon_server/integrations/SQLLite"
+)
+
+// testJPEG кодирует в JPEG изображение с горизонтальным градиентом заданного направления.
+func testJPEG(t *testing.T, reversed bool) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 36))
+	for x := 0; x < 64; x++ {
+		shade := uint8(x * 4)
+		if reversed {
+			shade = 255 - shade
+		}
+		for y := 0; y < 36; y++ {
+			img.Set(x, y, color.RGBA{R: shade, G: shade, B: shade, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestBackfillFeatures проверяет, что записи, закэшированные без характеристик, дополняются
+// фоновым проходом и при обращении из FindSimilar и после этого находятся поиском похожих.
+func TestBackfillFeatures(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_backfill.db")
+	photo := testJPEG(t, false)
+	old, copy, broken := "https://youtu.be/old", "https://youtu.be/copy", "https://youtu.be/broken-image"
+	for link, data := range map[string][]byte{old: photo, copy: photo, broken: []byte("not an image")} {
+		if err := logic.Sqlite.InsertResource(&database.Resource{URL: link, Photo: data}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+
+	// Обращение к записи без характеристик дополняет только её
+	similar, err := logic.FindSimilar(old, 0, 5, 0)
+	if err != nil {
+		t.Fatalf("FindSimilar failed for a resource without features: %v", err)
+	}
+	if len(similar) != 0 {
+		t.Errorf("Expected records without features to be skipped before backfill, got %+v", similar)
+	}
+
+	updated, err := logic.BackfillFeatures(context.Background())
+	if err != nil || updated != 1 {
+		t.Fatalf("Expected one backfilled resource, got %d (%v)", updated, err)
+	}
+	similar, err = logic.FindSimilar(old, 0, 5, 0)
+	if err != nil || len(similar) != 1 || similar[0].URL != copy || similar[0].Distance != 0 {
+		t.Errorf("Expected backfilled copy to be found, got %+v (%v)", similar, err)
+	}
+
+	// Неразбираемое изображение остаётся без характеристик, повторный проход его не зацикливает
+	if updated, err = logic.BackfillFeatures(context.Background()); err != nil || updated != 0 {
+		t.Errorf("Expected nothing left to backfill, got %d (%v)", updated, err)
+	}
+	if _, err := logic.FindSimilar(broken, 0, 5, 0); err == nil {
+		t.Error("Expected FindSimilar to fail for an image that cannot be analyzed")
+	}
+}
@@ -1,5 +1,183 @@
 package usecase
 
+import (
+	"context"
+	"image"
+	"io"
+	"shelon_server/imaging"
+	database "shelon_server/integrations/SQLLite"
+	"shelon_server/utilss/cron"
+	"time"
+)
+
+// LinkResult содержит результат обработки одной ссылки.
+type LinkResult struct {
+	Link          string // Исходная ссылка.
+	Photo         []byte // Содержимое обложки, nil если обработка не удалась.
+	PHash         uint64 // Перцептивный хеш обложки.
+	DominantColor string // Доминирующий цвет обложки в формате #rrggbb.
+	BlurHash      string // Строка BlurHash обложки.
+	ContentHash   string // SHA-256 возвращаемого содержимого обложки.
+	Err           error  // Ошибка обработки ссылки.
+}
+
+// ContactSheetOptions задает параметры сборки контактного листа.
+type ContactSheetOptions struct {
+	Layout     imaging.GridLayout // Раскладка плиток.
+	Format     string             // Формат результата (jpeg или png).
+	Background string             // Цвет фона в формате #rrggbb.
+}
+
+// ContactSheet содержит собранный контактный лист.
+type ContactSheet struct {
+	Image  []byte                     // Закодированное изображение мозаики.
+	Width  int                        // Ширина мозаики в пикселях.
+	Height int                        // Высота мозаики в пикселях.
+	Tiles  map[string]image.Rectangle // Координаты плиток по идентификатору видео.
+	Failed []string                   // Ссылки, обложки которых не удалось получить.
+}
+
+// ProcessOptions задает необязательные параметры обработки ссылок.
+type ProcessOptions struct {
+	Priority string // Приоритет загрузок с YouTube (пусто — PriorityInteractive).
+	// Background — продолжать загрузку ссылок, не обработанных к отмене контекста запроса,
+	// чтобы повторный запрос нашёл их в кэше.
+	Background bool
+}
+
+// VideoRequests содержит количество обращений к одному видео.
+type VideoRequests struct {
+	VideoID  string // Идентификатор видео (или ссылка, если идентификатор не извлекается).
+	Requests uint64 // Количество обращений с момента запуска.
+}
+
+// CacheStats содержит статистику кэша и счётчики обращений с момента запуска сервиса.
+type CacheStats struct {
+	database.CacheStats
+	Hits      uint64          // Обложки, найденные в кэше.
+	Misses    uint64          // Обложки, загруженные с YouTube.
+	Errors    uint64          // Обращения, завершившиеся ошибкой.
+	TopVideos []VideoRequests // Самые запрашиваемые видео по убыванию числа обращений.
+	// Tiers — попадания и промахи по уровням кэша (database.TierMemory, database.TierDatabase);
+	// nil, если кэш в памяти отключён.
+	Tiers map[string]database.TierStats
+}
+
+// memoryCacheStats реализуется базой с кэшем в памяти (database.MemoryCachedDatabase).
+type memoryCacheStats interface {
+	Stats() database.MemoryCacheStats
+}
+
 type DataProcessorUsecase interface {
-	ProcessData(flag bool, links []string) ([][]byte, error)
+	ProcessData(ctx context.Context, flag bool, links []string, options ProcessOptions) ([]LinkResult, error)
+	FindSimilar(link string, hash uint64, maxDistance int, limit int) ([]database.SimilarResource, error)
+	GetPlaceholders(links []string) ([]database.Placeholder, error)
+	BuildContactSheet(links []string, options ContactSheetOptions) (*ContactSheet, error)
+	GetCacheStats(topN int) (*CacheStats, error)
+}
+
+// maxRefreshEntries ограничивает количество записей, обновляемых за один вызов RefreshEntries:
+// по фильтру обновляется не больше записей, а более длинный список ссылок отклоняется.
+const maxRefreshEntries = 500
+
+// Типы и состояния фоновых заданий.
+const (
+	JobKindWarmCache = "warm_cache" // Предварительная загрузка обложек в кэш.
+
+	JobQueued    = "queued"    // Задание ждёт в очереди.
+	JobRunning   = "running"   // Задание выполняется.
+	JobCompleted = "completed" // Все ссылки задания обработаны.
+	JobCancelled = "cancelled" // Задание отменено; необработанные ссылки пропущены.
+)
+
+// Job описывает фоновое задание и его прогресс. Задания хранятся в базе данных
+// и после перезапуска сервиса продолжаются с необработанных ссылок.
+type Job struct {
+	ID         string       // Идентификатор задания.
+	Kind       string       // Тип задания (JobKind*).
+	State      string       // Состояние задания (JobQueued, JobRunning, JobCompleted, JobCancelled).
+	Total      int          // Количество ссылок в задании.
+	Fetched    int          // Обложки, загруженные с YouTube.
+	Cached     int          // Обложки, которые уже были в кэше.
+	Failed     int          // Ссылки, которые не удалось обработать.
+	Failures   []LinkResult // Первые ошибки обработки (не более maxJobFailures).
+	CreatedAt  time.Time    // Время создания задания.
+	StartedAt  time.Time    // Время начала выполнения.
+	FinishedAt time.Time    // Время завершения.
+	// CallbackURL — адрес, на который после завершения отправляется уведомление (пусто — без уведомления).
+	CallbackURL string
+	// Deliveries — попытки доставки уведомления о завершении.
+	Deliveries []database.WebhookDelivery
+	Priority   string // Полоса загрузок задания (PriorityInteractive или PriorityBulk).
+	Owner      string // Клиент, поставивший задание; пусто для заданий, созданных до учёта владельцев.
+}
+
+// JobOptions задает необязательные параметры задания.
+type JobOptions struct {
+	CallbackURL string // Адрес для уведомления о завершении задания.
+	Priority    string // Приоритет загрузок (пусто — PriorityBulk).
+	Owner       string // Клиент, поставивший задание.
+}
+
+// JobUsecase определяет операции с фоновыми заданиями.
+type JobUsecase interface {
+	SubmitJob(links []string, options JobOptions) (*Job, error)
+	GetJob(id string) (*Job, error)
+	ListJobs(owner string, state string, pageToken string, pageSize int) ([]Job, string, error)
+	CancelJob(id string) (*Job, error)
+	WatchJob(ctx context.Context, id string, send func(*Job) error) error
+	LinkLimit() int
+}
+
+// Источники записей для планового обновления кэша.
+const (
+	RefreshPopular = "popular" // Самые запрашиваемые с момента запуска видео, которые есть в кэше.
+	RefreshStale   = "stale"   // Записи, загруженные раньше заданного возраста, начиная с самых давних.
+)
+
+// RefreshTask описывает плановое обновление закэшированных обложек.
+type RefreshTask struct {
+	Name     string         // Имя задачи для логов.
+	Schedule *cron.Schedule // Расписание запусков.
+	Source   string         // Источник записей (RefreshPopular или RefreshStale).
+	Limit    int            // Максимум записей за запуск.
+	MaxAge   time.Duration  // Для RefreshStale: записи старше этого возраста считаются устаревающими.
+}
+
+// RefreshSummary содержит итог одного запуска планового обновления.
+type RefreshSummary struct {
+	Selected  int           // Записи, выбранные для обновления.
+	Refreshed int           // Обложки, загруженные заново.
+	Failed    int           // Обложки, которые не удалось загрузить; в кэше остаётся прежняя версия.
+	Duration  time.Duration // Длительность запуска.
+}
+
+// Политики разрешения конфликтов при импорте архива: запись с той же ссылкой уже есть в кэше.
+const (
+	ConflictSkip      = "skip"      // Оставить существующую запись.
+	ConflictOverwrite = "overwrite" // Заменить запись содержимым из архива.
+)
+
+// Статусы записей при импорте архива.
+const (
+	ImportStatusImported = "imported"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusError    = "error"
+)
+
+// ImportResult содержит результат импорта одной записи архива.
+type ImportResult struct {
+	Link   string // Ссылка на видео.
+	Status string // Один из статусов ImportStatus*.
+	Err    error  // Ошибка импорта или проверки целостности.
+}
+
+// AdminUsecase определяет операции администрирования кэша.
+type AdminUsecase interface {
+	ListEntries(filter database.EntryFilter, after string, limit int) ([]database.Entry, error)
+	GetEntry(link string) (*database.Entry, error)
+	PurgeEntries(filter database.EntryFilter) ([]string, error)
+	RefreshEntries(ctx context.Context, filter database.EntryFilter, after string) ([]LinkResult, string, error)
+	ExportCache(w io.Writer, format string, filter database.EntryFilter) (int, error)
+	ImportCache(r io.ReaderAt, size int64, format string, policy string) ([]ImportResult, error)
 }
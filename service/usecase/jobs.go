@@ -0,0 +1,593 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+	"shelon_server/integrations/webhook"
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+)
+
+// Ограничения фоновых заданий по умолчанию.
+const (
+	defaultJobQueueSize = 16     // Сколько заданий может ждать в очереди
+	defaultMaxJobLinks  = 100000 // Максимум ссылок в одном задании
+	maxJobFailures      = 100    // Сколько ошибок возвращается вместе с заданием
+)
+
+// Ошибки операций с фоновыми заданиями.
+var (
+	ErrNoLinks      = errors.New("no links provided")
+	ErrTooManyLinks = errors.New("too many links")
+	ErrQueueFull    = errors.New("job queue is full")
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobFinished  = errors.New("job is already finished")
+	// ErrWebhooksDisabled возвращается для задания с адресом уведомления, если уведомления не настроены.
+	ErrWebhooksDisabled = errors.New("job webhooks are not configured")
+)
+
+// activeJob описывает задание, которое ждёт в очереди или выполняется.
+type activeJob struct {
+	job      *Job
+	cancel   context.CancelFunc         // Отменяет выполнение; nil, пока задание не запущено
+	watchers map[chan struct{}]struct{} // Уведомления об изменении прогресса для WatchJob
+}
+
+// JobManager принимает фоновые задания, ставит их в очередь и выполняет по одному
+// через ограниченный конвейер загрузки. Задания и состояние каждой ссылки хранятся в JobStore,
+// поэтому после перезапуска сервиса незавершённые задания продолжаются с необработанных ссылок.
+// В памяти держатся только задания, которые ждут в очереди или выполняются.
+// Если задан Webhooks, о завершении заданий с адресом уведомления сообщается POST-запросом.
+type JobManager struct {
+	Logger   logger.Logger
+	Logic    *BusinessLogic
+	Store    database.JobStore
+	MaxLinks int
+	Webhooks *webhook.Sender // Отправитель уведомлений; nil — уведомления отключены
+
+	submitMu sync.Mutex // Упорядочивает постановку заданий, чтобы проверка места в очереди была точной
+	mu       sync.Mutex
+	active   map[string]*activeJob
+	queue    chan string // Идентификаторы заданий в порядке постановки
+}
+
+// NewJobManager создает менеджер фоновых заданий.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// logic: бизнес-логика, выполняющая загрузку обложек.
+// store: хранилище заданий.
+// queueSize: сколько заданий может ждать в очереди (0 — по умолчанию).
+// maxLinks: максимум ссылок в одном задании (0 — по умолчанию).
+func NewJobManager(logger logger.Logger, logic *BusinessLogic, store database.JobStore, queueSize, maxLinks int) *JobManager {
+	if queueSize <= 0 {
+		queueSize = defaultJobQueueSize
+	}
+	if maxLinks <= 0 {
+		maxLinks = defaultMaxJobLinks
+	}
+	return &JobManager{
+		Logger:   logger,
+		Logic:    logic,
+		Store:    store,
+		MaxLinks: maxLinks,
+		active:   make(map[string]*activeJob),
+		queue:    make(chan string, queueSize),
+	}
+}
+
+// Run продолжает незавершённые задания из хранилища, а затем выполняет задания из очереди до отмены ctx.
+// Задание, прерванное отменой ctx, остаётся незавершённым и продолжится при следующем запуске.
+func (jm *JobManager) Run(ctx context.Context) {
+	jm.Logger.Info("Job manager started")
+	jm.resume(ctx)
+	for {
+		select {
+		case id := <-jm.queue:
+			jm.run(ctx, id)
+		case <-ctx.Done():
+			jm.Logger.Info("Job manager stopped")
+			return
+		}
+	}
+}
+
+// LinkLimit возвращает максимальное количество ссылок в одном задании.
+func (jm *JobManager) LinkLimit() int {
+	return jm.MaxLinks
+}
+
+// SubmitJob создает задание загрузки обложек, сохраняет его и ставит в очередь.
+// Пустые строки и повторяющиеся ссылки отбрасываются. Без явного приоритета задание
+// загружает обложки в полосе PriorityBulk. Возвращает снимок созданного задания.
+func (jm *JobManager) SubmitJob(links []string, options JobOptions) (*Job, error) {
+	priority, err := resolvePriority(options.Priority, PriorityBulk)
+	if err != nil {
+		return nil, err
+	}
+	if options.CallbackURL != "" {
+		if jm.Webhooks == nil {
+			return nil, ErrWebhooksDisabled
+		}
+		if err := jm.Webhooks.ValidateURL(options.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	unique := make([]string, 0, len(links))
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		if link == "" || seen[link] {
+			continue
+		}
+		seen[link] = true
+		unique = append(unique, link)
+	}
+	if len(unique) == 0 {
+		return nil, ErrNoLinks
+	}
+	if len(unique) > jm.MaxLinks {
+		return nil, fmt.Errorf("%w: %d, at most %d allowed", ErrTooManyLinks, len(unique), jm.MaxLinks)
+	}
+
+	job := &Job{
+		ID:          newJobID(),
+		Kind:        JobKindWarmCache,
+		State:       JobQueued,
+		Total:       len(unique),
+		CreatedAt:   time.Now(),
+		CallbackURL: options.CallbackURL,
+		Priority:    priority,
+		Owner:       options.Owner,
+	}
+
+	// Очередь разбирает только Run, поэтому место, найденное под submitMu, не пропадёт до отправки
+	jm.submitMu.Lock()
+	defer jm.submitMu.Unlock()
+	if len(jm.queue) == cap(jm.queue) {
+		return nil, ErrQueueFull
+	}
+	record := toJobRecord(job)
+	if err := jm.Store.CreateJob(&record, unique); err != nil {
+		jm.Logger.Error("Failed to save job", zap.String("JobID", job.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+	jm.mu.Lock()
+	jm.active[job.ID] = &activeJob{job: job, watchers: make(map[chan struct{}]struct{})}
+	snapshot := jm.snapshot(job)
+	jm.mu.Unlock()
+	jm.queue <- job.ID
+
+	jm.Logger.Info("Job queued", zap.String("JobID", job.ID), zap.Int("Links", job.Total))
+	return &snapshot, nil
+}
+
+// GetJob возвращает снимок задания по идентификатору. Возвращает nil, если задание не найдено.
+func (jm *JobManager) GetJob(id string) (*Job, error) {
+	jm.mu.Lock()
+	if entry, ok := jm.active[id]; ok {
+		snapshot := jm.snapshot(entry.job)
+		jm.mu.Unlock()
+		return &snapshot, nil
+	}
+	jm.mu.Unlock()
+	return jm.loadJob(id)
+}
+
+// ListJobs возвращает страницу заданий, начиная с самых новых, и токен следующей страницы
+// (пустой, если страница последняя). Непустой owner ограничивает список заданиями одного клиента.
+// Ошибки обработки ссылок в задания списка не включаются.
+func (jm *JobManager) ListJobs(owner string, state string, pageToken string, pageSize int) ([]Job, string, error) {
+	records, err := jm.Store.ListJobs(owner, state, pageToken, pageSize)
+	if err != nil {
+		jm.Logger.Error("Failed to list jobs", zap.Error(err))
+		return nil, "", fmt.Errorf("failed to list jobs: %w", err)
+	}
+	jobs := make([]Job, 0, len(records))
+	for _, record := range records {
+		jobs = append(jobs, *fromJobRecord(record, nil))
+	}
+	var nextPageToken string
+	if pageSize > 0 && len(jobs) == pageSize {
+		nextPageToken = jobs[len(jobs)-1].ID
+	}
+	return jobs, nextPageToken, nil
+}
+
+// CancelJob отменяет задание. Задание из очереди отменяется сразу; у выполняющегося задания
+// дорабатываются уже начатые ссылки, остальные пропускаются.
+// Возвращает ErrJobNotFound для неизвестного задания и ErrJobFinished для завершённого.
+func (jm *JobManager) CancelJob(id string) (*Job, error) {
+	jm.mu.Lock()
+	entry, ok := jm.active[id]
+	if !ok {
+		jm.mu.Unlock()
+		job, err := jm.loadJob(id)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", ErrJobFinished, job.State)
+	}
+
+	entry.job.State = JobCancelled
+	if entry.cancel != nil {
+		// Итоговое состояние сохранит run после завершения начатых ссылок
+		entry.cancel()
+		snapshot := jm.snapshot(entry.job)
+		jm.mu.Unlock()
+		jm.Logger.Info("Job cancellation requested", zap.String("JobID", id))
+		return &snapshot, nil
+	}
+	entry.job.FinishedAt = time.Now()
+	delete(jm.active, id)
+	jm.notify(entry)
+	snapshot := jm.snapshot(entry.job)
+	jm.mu.Unlock()
+
+	jm.saveState(snapshot)
+	jm.deliverWebhook(snapshot)
+	jm.Logger.Info("Job cancelled", zap.String("JobID", id))
+	return &snapshot, nil
+}
+
+// WatchJob передает в send снимки задания при каждом изменении прогресса, пока задание
+// не завершится или не будет отменен ctx. Если обработчик не успевает, промежуточные
+// снимки пропускаются: send всегда получает актуальное состояние.
+// Для завершённого задания send вызывается один раз. Возвращает ErrJobNotFound для неизвестного задания.
+func (jm *JobManager) WatchJob(ctx context.Context, id string, send func(*Job) error) error {
+	jm.mu.Lock()
+	entry, ok := jm.active[id]
+	updates := make(chan struct{}, 1)
+	if ok {
+		entry.watchers[updates] = struct{}{}
+	}
+	jm.mu.Unlock()
+
+	if !ok {
+		job, err := jm.loadJob(id)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return ErrJobNotFound
+		}
+		return send(job)
+	}
+	defer func() {
+		jm.mu.Lock()
+		delete(entry.watchers, updates)
+		jm.mu.Unlock()
+	}()
+
+	for {
+		jm.mu.Lock()
+		snapshot := jm.snapshot(entry.job)
+		jm.mu.Unlock()
+		if err := send(&snapshot); err != nil {
+			return err
+		}
+		if !snapshot.FinishedAt.IsZero() {
+			return nil
+		}
+		select {
+		case <-updates:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// resume загружает задания, не завершённые до остановки сервиса, и выполняет их по порядку создания.
+func (jm *JobManager) resume(ctx context.Context) {
+	// Задания, поставленные в очередь уже после запуска сервиса, выполнит основной цикл Run
+	jm.submitMu.Lock()
+	records, err := jm.Store.ListJobsInStates([]string{JobQueued, JobRunning})
+	if err != nil {
+		jm.submitMu.Unlock()
+		jm.Logger.Error("Failed to load unfinished jobs", zap.Error(err))
+		return
+	}
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		jm.mu.Lock()
+		_, queued := jm.active[record.ID]
+		jm.mu.Unlock()
+		if queued {
+			continue
+		}
+		failures, err := jm.Store.JobFailures(record.ID, maxJobFailures)
+		if err != nil {
+			jm.Logger.Error("Failed to load job failures", zap.String("JobID", record.ID), zap.Error(err))
+			continue
+		}
+		job := fromJobRecord(record, failures)
+		job.State = JobQueued
+		jm.mu.Lock()
+		jm.active[job.ID] = &activeJob{job: job, watchers: make(map[chan struct{}]struct{})}
+		jm.mu.Unlock()
+		ids = append(ids, job.ID)
+	}
+	jm.submitMu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	jm.Logger.Info("Resuming unfinished jobs", zap.Int("Jobs", len(ids)))
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		jm.run(ctx, id)
+	}
+}
+
+// run выполняет необработанные ссылки задания и сохраняет результат каждой из них.
+func (jm *JobManager) run(ctx context.Context, id string) {
+	jm.mu.Lock()
+	entry, ok := jm.active[id]
+	if !ok {
+		// Задание отменено, пока ждало в очереди
+		jm.mu.Unlock()
+		return
+	}
+	job := entry.job
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	entry.cancel = cancel
+	job.State = JobRunning
+	if job.StartedAt.IsZero() {
+		job.StartedAt = time.Now()
+	}
+	jm.notify(entry)
+	started := jm.snapshot(job)
+	jm.mu.Unlock()
+	jm.saveState(started)
+
+	pending, err := jm.Store.PendingJobLinks(id)
+	if err != nil {
+		// Задание остаётся в состоянии running и будет продолжено при следующем запуске
+		jm.Logger.Error("Failed to load job links", zap.String("JobID", id), zap.Error(err))
+		jm.mu.Lock()
+		delete(jm.active, id)
+		jm.mu.Unlock()
+		return
+	}
+	jm.Logger.Info("Job started", zap.String("JobID", id), zap.Int("Links", job.Total), zap.Int("Pending", len(pending)))
+
+	links := make([]string, len(pending))
+	for i, link := range pending {
+		links[i] = link.URL
+	}
+	fetchPipeline(jobCtx, links, jm.Logic.FetchWorkers, func(i int, link string) {
+		cached, err := jm.Logic.warmLink(jobCtx, link, job.Priority)
+		// Ожидание слота прервано отменой: ссылка остаётся необработанной
+		if err != nil && jobCtx.Err() != nil && errors.Is(err, jobCtx.Err()) {
+			return
+		}
+		result := pending[i]
+		switch {
+		case err != nil:
+			result.Status, result.Error = database.JobLinkFailed, err.Error()
+		case cached:
+			result.Status = database.JobLinkCached
+		default:
+			result.Status = database.JobLinkFetched
+		}
+		if storeErr := jm.Store.CompleteJobLink(result); storeErr != nil {
+			jm.Logger.Warn("Failed to save job link result", zap.String("JobID", id), zap.String("Link", link), zap.Error(storeErr))
+		}
+
+		jm.mu.Lock()
+		defer jm.mu.Unlock()
+		switch result.Status {
+		case database.JobLinkFailed:
+			job.Failed++
+			if len(job.Failures) < maxJobFailures {
+				job.Failures = append(job.Failures, LinkResult{Link: link, Err: err})
+			}
+		case database.JobLinkCached:
+			job.Cached++
+		default:
+			job.Fetched++
+		}
+		jm.notify(entry)
+	})
+
+	jm.mu.Lock()
+	if job.State != JobCancelled && ctx.Err() != nil {
+		jm.mu.Unlock()
+		jm.Logger.Warn("Job interrupted, it will be resumed on restart", zap.String("JobID", id))
+		return
+	}
+	if job.State != JobCancelled {
+		job.State = JobCompleted
+	}
+	job.FinishedAt = time.Now()
+	delete(jm.active, id)
+	jm.notify(entry)
+	finished := jm.snapshot(job)
+	jm.mu.Unlock()
+
+	jm.saveState(finished)
+	jm.deliverWebhook(finished)
+	jm.Logger.Info("Job finished", zap.String("JobID", id), zap.String("State", finished.State),
+		zap.Int("Fetched", finished.Fetched), zap.Int("Cached", finished.Cached), zap.Int("Failed", finished.Failed))
+}
+
+// loadJob загружает задание, первые ошибки его ссылок и попытки доставки уведомления из хранилища. Возвращает nil, если задание не найдено.
+func (jm *JobManager) loadJob(id string) (*Job, error) {
+	record, err := jm.Store.GetJob(id)
+	if err != nil {
+		jm.Logger.Error("Failed to load job", zap.String("JobID", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to load job: %w", err)
+	}
+	if record == nil {
+		return nil, nil
+	}
+	failures, err := jm.Store.JobFailures(id, maxJobFailures)
+	if err != nil {
+		jm.Logger.Error("Failed to load job failures", zap.String("JobID", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to load job failures: %w", err)
+	}
+	job := fromJobRecord(*record, failures)
+	if job.CallbackURL != "" {
+		if job.Deliveries, err = jm.Store.WebhookDeliveries(id); err != nil {
+			jm.Logger.Error("Failed to load webhook deliveries", zap.String("JobID", id), zap.Error(err))
+			return nil, fmt.Errorf("failed to load webhook deliveries: %w", err)
+		}
+	}
+	return job, nil
+}
+
+// saveState сохраняет состояние задания; ошибка только логируется, так как прогресс ссылок уже сохранён.
+func (jm *JobManager) saveState(job Job) {
+	record := toJobRecord(&job)
+	if err := jm.Store.UpdateJobState(&record); err != nil {
+		jm.Logger.Error("Failed to save job state", zap.String("JobID", job.ID), zap.Error(err))
+	}
+}
+
+// notify сообщает наблюдателям задания об изменении прогресса, не дожидаясь их.
+// Вызывается под блокировкой менеджера.
+func (jm *JobManager) notify(entry *activeJob) {
+	for updates := range entry.watchers {
+		select {
+		case updates <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// snapshot возвращает копию задания, которую можно отдавать наружу без блокировки.
+// Вызывается под блокировкой менеджера.
+func (jm *JobManager) snapshot(job *Job) Job {
+	snapshot := *job
+	snapshot.Failures = append([]LinkResult(nil), job.Failures...)
+	return snapshot
+}
+
+// toJobRecord преобразует задание в запись хранилища.
+func toJobRecord(job *Job) database.Job {
+	return database.Job{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		State:       job.State,
+		Total:       job.Total,
+		Fetched:     job.Fetched,
+		Cached:      job.Cached,
+		Failed:      job.Failed,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+		CallbackURL: job.CallbackURL,
+		Priority:    job.Priority,
+		Owner:       job.Owner,
+	}
+}
+
+// fromJobRecord преобразует запись хранилища и ошибки ссылок в задание.
+func fromJobRecord(record database.Job, failures []database.JobLink) *Job {
+	job := &Job{
+		ID:          record.ID,
+		Kind:        record.Kind,
+		State:       record.State,
+		Total:       record.Total,
+		Fetched:     record.Fetched,
+		Cached:      record.Cached,
+		Failed:      record.Failed,
+		CreatedAt:   record.CreatedAt,
+		StartedAt:   record.StartedAt,
+		FinishedAt:  record.FinishedAt,
+		CallbackURL: record.CallbackURL,
+		Priority:    record.Priority,
+		Owner:       record.Owner,
+	}
+	// Задания, созданные до появления приоритетов, выполняются как фоновые
+	if job.Priority == "" {
+		job.Priority = PriorityBulk
+	}
+	for _, failure := range failures {
+		job.Failures = append(job.Failures, LinkResult{Link: failure.URL, Err: errors.New(failure.Error)})
+	}
+	return job
+}
+
+// newJobID генерирует случайный идентификатор задания.
+func newJobID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+/*
+NewJobManager создает менеджер фоновых заданий.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+logic: бизнес-логика, выполняющая загрузку обложек.
+store: хранилище заданий.
+queueSize: сколько заданий может ждать в очереди.
+maxLinks: максимум ссылок в одном задании.
+
+Run продолжает незавершённые задания, а затем выполняет задания из очереди до отмены ctx.
+ctx: контекст жизни сервиса.
+
+LinkLimit возвращает максимальное количество ссылок в одном задании.
+
+SubmitJob создает задание загрузки обложек, сохраняет его и ставит в очередь.
+links: ссылки на видео.
+options: необязательные параметры задания (адрес уведомления, приоритет).
+Возвращает снимок созданного задания.
+
+GetJob возвращает снимок задания по идентификатору.
+id: идентификатор задания.
+
+ListJobs возвращает страницу заданий, начиная с самых новых, и токен следующей страницы.
+owner: клиент, поставивший задания (пусто — все клиенты).
+state: состояние заданий (пусто — все задания).
+pageToken: токен страницы из предыдущего ответа.
+pageSize: размер страницы.
+
+CancelJob отменяет задание из очереди или выполняющееся задание.
+id: идентификатор задания.
+
+WatchJob передает в send снимки задания при каждом изменении прогресса до его завершения.
+ctx: контекст вызова.
+id: идентификатор задания.
+send: получатель снимков.
+
+resume загружает незавершённые задания и выполняет их по порядку создания.
+ctx: контекст жизни сервиса.
+
+run выполняет необработанные ссылки задания и сохраняет результат каждой из них.
+ctx: контекст жизни сервиса.
+id: идентификатор задания.
+
+loadJob загружает задание, первые ошибки его ссылок и попытки доставки уведомления из хранилища.
+id: идентификатор задания.
+
+saveState сохраняет состояние задания.
+job: снимок задания.
+
+notify сообщает наблюдателям задания об изменении прогресса.
+entry: активное задание.
+
+snapshot возвращает копию задания, которую можно отдавать наружу без блокировки.
+
+toJobRecord преобразует задание в запись хранилища.
+
+fromJobRecord преобразует запись хранилища и ошибки ссылок в задание.
+
+newJobID генерирует случайный идентификатор задания.
+*/
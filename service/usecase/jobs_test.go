@@ -0,0 +1,291 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+)
+
+// MockLogger заглушка для логирования в тестах
+type MockLogger struct{}
+
+func (m *MockLogger) Info(message string, fields ...interface{})  {}
+func (m *MockLogger) Warn(message string, fields ...interface{})  {}
+func (m *MockLogger) Error(message string, fields ...interface{}) {}
+
+// fakeYouTube возвращает содержимое по ссылке и ошибку для ссылок, содержащих "broken".
+// Если задан gate, каждая загрузка ждёт значения из него.
+type fakeYouTube struct {
+	fetches atomic.Int32
+	gate    chan struct{}
+}
+
+func (f *fakeYouTube) ProcessLinks(links []string) error { return nil }
+
+func (f *fakeYouTube) FetchThumbnail(link string) ([]byte, error) {
+	f.fetches.Add(1)
+	if f.gate != nil {
+		<-f.gate
+	}
+	if strings.Contains(link, "broken") {
+		return nil, errors.New("thumbnail not found")
+	}
+	return []byte("image of " + link), nil
+}
+
+// newTestLogic создает бизнес-логику поверх временной базы SQLite.
+func newTestLogic(t *testing.T, dbFile string) (*BusinessLogic, *fakeYouTube) {
+	t.Cleanup(func() { os.Remove(dbFile) })
+	db, err := database.NewSQLiteDatabase(&MockLogger{}, dbFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitDatabase(); err != nil {
+		t.Fatalf("Failed to initialize tables: %v", err)
+	}
+	youtube := &fakeYouTube{}
+	return NewBusinessLogic(&MockLogger{}, db, youtube, nil, 4), youtube
+}
+
+// TestFetchPipeline проверяет, что конвейер обрабатывает все ссылки, не превышая число воркеров.
+func TestFetchPipeline(t *testing.T) {
+	links := make([]string, 50)
+	var active, peak atomic.Int32
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	fetchPipeline(context.Background(), links, 3, func(i int, link string) {
+		current := active.Add(1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		mu.Lock()
+		seen[i] = true
+		mu.Unlock()
+	})
+	if len(seen) != len(links) {
+		t.Errorf("Expected %d links processed, got %d", len(links), len(seen))
+	}
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most 3 concurrent workers, got %d", peak.Load())
+	}
+}
+
+// newTestJobManager создает менеджер заданий, хранящий задания в базе бизнес-логики.
+func newTestJobManager(logic *BusinessLogic, queueSize int) *JobManager {
+	return NewJobManager(&MockLogger{}, logic, logic.Sqlite.(database.JobStore), queueSize, 10)
+}
+
+// TestWarmCacheJob проверяет выполнение задания предварительной загрузки и его прогресс.
+func TestWarmCacheJob(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_warm_cache.db")
+	if err := logic.Sqlite.InsertResource(&database.Resource{URL: "https://youtu.be/cached", Photo: []byte("cached")}); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+
+	manager := newTestJobManager(logic, 1)
+	if _, err := manager.SubmitJob([]string{" ", ""}, JobOptions{}); !errors.Is(err, ErrNoLinks) {
+		t.Errorf("Expected ErrNoLinks, got %v", err)
+	}
+	first, err := manager.SubmitJob(strings.Split(strings.Repeat("https://youtu.be/x,", 11), ","), JobOptions{})
+	if err != nil || first.Total != 1 {
+		t.Fatalf("Expected duplicate links to collapse into one, got %+v (%v)", first, err)
+	}
+	// Очередь рассчитана на одно задание, а менеджер ещё не запущен
+	if _, err := manager.SubmitJob([]string{"https://youtu.be/y"}, JobOptions{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Run(ctx)
+	waitJob(t, manager, first.ID)
+
+	job, err := manager.SubmitJob([]string{"https://youtu.be/a", "https://youtu.be/b", "https://youtu.be/cached", "https://youtu.be/broken", "https://youtu.be/a"}, JobOptions{Owner: "dashboard"})
+	if err != nil {
+		t.Fatalf("Failed to queue job: %v", err)
+	}
+	if job.Total != 4 || job.State != JobQueued {
+		t.Errorf("Expected queued job with 4 links, got %+v", job)
+	}
+
+	job = waitJob(t, manager, job.ID)
+	if job.Fetched != 2 || job.Cached != 1 || job.Failed != 1 {
+		t.Errorf("Unexpected progress: fetched %d, cached %d, failed %d", job.Fetched, job.Cached, job.Failed)
+	}
+	if len(job.Failures) != 1 || job.Failures[0].Link != "https://youtu.be/broken" {
+		t.Errorf("Expected failure for the broken link, got %+v", job.Failures)
+	}
+	if exists, _ := logic.Sqlite.ResourceExists("https://youtu.be/a"); !exists {
+		t.Errorf("Expected warmed link to be cached")
+	}
+	// Первое задание (одна ссылка) и три незакэшированные ссылки второго
+	if got := youtube.fetches.Load(); got != 4 {
+		t.Errorf("Expected 4 fetches, got %d", got)
+	}
+
+	if job, err := manager.GetJob("unknown"); err != nil || job != nil {
+		t.Errorf("Expected unknown job to be nil, got %+v (%v)", job, err)
+	}
+
+	// Завершённые задания читаются из базы вместе с ошибками
+	jobs, next, err := manager.ListJobs("", JobCompleted, "", 1)
+	if err != nil || len(jobs) != 1 || next == "" {
+		t.Fatalf("Unexpected first page: %+v, %q (%v)", jobs, next, err)
+	}
+	if jobs, next, _ := manager.ListJobs("", JobCompleted, next, 1); len(jobs) != 1 || next == "" {
+		t.Errorf("Unexpected second page: %+v, %q", jobs, next)
+	}
+	if jobs, _, _ := manager.ListJobs("dashboard", "", "", 0); len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("Expected only the owner's job, got %+v", jobs)
+	}
+	stored, err := newTestJobManager(logic, 1).GetJob(job.ID)
+	if err != nil || stored == nil || stored.Owner != "dashboard" || stored.Failed != 1 || len(stored.Failures) != 1 || stored.Failures[0].Err.Error() != "thumbnail not found" {
+		t.Errorf("Unexpected stored job: %+v (%v)", stored, err)
+	}
+}
+
+// TestJobResume проверяет, что после перезапуска задание продолжается только с необработанных ссылок.
+func TestJobResume(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_job_resume.db")
+	store := logic.Sqlite.(database.JobStore)
+	record := &database.Job{ID: "interrupted", Kind: JobKindWarmCache, State: JobRunning, Total: 3, CreatedAt: time.Now(), StartedAt: time.Now()}
+	if err := store.CreateJob(record, []string{"https://youtu.be/done", "https://youtu.be/a", "https://youtu.be/b"}); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if err := store.CompleteJobLink(database.JobLink{JobID: "interrupted", Position: 0, Status: database.JobLinkFetched}); err != nil {
+		t.Fatalf("Failed to complete link: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go newTestJobManager(logic, 1).Run(ctx)
+	job := waitJob(t, newTestJobManager(logic, 1), "interrupted")
+	if job.Fetched != 3 || job.Failed != 0 {
+		t.Errorf("Unexpected progress after resume: %+v", job)
+	}
+	if got := youtube.fetches.Load(); got != 2 {
+		t.Errorf("Expected only 2 pending links to be fetched, got %d", got)
+	}
+}
+
+// TestCancelJob проверяет отмену задания из очереди и выполняющегося задания.
+func TestCancelJob(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_cancel_job.db")
+	youtube.gate = make(chan struct{})
+	logic.FetchWorkers = 1
+	manager := newTestJobManager(logic, 2)
+
+	queued, err := manager.SubmitJob([]string{"https://youtu.be/q"}, JobOptions{})
+	if err != nil {
+		t.Fatalf("Failed to queue job: %v", err)
+	}
+	if job, err := manager.CancelJob(queued.ID); err != nil || job.State != JobCancelled || job.FinishedAt.IsZero() {
+		t.Fatalf("Expected cancelled job, got %+v (%v)", job, err)
+	}
+	if _, err := manager.CancelJob(queued.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("Expected ErrJobFinished, got %v", err)
+	}
+	if _, err := manager.CancelJob("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	running, err := manager.SubmitJob([]string{"https://youtu.be/1", "https://youtu.be/2", "https://youtu.be/3"}, JobOptions{})
+	if err != nil {
+		t.Fatalf("Failed to queue job: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Run(ctx)
+
+	// Отмена первой загрузки: ссылка дорабатывается, остальные пропускаются
+	youtube.gate <- struct{}{}
+	for youtube.fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := manager.CancelJob(running.ID); err != nil {
+		t.Fatalf("Failed to cancel running job: %v", err)
+	}
+	close(youtube.gate)
+
+	job := waitJob(t, manager, running.ID)
+	if job.State != JobCancelled || job.Fetched != 2 {
+		t.Errorf("Expected cancelled job with 2 fetched links, got %+v", job)
+	}
+	if stored, _ := newTestJobManager(logic, 1).GetJob(running.ID); stored.State != JobCancelled {
+		t.Errorf("Expected cancelled state to be stored, got %+v", stored)
+	}
+}
+
+// TestWatchJob проверяет поток прогресса задания до его завершения.
+func TestWatchJob(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_watch_job.db")
+	youtube.gate = make(chan struct{})
+	manager := newTestJobManager(logic, 1)
+	job, err := manager.SubmitJob([]string{"https://youtu.be/1", "https://youtu.be/2"}, JobOptions{})
+	if err != nil {
+		t.Fatalf("Failed to queue job: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Run(ctx)
+
+	var snapshots []Job
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.WatchJob(ctx, job.ID, func(job *Job) error {
+			snapshots = append(snapshots, *job)
+			return nil
+		})
+	}()
+	close(youtube.gate)
+	if err := <-done; err != nil {
+		t.Fatalf("WatchJob failed: %v", err)
+	}
+	last := snapshots[len(snapshots)-1]
+	if last.State != JobCompleted || last.Fetched != 2 {
+		t.Errorf("Expected completed job as the last snapshot, got %+v", last)
+	}
+
+	// Для завершённого задания отправляется один снимок
+	snapshots = nil
+	if err := manager.WatchJob(ctx, job.ID, func(job *Job) error {
+		snapshots = append(snapshots, *job)
+		return nil
+	}); err != nil || len(snapshots) != 1 {
+		t.Errorf("Expected one snapshot for finished job, got %d (%v)", len(snapshots), err)
+	}
+	if err := manager.WatchJob(ctx, "unknown", func(*Job) error { return nil }); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
+// waitJob опрашивает задание, пока оно не завершится.
+func waitJob(t *testing.T, manager *JobManager, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := manager.GetJob(id)
+		if err != nil || job == nil {
+			t.Fatalf("Failed to get job: %+v (%v)", job, err)
+		}
+		if !job.FinishedAt.IsZero() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not complete", id)
+	return nil
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultFetchWorkers — количество параллельных загрузок, если оно не задано в конфигурации.
+const defaultFetchWorkers = 8
+
+// fetchPipeline выполняет fn для каждой ссылки, ограничивая параллелизм workers горутинами.
+// Ссылки раздаются по мере освобождения воркеров, поэтому большой список не порождает
+// тысячи одновременных запросов к YouTube. После отмены ctx новые ссылки не начинаются,
+// а уже начатые дорабатывают. fn получает индекс ссылки в исходном списке.
+func fetchPipeline(ctx context.Context, links []string, workers int, fn func(i int, link string)) {
+	if workers <= 0 {
+		workers = defaultFetchWorkers
+	}
+	if workers > len(links) {
+		workers = len(links)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// Ссылка могла быть выдана одновременно с отменой
+				if ctx.Err() != nil {
+					continue
+				}
+				fn(i, links[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range links {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+/*
+fetchPipeline выполняет fn для каждой ссылки, ограничивая параллелизм workers горутинами.
+ctx: контекст; после отмены новые ссылки не начинаются.
+links: обрабатываемые ссылки.
+workers: количество воркеров (0 — по умолчанию).
+fn: обработчик ссылки, получает её индекс в списке.
+*/
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Приоритеты запросов к YouTube. Каждому приоритету соответствует своя очередь (полоса)
+// в FetchScheduler.
+const (
+	PriorityInteractive = "interactive" // Запросы, которых ждёт пользователь (SendData, поиск похожих, контактные листы).
+	PriorityBulk        = "bulk"        // Фоновая работа: задания, плановое и административное обновление.
+)
+
+// defaultLaneWeights — веса полос по умолчанию: пока обе очереди не пусты,
+// интерактивные запросы получают 8 освободившихся слотов из 9.
+var defaultLaneWeights = map[string]int{
+	PriorityInteractive: 8,
+	PriorityBulk:        1,
+}
+
+// ErrUnknownPriority возвращается для приоритета, которому не соответствует ни одна полоса.
+var ErrUnknownPriority = errors.New("unknown priority")
+
+// resolvePriority возвращает приоритет запроса: пустой заменяется значением по умолчанию,
+// неизвестный отклоняется.
+func resolvePriority(priority, fallback string) (string, error) {
+	if priority == "" {
+		return fallback, nil
+	}
+	if _, ok := defaultLaneWeights[priority]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownPriority, priority)
+	}
+	return priority, nil
+}
+
+// fetchLane — очередь ожидающих слота запросов одного приоритета.
+type fetchLane struct {
+	priority string
+	weight   int
+	current  int             // Накопленный вес для сглаженного взвешенного циклического выбора
+	waiters  []chan struct{} // Ожидающие в порядке поступления; закрытие канала передаёт слот
+}
+
+// FetchScheduler ограничивает число одновременных загрузок с YouTube общим для всего сервиса
+// количеством слотов. Свободный слот выдаётся сразу; если слотов нет, запрос ждёт в очереди своего
+// приоритета. Освободившийся слот достаётся одной из непустых очередей по сглаженному взвешенному
+// циклическому алгоритму (как в nginx): пока ждут обе полосы, слоты делятся пропорционально весам,
+// поэтому интерактивные запросы не ждут за тысячами фоновых, а фоновые не голодают.
+// Методы nil-планировщика не ограничивают загрузки.
+type FetchScheduler struct {
+	mu    sync.Mutex
+	free  int
+	lanes []*fetchLane
+}
+
+// NewFetchScheduler создает планировщик загрузок.
+// slots: количество одновременных загрузок (0 — по умолчанию).
+// weights: веса полос по приоритетам; незаданные и неположительные веса берутся по умолчанию.
+func NewFetchScheduler(slots int, weights map[string]int) *FetchScheduler {
+	if slots <= 0 {
+		slots = defaultFetchWorkers
+	}
+	fs := &FetchScheduler{free: slots}
+	for _, priority := range []string{PriorityInteractive, PriorityBulk} {
+		weight := weights[priority]
+		if weight <= 0 {
+			weight = defaultLaneWeights[priority]
+		}
+		fs.lanes = append(fs.lanes, &fetchLane{priority: priority, weight: weight})
+	}
+	return fs
+}
+
+// Acquire занимает слот загрузки для запроса с приоритетом priority, ожидая его при необходимости.
+// Неизвестный приоритет обслуживается как PriorityBulk. Возвращает ошибку ctx, если ожидание прервано;
+// в этом случае слот не занят. После успешного вызова слот нужно вернуть через Release.
+func (fs *FetchScheduler) Acquire(ctx context.Context, priority string) error {
+	if fs == nil {
+		return nil
+	}
+	fs.mu.Lock()
+	lane := fs.lane(priority)
+	if fs.free > 0 && !fs.waiting() {
+		fs.free--
+		fs.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	lane.waiters = append(lane.waiters, ready)
+	fs.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	fs.mu.Lock()
+	for i, waiter := range lane.waiters {
+		if waiter == ready {
+			lane.waiters = append(lane.waiters[:i], lane.waiters[i+1:]...)
+			fs.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+	fs.mu.Unlock()
+	// Слот был передан одновременно с отменой: возвращаем его следующему
+	fs.Release()
+	return ctx.Err()
+}
+
+// Release возвращает слот загрузки: он передаётся ожидающему запросу из очереди,
+// выбранной по весам, или становится свободным.
+func (fs *FetchScheduler) Release() {
+	if fs == nil {
+		return
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var next *fetchLane
+	total := 0
+	for _, lane := range fs.lanes {
+		if len(lane.waiters) == 0 {
+			continue
+		}
+		lane.current += lane.weight
+		total += lane.weight
+		if next == nil || lane.current > next.current {
+			next = lane
+		}
+	}
+	if next == nil {
+		fs.free++
+		return
+	}
+	next.current -= total
+	ready := next.waiters[0]
+	next.waiters = next.waiters[1:]
+	close(ready)
+}
+
+// Waiting возвращает количество запросов, ожидающих слот, по приоритетам.
+func (fs *FetchScheduler) Waiting() map[string]int {
+	waiting := make(map[string]int)
+	if fs == nil {
+		return waiting
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, lane := range fs.lanes {
+		waiting[lane.priority] = len(lane.waiters)
+	}
+	return waiting
+}
+
+// lane возвращает очередь приоритета; вызывается под mu.
+func (fs *FetchScheduler) lane(priority string) *fetchLane {
+	for _, lane := range fs.lanes {
+		if lane.priority == priority {
+			return lane
+		}
+	}
+	return fs.lane(PriorityBulk)
+}
+
+// waiting сообщает, есть ли ожидающие запросы; вызывается под mu.
+func (fs *FetchScheduler) waiting() bool {
+	for _, lane := range fs.lanes {
+		if len(lane.waiters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+resolvePriority возвращает приоритет запроса: пустой заменяется значением по умолчанию.
+priority: приоритет из запроса.
+fallback: приоритет по умолчанию.
+
+NewFetchScheduler создает планировщик загрузок.
+slots: количество одновременных загрузок.
+weights: веса полос по приоритетам.
+
+Acquire занимает слот загрузки, ожидая его при необходимости.
+ctx: контекст ожидания.
+priority: приоритет запроса.
+
+Release возвращает слот загрузки следующему ожидающему или в свободные.
+
+Waiting возвращает количество запросов, ожидающих слот, по приоритетам.
+
+lane возвращает очередь приоритета.
+priority: приоритет запроса.
+
+waiting сообщает, есть ли ожидающие запросы.
+*/
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForWaiting ждёт, пока в очередях планировщика окажется ожидаемое число запросов.
+func waitForWaiting(t *testing.T, fs *FetchScheduler, interactive, bulk int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		waiting := fs.Waiting()
+		if waiting[PriorityInteractive] == interactive && waiting[PriorityBulk] == bulk {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d interactive and %d bulk waiters, got %v", interactive, bulk, waiting)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestFetchSchedulerWeights проверяет, что освободившиеся слоты делятся между полосами по весам
+// и внутри полосы выдаются в порядке поступления.
+func TestFetchSchedulerWeights(t *testing.T) {
+	fs := NewFetchScheduler(1, map[string]int{PriorityInteractive: 3, PriorityBulk: 1})
+	if err := fs.Acquire(context.Background(), PriorityBulk); err != nil {
+		t.Fatalf("Failed to acquire free slot: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(priority string, count int) {
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fs.Acquire(context.Background(), priority); err != nil {
+					t.Errorf("Acquire failed: %v", err)
+					return
+				}
+				mu.Lock()
+				order = append(order, priority)
+				mu.Unlock()
+				fs.Release()
+			}()
+		}
+	}
+	enqueue(PriorityBulk, 4)
+	waitForWaiting(t, fs, 0, 4)
+	enqueue(PriorityInteractive, 4)
+	waitForWaiting(t, fs, 4, 4)
+
+	fs.Release()
+	wg.Wait()
+	expected := []string{PriorityInteractive, PriorityInteractive, PriorityBulk, PriorityInteractive, PriorityInteractive, PriorityBulk, PriorityBulk, PriorityBulk}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %d grants, got %v", len(expected), order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Unexpected grant order: %v", order)
+		}
+	}
+}
+
+// TestFetchSchedulerCancel проверяет, что отменённое ожидание не занимает слот.
+func TestFetchSchedulerCancel(t *testing.T) {
+	fs := NewFetchScheduler(1, nil)
+	if err := fs.Acquire(context.Background(), PriorityInteractive); err != nil {
+		t.Fatalf("Failed to acquire free slot: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := fs.Acquire(ctx, PriorityBulk); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+	waitForWaiting(t, fs, 0, 0)
+
+	fs.Release()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := fs.Acquire(ctx, PriorityBulk); err != nil {
+		t.Errorf("Expected released slot to be free, got %v", err)
+	}
+}
+
+// TestRequestPriority проверяет приоритеты по умолчанию и отклонение неизвестного приоритета.
+func TestRequestPriority(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_priority.db")
+	if _, err := logic.ProcessData(context.Background(), false, []string{"https://youtu.be/a"}, ProcessOptions{Priority: "urgent"}); !errors.Is(err, ErrUnknownPriority) {
+		t.Errorf("Expected ErrUnknownPriority, got %v", err)
+	}
+
+	manager := newTestJobManager(logic, 2)
+	if _, err := manager.SubmitJob([]string{"https://youtu.be/a"}, JobOptions{Priority: "urgent"}); !errors.Is(err, ErrUnknownPriority) {
+		t.Errorf("Expected ErrUnknownPriority, got %v", err)
+	}
+	job, err := manager.SubmitJob([]string{"https://youtu.be/a"}, JobOptions{})
+	if err != nil || job.Priority != PriorityBulk {
+		t.Fatalf("Expected bulk job by default, got %+v (%v)", job, err)
+	}
+	job, err = manager.SubmitJob([]string{"https://youtu.be/b"}, JobOptions{Priority: PriorityInteractive})
+	if err != nil {
+		t.Fatalf("Failed to queue job: %v", err)
+	}
+	if stored, err := manager.GetJob(job.ID); err != nil || stored.Priority != PriorityInteractive {
+		t.Errorf("Expected interactive priority to be kept, got %+v (%v)", stored, err)
+	}
+}
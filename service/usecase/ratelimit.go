@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter ограничивает частоту запросов к YouTube алгоритмом «ведро токенов»: токены
+// пополняются с постоянной скоростью до burst, каждый запрос забирает один токен, а при пустом
+// ведре ждёт следующего. Ограничение общее для всех загрузок сервиса — интерактивных, заданий
+// и планового обновления. Методы nil-ограничителя не ограничивают запросы.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // Время пополнения одного токена
+	burst    float64
+	tokens   float64
+	last     time.Time // Время последнего пополнения
+}
+
+// NewRateLimiter создает ограничитель частоты запросов. Ведро изначально полно.
+// perSecond: средняя частота запросов в секунду; неположительное значение отключает ограничение.
+// burst: сколько запросов можно выполнить подряд без ожидания (не меньше 1).
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait забирает токен, ожидая его пополнения при необходимости. Возвращает ошибку ctx,
+// если ожидание прервано; в этом случае токен не забирается.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+	for {
+		delay, ok := rl.take()
+		if ok {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// take пополняет ведро по прошедшему времени и забирает токен, если он есть.
+// Иначе возвращает время до появления следующего токена и false.
+func (rl *RateLimiter) take() (time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	rl.tokens = min(rl.burst, rl.tokens+float64(now.Sub(rl.last))/float64(rl.interval))
+	rl.last = now
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0, true
+	}
+	return max(time.Duration((1-rl.tokens)*float64(rl.interval)), time.Millisecond), false
+}
+
+/*
+NewRateLimiter создает ограничитель частоты запросов.
+perSecond: средняя частота запросов в секунду.
+burst: сколько запросов можно выполнить подряд без ожидания.
+
+Wait забирает токен, ожидая его пополнения при необходимости.
+ctx: контекст ожидания.
+
+take пополняет ведро и забирает токен или возвращает время ожидания следующего.
+*/
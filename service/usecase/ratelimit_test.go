@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter проверяет выдачу burst запросов без ожидания, ожидание пополнения
+// и прерывание ожидания отменой контекста.
+func TestRateLimiter(t *testing.T) {
+	if err := (*RateLimiter)(nil).Wait(context.Background()); err != nil {
+		t.Errorf("Expected nil limiter not to limit, got %v", err)
+	}
+	if NewRateLimiter(0, 5) != nil {
+		t.Error("Expected zero rate to disable the limiter")
+	}
+
+	limiter := NewRateLimiter(20, 2)
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Failed to wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected burst requests not to wait, took %v", elapsed)
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("Expected request after the burst to wait for a token, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected wait to be interrupted, got %v", err)
+	}
+}
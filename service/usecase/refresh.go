@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shelon_server/utilss/cron"
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+)
+
+// NewRefreshTask проверяет параметры планового обновления и разбирает его расписание.
+// name: имя задачи для логов.
+// expression: cron-выражение расписания.
+// source: источник записей (RefreshPopular или RefreshStale).
+// limit: максимум записей за запуск.
+// maxAge: для RefreshStale — возраст, начиная с которого запись обновляется.
+func NewRefreshTask(name, expression, source string, limit int, maxAge time.Duration) (RefreshTask, error) {
+	schedule, err := cron.Parse(expression)
+	if err != nil {
+		return RefreshTask{}, fmt.Errorf("refresh task %q: %w", name, err)
+	}
+	switch {
+	case source != RefreshPopular && source != RefreshStale:
+		return RefreshTask{}, fmt.Errorf("refresh task %q: unsupported source %q", name, source)
+	case limit <= 0:
+		return RefreshTask{}, fmt.Errorf("refresh task %q: limit must be positive", name)
+	case source == RefreshStale && maxAge <= 0:
+		return RefreshTask{}, fmt.Errorf("refresh task %q: max age must be positive", name)
+	}
+	return RefreshTask{Name: name, Schedule: schedule, Source: source, Limit: limit, MaxAge: maxAge}, nil
+}
+
+// RunRefresh выбирает записи по источнику задачи и загружает их обложки заново через конвейер
+// загрузки в полосе PriorityBulk, не опережая интерактивные запросы. Ошибка загрузки не удаляет
+// прежнюю версию из кэша. После отмены ctx новые загрузки не начинаются.
+func (bl *BusinessLogic) RunRefresh(ctx context.Context, task RefreshTask) (RefreshSummary, error) {
+	started := time.Now()
+	links, err := bl.refreshCandidates(task)
+	if err != nil {
+		bl.Logger.Error("Error selecting entries to refresh", zap.String("Task", task.Name), zap.Error(err))
+		return RefreshSummary{}, err
+	}
+
+	var refreshed, failed atomic.Int32
+	fetchPipeline(ctx, links, bl.FetchWorkers, func(i int, link string) {
+		if _, err := bl.fetchAndStore(ctx, link, PriorityBulk); err != nil {
+			failed.Add(1)
+			return
+		}
+		refreshed.Add(1)
+	})
+	return RefreshSummary{
+		Selected:  len(links),
+		Refreshed: int(refreshed.Load()),
+		Failed:    int(failed.Load()),
+		Duration:  time.Since(started),
+	}, nil
+}
+
+// refreshCandidates возвращает ссылки записей для обновления: самые запрашиваемые видео,
+// которые есть в кэше, или самые давно загруженные записи старше task.MaxAge.
+func (bl *BusinessLogic) refreshCandidates(task RefreshTask) ([]string, error) {
+	var links []string
+	switch task.Source {
+	case RefreshPopular:
+		for _, link := range bl.stats.topLinks(task.Limit) {
+			exists, err := bl.Sqlite.ResourceExists(link)
+			if err != nil {
+				return nil, err
+			}
+			// Отсутствующие обложки загрузит первый же запрос клиента
+			if exists {
+				links = append(links, link)
+			}
+		}
+	case RefreshStale:
+		entries, err := bl.Sqlite.OldestEntries(time.Now().Add(-task.MaxAge), task.Limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			links = append(links, entry.URL)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported refresh source %q", task.Source)
+	}
+	return links, nil
+}
+
+// RefreshScheduler запускает плановые обновления кэша по расписаниям задач.
+// Запуски одной задачи не пересекаются: если обновление длится дольше интервала,
+// пропущенные срабатывания не накапливаются и следующее выбирается после завершения.
+type RefreshScheduler struct {
+	Logger logger.Logger
+	Logic  *BusinessLogic
+	Tasks  []RefreshTask
+}
+
+// NewRefreshScheduler создает планировщик обновлений кэша.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// logic: бизнес-логика, выполняющая загрузку обложек.
+// tasks: задачи планового обновления.
+func NewRefreshScheduler(logger logger.Logger, logic *BusinessLogic, tasks []RefreshTask) *RefreshScheduler {
+	return &RefreshScheduler{
+		Logger: logger,
+		Logic:  logic,
+		Tasks:  tasks,
+	}
+}
+
+// Run выполняет задачи по расписанию до отмены ctx. Запуск, прерванный отменой, дорабатывает
+// уже начатые загрузки.
+func (rs *RefreshScheduler) Run(ctx context.Context) {
+	rs.Logger.Info("Refresh scheduler started", zap.Int("Tasks", len(rs.Tasks)))
+	var wg sync.WaitGroup
+	for _, task := range rs.Tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rs.runTask(ctx, task)
+		}()
+	}
+	wg.Wait()
+	rs.Logger.Info("Refresh scheduler stopped")
+}
+
+// runTask ждёт очередного срабатывания расписания задачи и выполняет обновление.
+func (rs *RefreshScheduler) runTask(ctx context.Context, task RefreshTask) {
+	for {
+		next := task.Schedule.Next(time.Now())
+		if next.IsZero() {
+			rs.Logger.Warn("Refresh task never fires", zap.String("Task", task.Name))
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		summary, err := rs.Logic.RunRefresh(ctx, task)
+		if err != nil {
+			rs.Logger.Error("Scheduled refresh failed", zap.String("Task", task.Name), zap.Error(err))
+			continue
+		}
+		rs.Logger.Info("Scheduled refresh finished", zap.String("Task", task.Name), zap.String("Source", task.Source),
+			zap.Int("Selected", summary.Selected), zap.Int("Refreshed", summary.Refreshed), zap.Int("Failed", summary.Failed),
+			zap.Duration("Duration", summary.Duration), zap.Time("Next", task.Schedule.Next(time.Now())))
+	}
+}
+
+/*
+NewRefreshTask проверяет параметры планового обновления и разбирает его расписание.
+name: имя задачи для логов.
+expression: cron-выражение расписания.
+source: источник записей.
+limit: максимум записей за запуск.
+maxAge: возраст, начиная с которого запись обновляется.
+
+RunRefresh выбирает записи по источнику задачи и загружает их обложки заново.
+ctx: контекст; после отмены новые загрузки не начинаются.
+task: задача планового обновления.
+
+refreshCandidates возвращает ссылки записей для обновления.
+task: задача планового обновления.
+
+NewRefreshScheduler создает планировщик обновлений кэша.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+logic: бизнес-логика, выполняющая загрузку обложек.
+tasks: задачи планового обновления.
+
+Run выполняет задачи по расписанию до отмены ctx.
+ctx: контекст работы планировщика.
+
+runTask ждёт очередного срабатывания расписания задачи и выполняет обновление.
+ctx: контекст работы планировщика.
+task: задача планового обновления.
+*/
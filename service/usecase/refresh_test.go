@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+)
+
+// TestRunRefresh проверяет выбор записей для планового обновления и итог запуска.
+func TestRunRefresh(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_refresh.db")
+	popular, rare, stale := "https://youtu.be/popular", "https://youtu.be/rare", "https://youtu.be/stale"
+	for _, link := range []string{popular, rare, stale} {
+		if err := logic.Sqlite.InsertResource(&database.Resource{URL: link, Photo: []byte("old " + link)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	// Ссылка с ошибкой запрашивается часто, но в кэш не попадает
+	for _, links := range [][]string{{popular, "https://youtu.be/broken"}, {popular, "https://youtu.be/broken"}, {popular, rare}} {
+		if _, err := logic.ProcessData(context.Background(), false, links, ProcessOptions{}); err != nil {
+			t.Fatalf("Failed to process links: %v", err)
+		}
+	}
+	youtube.fetches.Store(0)
+
+	task, err := NewRefreshTask("popular", "@hourly", RefreshPopular, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	summary, err := logic.RunRefresh(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if summary.Selected != 1 || summary.Refreshed != 1 || summary.Failed != 0 || youtube.fetches.Load() != 1 {
+		t.Errorf("Unexpected popular refresh: %+v, fetches %d", summary, youtube.fetches.Load())
+	}
+	if photo, _ := logic.Sqlite.GetPhotoByUrl(popular); string(photo) != "image of "+popular {
+		t.Errorf("Expected popular entry to be refreshed, got %q", photo)
+	}
+
+	// Давно загруженная запись обновляется и получает новое время загрузки
+	db := logic.Sqlite.(*database.SQLDatabase)
+	if _, err := db.DB.Exec(`UPDATE resources SET fetched_at = ? WHERE url = ?`, time.Now().Add(-72*time.Hour).Unix(), stale); err != nil {
+		t.Fatalf("Failed to age entry: %v", err)
+	}
+	task, err = NewRefreshTask("stale", "30 3 * * *", RefreshStale, 10, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	summary, err = logic.RunRefresh(context.Background(), task)
+	if err != nil || summary.Selected != 1 || summary.Refreshed != 1 {
+		t.Errorf("Unexpected stale refresh: %+v (%v)", summary, err)
+	}
+	if entry, err := logic.Sqlite.GetEntry(stale); err != nil || time.Since(entry.FetchedAt) > time.Hour {
+		t.Errorf("Expected stale entry to be refreshed, got %+v (%v)", entry, err)
+	}
+}
+
+// TestNewRefreshTask проверяет отклонение некорректных параметров задачи.
+func TestNewRefreshTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		source     string
+		limit      int
+		maxAge     time.Duration
+	}{
+		{"bad cron", "every hour", RefreshPopular, 10, 0},
+		{"bad source", "@daily", "random", 10, 0},
+		{"no limit", "@daily", RefreshPopular, 0, 0},
+		{"stale without age", "@daily", RefreshStale, 10, 0},
+	}
+	for _, tt := range tests {
+		if _, err := NewRefreshTask(tt.name, tt.expression, tt.source, tt.limit, tt.maxAge); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+// TestRefreshEntries проверяет обновление записей по фильтру порциями со ссылкой продолжения
+// и остановку по отмене контекста.
+func TestRefreshEntries(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_refresh_entries.db")
+	total := maxRefreshEntries + 3
+	for i := 0; i < total; i++ {
+		link := fmt.Sprintf("https://youtu.be/video%04d", i)
+		if err := logic.Sqlite.InsertResource(&database.Resource{URL: link, Photo: []byte("old")}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	filter := database.EntryFilter{VideoIDPrefix: "video"}
+
+	results, next, err := logic.RefreshEntries(context.Background(), filter, "")
+	if err != nil || len(results) != maxRefreshEntries || next != results[maxRefreshEntries-1].Link {
+		t.Fatalf("Expected first batch with a continuation, got %d results, next %q (%v)", len(results), next, err)
+	}
+	results, next, err = logic.RefreshEntries(context.Background(), filter, next)
+	if err != nil || len(results) != 3 || next != "" || results[0].Link != fmt.Sprintf("https://youtu.be/video%04d", maxRefreshEntries) {
+		t.Fatalf("Expected the remaining entries without a continuation, got %d results, next %q (%v)", len(results), next, err)
+	}
+	if fetches := youtube.fetches.Load(); int(fetches) != total {
+		t.Errorf("Expected every entry to be refreshed once, got %d fetches", fetches)
+	}
+
+	links := make([]string, maxRefreshEntries+1)
+	for i := range links {
+		links[i] = fmt.Sprintf("https://youtu.be/video%04d", i)
+	}
+	if _, _, err := logic.RefreshEntries(context.Background(), database.EntryFilter{URLs: links}, ""); !errors.Is(err, ErrTooManyLinks) {
+		t.Errorf("Expected ErrTooManyLinks for %d explicit links, got %v", len(links), err)
+	}
+	if fetches := youtube.fetches.Load(); int(fetches) != total {
+		t.Errorf("Expected a rejected list not to be fetched, got %d fetches", fetches)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, _, err = logic.RefreshEntries(ctx, database.EntryFilter{URLs: []string{"https://youtu.be/video0000"}}, "")
+	if err != nil || len(results) != 1 || !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("Expected canceled refresh to report the link as not processed, got %+v (%v)", results, err)
+	}
+}
+
+// TestRefreshRateLimit проверяет, что плановое обновление выполняет запросы к YouTube
+// не чаще, чем разрешает ограничитель.
+func TestRefreshRateLimit(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_refresh_rate_limit.db")
+	for i := 0; i < 6; i++ {
+		link := fmt.Sprintf("https://youtu.be/stale%d", i)
+		if err := logic.Sqlite.InsertResource(&database.Resource{URL: link, Photo: []byte("old " + link), FetchedAt: time.Now().Add(-72 * time.Hour)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	// 50 запросов в секунду без запаса: шесть запросов занимают не меньше пяти интервалов по 20 мс
+	logic.Limiter = NewRateLimiter(50, 1)
+	task, err := NewRefreshTask("stale", "@hourly", RefreshStale, 10, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	start := time.Now()
+	summary, err := logic.RunRefresh(context.Background(), task)
+	if err != nil || summary.Refreshed != 6 || youtube.fetches.Load() != 6 {
+		t.Fatalf("Unexpected refresh: %+v, fetches %d (%v)", summary, youtube.fetches.Load(), err)
+	}
+	if elapsed := time.Since(start); elapsed < 95*time.Millisecond {
+		t.Errorf("Expected refresh to respect the rate limit, took %v", elapsed)
+	}
+}
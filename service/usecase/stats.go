@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	youtubeclient "shelon_server/integrations/youtubeCLient"
+)
+
+// maxTrackedVideos ограничивает количество различных видео, для которых считаются обращения:
+// после заполнения новые видео не добавляются, а счётчики уже известных продолжают расти.
+const maxTrackedVideos = 10000
+
+// requestStats считает обращения к кэшу с момента запуска сервиса.
+// Нулевое значение готово к использованию.
+type requestStats struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+
+	mu     sync.Mutex
+	videos map[string]uint64
+	links  map[string]string // Последняя запрошенная ссылка каждого видео
+}
+
+// recordRequest учитывает обращение к обложке видео по ссылке.
+func (rs *requestStats) recordRequest(link string) {
+	key, err := youtubeclient.ExtractVideoID(link)
+	if err != nil {
+		key = link
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.videos == nil {
+		rs.videos = make(map[string]uint64)
+		rs.links = make(map[string]string)
+	}
+	if _, ok := rs.videos[key]; ok || len(rs.videos) < maxTrackedVideos {
+		rs.videos[key]++
+		rs.links[key] = link
+	}
+}
+
+// topVideos возвращает n самых запрашиваемых видео по убыванию числа обращений.
+func (rs *requestStats) topVideos(n int) []VideoRequests {
+	rs.mu.Lock()
+	top := make([]VideoRequests, 0, len(rs.videos))
+	for videoID, requests := range rs.videos {
+		top = append(top, VideoRequests{VideoID: videoID, Requests: requests})
+	}
+	rs.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Requests != top[j].Requests {
+			return top[i].Requests > top[j].Requests
+		}
+		return top[i].VideoID < top[j].VideoID
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// topLinks возвращает ссылки n самых запрашиваемых видео по убыванию числа обращений.
+func (rs *requestStats) topLinks(n int) []string {
+	top := rs.topVideos(n)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	links := make([]string, 0, len(top))
+	for _, video := range top {
+		links = append(links, rs.links[video.VideoID])
+	}
+	return links
+}
+
+/*
+recordRequest учитывает обращение к обложке видео по ссылке.
+link: ссылка на видео.
+
+topVideos возвращает n самых запрашиваемых видео по убыванию числа обращений.
+n: количество видео.
+
+topLinks возвращает ссылки n самых запрашиваемых видео.
+n: количество видео.
+*/
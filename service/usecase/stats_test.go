@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	database "shelon_server/integrations/SQLLite"
+)
+
+// TestGetCacheStats проверяет счётчики попаданий, промахов и ошибок и объёмы сохранённых данных.
+func TestGetCacheStats(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_cache_stats.db")
+	ctx := context.Background()
+	link := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	// Первое обращение — промах, второе — попадание, ссылка без обложки — ошибка
+	for _, links := range [][]string{{link}, {link}, {"https://youtu.be/broken"}} {
+		if _, err := logic.ProcessData(ctx, false, links, ProcessOptions{}); err != nil {
+			t.Fatalf("Failed to process %v: %v", links, err)
+		}
+	}
+	if err := logic.Sqlite.InsertVariant(link, "w=10", []byte("variant")); err != nil {
+		t.Fatalf("Failed to insert variant: %v", err)
+	}
+
+	stats, err := logic.GetCacheStats(10)
+	if err != nil {
+		t.Fatalf("Failed to get cache stats: %v", err)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Errors != 1 {
+		t.Errorf("Expected 1 hit, 1 miss and 1 error, got %d, %d, %d", stats.Hits, stats.Misses, stats.Errors)
+	}
+	if stats.Entries != 1 || stats.OriginalBytes != int64(len("image of "+link)) || stats.VariantBytes != int64(len("variant")) {
+		t.Errorf("Unexpected stored volume: %+v", stats.CacheStats)
+	}
+	expected := []VideoRequests{{VideoID: "dQw4w9WgXcQ", Requests: 2}, {VideoID: "broken", Requests: 1}}
+	if !reflect.DeepEqual(stats.TopVideos, expected) {
+		t.Errorf("Expected top videos %v, got %v", expected, stats.TopVideos)
+	}
+}
+
+// TestGetCacheStatsTiers проверяет попадания и промахи по уровням кэша в памяти и базы данных.
+func TestGetCacheStatsTiers(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_cache_stats_tiers.db")
+	if stats, err := logic.GetCacheStats(0); err != nil || stats.Tiers != nil {
+		t.Fatalf("Expected no tiers without the memory cache, got %v (%v)", stats, err)
+	}
+
+	logic.Sqlite = database.NewMemoryCachedDatabase(&MockLogger{}, logic.Sqlite, 1<<20)
+	link := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	// Первое обращение не находит обложку ни в памяти, ни в базе; сохранённая обложка попадает в память
+	for i := 0; i < 3; i++ {
+		if _, err := logic.ProcessData(context.Background(), false, []string{link}, ProcessOptions{}); err != nil {
+			t.Fatalf("Failed to process link: %v", err)
+		}
+	}
+	stats, err := logic.GetCacheStats(0)
+	if err != nil {
+		t.Fatalf("Failed to get cache stats: %v", err)
+	}
+	expected := map[string]database.TierStats{
+		database.TierMemory:   {Hits: 2, Misses: 1},
+		database.TierDatabase: {Hits: 0, Misses: 1},
+	}
+	if !reflect.DeepEqual(stats.Tiers, expected) {
+		t.Errorf("Expected tiers %v, got %v", expected, stats.Tiers)
+	}
+}
+
+// TestTopVideos проверяет порядок самых запрашиваемых видео: по убыванию обращений,
+// при равенстве — по идентификатору, не больше n.
+func TestTopVideos(t *testing.T) {
+	var stats requestStats
+	for _, link := range []string{
+		"https://youtu.be/bbbbbbbbbbb",
+		"https://youtu.be/ccccccccccc",
+		"https://youtu.be/ccccccccccc",
+		"https://youtu.be/aaaaaaaaaaa",
+		"https://www.youtube.com/watch?v=ccccccccccc",
+	} {
+		stats.recordRequest(link)
+	}
+
+	expected := []VideoRequests{{VideoID: "ccccccccccc", Requests: 3}, {VideoID: "aaaaaaaaaaa", Requests: 1}}
+	if top := stats.topVideos(2); !reflect.DeepEqual(top, expected) {
+		t.Errorf("Expected %v, got %v", expected, top)
+	}
+	if top := stats.topVideos(10); len(top) != 3 || top[2].VideoID != "bbbbbbbbbbb" {
+		t.Errorf("Expected all three videos, got %v", top)
+	}
+	if links := stats.topLinks(1); len(links) != 1 || links[0] != "https://www.youtube.com/watch?v=ccccccccccc" {
+		t.Errorf("Expected last requested link of the top video, got %v", links)
+	}
+}
@@ -1,6 +1,14 @@
 package usecase
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"shelon_server/imaging"
 	database "shelon_server/integrations/SQLLite"
 	youtubeclient "shelon_server/integrations/youtubeCLient"
 	"shelon_server/utilss/logger"
@@ -13,142 +21,501 @@ import (
 // - Logger: логирование событий и ошибок.
 // - YouTubeService: клиент для взаимодействия с API YouTube.
 // - Sqlite: интерфейс для работы с базой данных SQLite.
+// - Transforms: цепочка преобразований, применяемых к обложкам перед возвратом клиенту.
+// - FetchWorkers: количество параллельных загрузок в конвейере (0 — по умолчанию).
+// - Lanes: общий для сервиса лимит загрузок с YouTube с очередями по приоритетам.
 type BusinessLogic struct {
 	Logger         logger.Logger
 	YouTubeService youtubeclient.YouTubeClient
 	Sqlite         database.Database
+	Transforms     []imaging.Transform
+	FetchWorkers   int
+	Lanes          *FetchScheduler
+	Limiter        *RateLimiter // Ограничение частоты запросов к YouTube; nil — без ограничения
+
+	stats requestStats // Счётчики обращений к кэшу с момента запуска
 }
 
 // NewBusinessLogic создает и инициализирует объект BusinessLogic с переданными зависимостями.
 // logger: экземпляр интерфейса logger.Logger для логирования действий.
 // sqlite: экземпляр интерфейса database.Database для работы с базой данных.
 // youTubeService: экземпляр интерфейса youtubeclient.YouTubeClient для взаимодействия с YouTube API.
-func NewBusinessLogic(logger logger.Logger, sqlite database.Database, youTubeService youtubeclient.YouTubeClient) *BusinessLogic {
+// transforms: преобразования (например, наложение логотипа), применяемые к обложкам перед возвратом.
+// fetchWorkers: количество параллельных загрузок в конвейере и слотов загрузки с YouTube (0 — по умолчанию).
+func NewBusinessLogic(logger logger.Logger, sqlite database.Database, youTubeService youtubeclient.YouTubeClient, transforms []imaging.Transform, fetchWorkers int) *BusinessLogic {
 	return &BusinessLogic{
 		Logger:         logger,
 		Sqlite:         sqlite,
 		YouTubeService: youTubeService,
+		Transforms:     transforms,
+		FetchWorkers:   fetchWorkers,
+		Lanes:          NewFetchScheduler(fetchWorkers, nil),
 	}
 }
 
 // ProcessData управляет обработкой списка ссылок. Если флаг "flag" установлен, данные обрабатываются асинхронно.
-// Возвращает массив фотографий или ошибку.
-func (bl *BusinessLogic) ProcessData(flag bool, links []string) ([][]byte, error) {
-	bl.Logger.Info("Starting data processing", zap.Bool("Async", flag), zap.Int("Links count", len(links)))
-	if flag {
-		return bl.processAsync(links)
-	} else {
-		return bl.process(links)
+// Загрузки с YouTube ждут слота в полосе options.Priority (пустой приоритет — PriorityInteractive).
+// Если ctx отменяется раньше, чем обработаны все ссылки (например, истёк срок вызова), возвращаются
+// готовые результаты, а необработанные ссылки получают ошибку ctx.Err(). С options.Background
+// они продолжают загружаться в фоне, иначе новые загрузки не начинаются. Истёкший срок вызова
+// не прерывает и асинхронный запрос: готовые результаты возвращаются вместе с отметками.
+// Возвращает результаты обработки в порядке переданных ссылок или ошибку.
+func (bl *BusinessLogic) ProcessData(ctx context.Context, flag bool, links []string, options ProcessOptions) ([]LinkResult, error) {
+	priority, err := resolvePriority(options.Priority, PriorityInteractive)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// processAsync обрабатывает ссылки в асинхронном режиме с использованием горутин и каналов.
-func (bl *BusinessLogic) processAsync(links []string) ([][]byte, error) {
-	bl.Logger.Info("Starting asynchronous processing of links")
-	ch := make(chan []byte, len(links))
-	errCh := make(chan error, len(links))
+	bl.Logger.Info("Starting data processing", zap.Bool("Async", flag), zap.Int("Links count", len(links)), zap.String("Priority", priority))
 
-	var wg sync.WaitGroup
-	for _, link := range links {
-		wg.Add(1)
-		go func(link string) {
-			defer wg.Done()
-			bl.Logger.Info("Processing link in goroutine", zap.String("Link", link))
-			photo, err := bl.getPhotoOrFetch(link)
-			if err != nil {
-				bl.Logger.Error("Error in goroutine", zap.String("Link", link), zap.Error(err))
-				errCh <- err
-				return
-			}
-			ch <- photo
-		}(link)
+	workCtx := ctx
+	if options.Background {
+		workCtx = context.WithoutCancel(ctx)
 	}
-
+	var mu sync.Mutex
+	results := make([]LinkResult, len(links))
+	finished := make([]bool, len(links))
+	done := make(chan struct{})
 	go func() {
-		wg.Wait()
-		close(ch)
-		close(errCh)
+		defer close(done)
+		bl.processLinks(workCtx, flag, links, priority, func(i int, result LinkResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			results[i], finished[i] = result, true
+		})
 	}()
 
-	var photos [][]byte
-	for {
-		select {
-		case photo, ok := <-ch:
-			if !ok {
-				ch = nil
-			} else {
-				bl.Logger.Info("Photo successfully processed in goroutine")
-				photos = append(photos, photo)
+	select {
+	case <-done:
+		// Обработка могла завершиться по отмене ctx, не начав часть ссылок: они получают ту же
+		// отметку ctx.Err(), что и при возврате по сроку, а не пустой результат
+		for i := range links {
+			if !finished[i] {
+				results[i] = LinkResult{Link: links[i], Err: ctx.Err()}
 			}
-		case err, ok := <-errCh:
-			if !ok {
-				errCh = nil
-			} else {
-				bl.Logger.Error("Error during asynchronous processing", zap.Error(err))
-				return nil, err
+		}
+		// В асинхронном режиме ошибка любой ссылки прерывает весь запрос; ссылки, не успевшие
+		// к сроку вызова, не считаются ошибкой и возвращаются с отметкой вместе с готовыми результатами
+		if flag {
+			for _, result := range results {
+				if result.Err != nil && !errors.Is(result.Err, context.DeadlineExceeded) {
+					bl.Logger.Error("Error during asynchronous processing", zap.Error(result.Err))
+					return nil, result.Err
+				}
 			}
 		}
-		if ch == nil && errCh == nil {
-			break
+	case <-ctx.Done():
+		mu.Lock()
+		partial := make([]LinkResult, len(links))
+		pending := 0
+		for i := range links {
+			if finished[i] {
+				partial[i] = results[i]
+				continue
+			}
+			partial[i] = LinkResult{Link: links[i], Err: ctx.Err()}
+			pending++
 		}
+		mu.Unlock()
+		results = partial
+		bl.Logger.Warn("Request context done, returning partial results", zap.Int("Pending", pending),
+			zap.Bool("Background", options.Background), zap.Error(ctx.Err()))
 	}
 
-	bl.Logger.Info("Asynchronous processing completed")
-	return photos, nil
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		if len(bl.Transforms) > 0 {
+			results[i].Photo = bl.renderVariant(results[i].Link, results[i].Photo)
+			results[i].ContentHash = ""
+		}
+		if results[i].ContentHash == "" {
+			results[i].ContentHash = contentHash(results[i].Photo)
+		}
+	}
+	return results, nil
 }
 
-// process обрабатывает ссылки в синхронном режиме.
-func (bl *BusinessLogic) process(links []string) ([][]byte, error) {
-	bl.Logger.Info("Starting synchronous processing of links")
-	var photos [][]byte
-	for _, link := range links {
+// processLinks обрабатывает ссылки последовательно или (если async установлен) параллельно через
+// ограниченный конвейер загрузки и передаёт результат каждой ссылки в record вместе с её индексом.
+// Ошибки отдельных ссылок не прерывают обработку. После отмены ctx новые ссылки не начинаются.
+func (bl *BusinessLogic) processLinks(ctx context.Context, async bool, links []string, priority string, record func(i int, result LinkResult)) {
+	process := func(i int, link string) {
 		bl.Logger.Info("Processing link", zap.String("Link", link))
-		photo, err := bl.getPhotoOrFetch(link)
+		resource, err := bl.getPhotoOrFetch(ctx, link, priority)
 		if err != nil {
 			bl.Logger.Error("Error processing link", zap.String("Link", link), zap.Error(err))
-			continue
+			record(i, LinkResult{Link: link, Err: err})
+			return
 		}
-		if photo != nil {
-			bl.Logger.Info("Photo successfully loaded", zap.String("Link", link))
-			photos = append(photos, photo)
+		bl.Logger.Info("Photo successfully loaded", zap.String("Link", link))
+		record(i, newLinkResult(link, resource))
+	}
+
+	if async {
+		bl.Logger.Info("Starting asynchronous processing of links", zap.Int("Workers", bl.FetchWorkers))
+		fetchPipeline(ctx, links, bl.FetchWorkers, process)
+		bl.Logger.Info("Asynchronous processing completed")
+		return
+	}
+	bl.Logger.Info("Starting synchronous processing of links")
+	for i, link := range links {
+		if ctx.Err() != nil {
+			break
 		}
+		process(i, link)
 	}
 	bl.Logger.Info("Synchronous processing completed")
-	return photos, nil
+}
+
+// FindSimilar ищет закэшированные обложки, похожие на обложку указанной ссылки.
+// Если ссылка пуста, в качестве образца используется переданный перцептивный хеш.
+// Сама ссылка-образец в результат не включается.
+func (bl *BusinessLogic) FindSimilar(link string, hash uint64, maxDistance int, limit int) ([]database.SimilarResource, error) {
+	if link != "" {
+		resource, err := bl.getPhotoOrFetch(context.Background(), link, PriorityInteractive)
+		if err != nil {
+			return nil, err
+		}
+		// Записи, закэшированные до появления характеристик, дополняются при обращении
+		if !resource.HasFeatures {
+			if err := bl.backfillResource(resource); err != nil {
+				return nil, fmt.Errorf("perceptual hash is not available for link %s: %w", link, err)
+			}
+		}
+		hash = resource.PHash
+	}
+
+	bl.Logger.Info("Searching for similar thumbnails", zap.Uint64("phash", hash), zap.Int("maxDistance", maxDistance))
+	// Запрашиваем на одну запись больше, чтобы после исключения образца осталось limit результатов
+	queryLimit := limit
+	if link != "" && limit > 0 {
+		queryLimit++
+	}
+	similar, err := bl.Sqlite.FindSimilar(hash, maxDistance, queryLimit)
+	if err != nil {
+		bl.Logger.Error("Error searching for similar thumbnails", zap.Error(err))
+		return nil, err
+	}
+
+	filtered := make([]database.SimilarResource, 0, len(similar))
+	for _, item := range similar {
+		if item.URL == link {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+	return filtered, nil
+}
+
+// GetPlaceholders возвращает заглушки для уже закэшированных обложек.
+// Обложки не загружаются с YouTube: ссылки, отсутствующие в кэше, просто не попадают в результат.
+func (bl *BusinessLogic) GetPlaceholders(links []string) ([]database.Placeholder, error) {
+	bl.Logger.Info("Loading placeholders", zap.Int("Links count", len(links)))
+	placeholders, err := bl.Sqlite.GetPlaceholders(links)
+	if err != nil {
+		bl.Logger.Error("Error loading placeholders", zap.Error(err))
+		return nil, err
+	}
+	return placeholders, nil
+}
+
+// ErrDuplicateVideo возвращается, если контактный лист содержит одно видео несколько раз:
+// карта плиток адресуется идентификатором видео.
+var ErrDuplicateVideo = errors.New("duplicate video in contact sheet")
+
+// BuildContactSheet собирает обложки указанных видео в одну мозаику.
+// Обложки получаются через кэш (getPhotoOrFetch); ссылки, которые не удалось обработать или декодировать,
+// пропускаются и возвращаются в списке Failed. Повторяющиеся видео (в том числе по разным
+// ссылкам на одно видео) отклоняются с ErrDuplicateVideo до загрузки обложек.
+func (bl *BusinessLogic) BuildContactSheet(links []string, options ContactSheetOptions) (*ContactSheet, error) {
+	bl.Logger.Info("Building contact sheet", zap.Int("Links count", len(links)), zap.Int("Columns", options.Layout.Columns))
+	background := color.RGBA{A: 255}
+	if options.Background != "" {
+		parsed, err := imaging.ParseHexColor(options.Background)
+		if err != nil {
+			return nil, err
+		}
+		background = parsed
+	}
+	seen := make(map[string]string, len(links))
+	for _, link := range links {
+		videoID, err := youtubeclient.ExtractVideoID(link)
+		if err != nil {
+			continue
+		}
+		if previous, ok := seen[videoID]; ok {
+			return nil, fmt.Errorf("%w: %s and %s both refer to %s", ErrDuplicateVideo, previous, link, videoID)
+		}
+		seen[videoID] = link
+	}
+
+	results := make([]LinkResult, len(links))
+	bl.processLinks(context.Background(), false, links, PriorityInteractive, func(i int, result LinkResult) {
+		results[i] = result
+	})
+
+	sheet := &ContactSheet{Tiles: make(map[string]image.Rectangle)}
+	var images []image.Image
+	var videoIDs []string
+	for _, result := range results {
+		if result.Err != nil {
+			sheet.Failed = append(sheet.Failed, result.Link)
+			continue
+		}
+		videoID, err := youtubeclient.ExtractVideoID(result.Link)
+		if err != nil {
+			bl.Logger.Warn("Failed to extract video ID for contact sheet", zap.String("Link", result.Link), zap.Error(err))
+			sheet.Failed = append(sheet.Failed, result.Link)
+			continue
+		}
+		img, err := imaging.Decode(result.Photo)
+		if err != nil {
+			bl.Logger.Warn("Failed to decode thumbnail for contact sheet", zap.String("Link", result.Link), zap.Error(err))
+			sheet.Failed = append(sheet.Failed, result.Link)
+			continue
+		}
+		images = append(images, img)
+		videoIDs = append(videoIDs, videoID)
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no thumbnails available for contact sheet")
+	}
+
+	mosaic, tiles := imaging.Mosaic(images, options.Layout, background)
+	for i, videoID := range videoIDs {
+		sheet.Tiles[videoID] = tiles[i]
+	}
+	encoded, err := imaging.Encode(mosaic, options.Format)
+	if err != nil {
+		return nil, err
+	}
+	sheet.Image = encoded
+	sheet.Width, sheet.Height = mosaic.Bounds().Dx(), mosaic.Bounds().Dy()
+
+	bl.Logger.Info("Contact sheet built", zap.Int("Tiles", len(images)), zap.Int("Failed", len(sheet.Failed)))
+	return sheet, nil
 }
 
 // getPhotoOrFetch проверяет наличие фотографии в базе данных и возвращает её.
-// Если фото отсутствует, обращается к YouTubeService и сохраняет результат в базе.
-func (bl *BusinessLogic) getPhotoOrFetch(link string) ([]byte, error) {
+// Если фото отсутствует, обращается к YouTubeService, вычисляет характеристики изображения
+// и сохраняет результат в базе. Каждое обращение учитывается в счётчиках попаданий, промахов и ошибок.
+func (bl *BusinessLogic) getPhotoOrFetch(ctx context.Context, link, priority string) (*database.Resource, error) {
 	bl.Logger.Info("Checking photo in the database", zap.String("Link", link))
+	bl.stats.recordRequest(link)
 	// Проверяем наличие в базе и возвращаем фото, если оно есть
-	photo, err := bl.Sqlite.GetPhotoByUrl(link)
+	resource, err := bl.Sqlite.GetResourceByUrl(link)
 	if err != nil {
 		bl.Logger.Error("Error checking photo in the database", zap.String("Link", link), zap.Error(err))
+		bl.stats.errors.Add(1)
 		return nil, err
 	}
-	if photo != nil {
+	if resource != nil && resource.Photo != nil {
 		bl.Logger.Info("Photo found in the database", zap.String("Link", link))
-		return photo, nil
+		bl.stats.hits.Add(1)
+		return resource, nil
 	}
 
 	bl.Logger.Info("Photo not found in the database, fetching from YouTube API", zap.String("Link", link))
-	// Если в базе нет, обращаемся к YouTube-клиенту
-	photo, err = bl.YouTubeService.FetchThumbnail(link)
+	resource, err = bl.fetchAndStore(ctx, link, priority)
+	if err != nil {
+		bl.stats.errors.Add(1)
+		return nil, err
+	}
+	bl.stats.misses.Add(1)
+	return resource, nil
+}
+
+// fetchAndStore загружает обложку через YouTubeService, вычисляет характеристики изображения
+// и сохраняет результат в базе, заменяя прежнюю запись, если она была.
+// Перед загрузкой ждёт слот в полосе priority; ожидание прерывается отменой ctx.
+func (bl *BusinessLogic) fetchAndStore(ctx context.Context, link, priority string) (*database.Resource, error) {
+	if err := bl.Lanes.Acquire(ctx, priority); err != nil {
+		return nil, err
+	}
+	// Токен берётся уже со слотом, чтобы очередь приоритетов определяла и порядок запросов к YouTube
+	if err := bl.Limiter.Wait(ctx); err != nil {
+		bl.Lanes.Release()
+		return nil, err
+	}
+	photo, err := bl.YouTubeService.FetchThumbnail(link)
+	bl.Lanes.Release()
 	if err != nil {
 		bl.Logger.Error("Error fetching from YouTube API", zap.String("Link", link), zap.Error(err))
 		return nil, err
 	}
 
+	resource := &database.Resource{URL: link, Photo: photo}
+	// Вычисляем характеристики изображения один раз перед сохранением
+	if err := applyFeatures(resource); err != nil {
+		bl.Logger.Warn("Failed to analyze thumbnail", zap.String("Link", link), zap.Error(err))
+	}
+
 	// Сохраняем фото в базу
 	bl.Logger.Info("Saving photo to the database", zap.String("Link", link))
-	err = bl.Sqlite.InsertResource(link, photo)
+	err = bl.Sqlite.InsertResource(resource)
 	if err != nil {
 		bl.Logger.Error("Error saving photo to the database for link", zap.String("Link", link), zap.Error(err))
 	}
 
 	bl.Logger.Info("Photo successfully processed and saved", zap.String("Link", link))
-	return photo, nil
+	return resource, nil
+}
+
+// warmLink загружает обложку в кэш, если её там ещё нет. В отличие от getPhotoOrFetch не читает
+// содержимое из базы и не учитывается в счётчиках обращений.
+// Возвращает true, если обложка уже была в кэше.
+func (bl *BusinessLogic) warmLink(ctx context.Context, link, priority string) (bool, error) {
+	exists, err := bl.Sqlite.ResourceExists(link)
+	if err != nil {
+		bl.Logger.Error("Error checking photo in the database", zap.String("Link", link), zap.Error(err))
+		return false, err
+	}
+	if exists {
+		return true, nil
+	}
+	_, err = bl.fetchAndStore(ctx, link, priority)
+	return false, err
+}
+
+// GetCacheStats возвращает статистику кэша: объём и количество записей из базы,
+// счётчики попаданий, промахов и ошибок с момента запуска и topN самых запрашиваемых видео.
+// Если перед базой стоит кэш в памяти, добавляются попадания и промахи по его уровням.
+func (bl *BusinessLogic) GetCacheStats(topN int) (*CacheStats, error) {
+	dbStats, err := bl.Sqlite.GetCacheStats()
+	if err != nil {
+		bl.Logger.Error("Error loading cache stats", zap.Error(err))
+		return nil, err
+	}
+	stats := &CacheStats{
+		CacheStats: *dbStats,
+		Hits:       bl.stats.hits.Load(),
+		Misses:     bl.stats.misses.Load(),
+		Errors:     bl.stats.errors.Load(),
+		TopVideos:  bl.stats.topVideos(topN),
+	}
+	if cache, ok := bl.Sqlite.(memoryCacheStats); ok {
+		stats.Tiers = cache.Stats().Tiers
+	}
+	return stats, nil
+}
+
+// ListEntries возвращает страницу записей кэша для администрирования.
+func (bl *BusinessLogic) ListEntries(filter database.EntryFilter, after string, limit int) ([]database.Entry, error) {
+	return bl.Sqlite.ListEntries(filter, after, limit)
+}
+
+// GetEntry возвращает метаданные записи кэша по ссылке. Возвращает nil, если записи нет.
+func (bl *BusinessLogic) GetEntry(link string) (*database.Entry, error) {
+	return bl.Sqlite.GetEntry(link)
+}
+
+// PurgeEntries удаляет записи кэша, подходящие под фильтр, и возвращает их ссылки.
+func (bl *BusinessLogic) PurgeEntries(filter database.EntryFilter) ([]string, error) {
+	bl.Logger.Info("Purging cache entries", zap.Int("Links", len(filter.URLs)), zap.String("VideoIDPrefix", filter.VideoIDPrefix))
+	return bl.Sqlite.PurgeEntries(filter)
+}
+
+// RefreshEntries принудительно загружает заново обложки по ссылкам из фильтра; список длиннее
+// maxRefreshEntries отклоняется с ErrTooManyLinks. Если ссылки не указаны, обновляются закэшированные записи, подходящие под фильтр, в порядке ссылок
+// после after, но не более maxRefreshEntries за вызов; следующую порцию обновляет вызов
+// с возвращённой ссылкой продолжения. Загрузки идут параллельно в полосе PriorityBulk.
+// Ошибки отдельных ссылок не прерывают обновление и сохраняются в результатах; ссылки,
+// не обработанные к отмене ctx, возвращаются с ошибкой ctx.
+// Возвращает результаты, ссылку продолжения (пусто — записей больше нет) и ошибку.
+func (bl *BusinessLogic) RefreshEntries(ctx context.Context, filter database.EntryFilter, after string) ([]LinkResult, string, error) {
+	links, next := filter.URLs, ""
+	if len(links) > maxRefreshEntries {
+		return nil, "", fmt.Errorf("%w: %d, at most %d allowed", ErrTooManyLinks, len(links), maxRefreshEntries)
+	}
+	if len(links) == 0 {
+		// Запрашиваем на одну запись больше, чтобы понять, остались ли записи после порции
+		entries, err := bl.Sqlite.ListEntries(filter, after, maxRefreshEntries+1)
+		if err != nil {
+			bl.Logger.Error("Error listing entries to refresh", zap.Error(err))
+			return nil, "", err
+		}
+		if len(entries) > maxRefreshEntries {
+			entries = entries[:maxRefreshEntries]
+			next = entries[maxRefreshEntries-1].URL
+		}
+		for _, entry := range entries {
+			links = append(links, entry.URL)
+		}
+	}
+
+	bl.Logger.Info("Refreshing cache entries", zap.Int("Links", len(links)))
+	results := make([]LinkResult, len(links))
+	processed := make([]bool, len(links))
+	fetchPipeline(ctx, links, bl.FetchWorkers, func(i int, link string) {
+		resource, err := bl.fetchAndStore(ctx, link, PriorityBulk)
+		if err != nil {
+			results[i] = LinkResult{Link: link, Err: err}
+		} else {
+			results[i] = newLinkResult(link, resource)
+		}
+		processed[i] = true
+	})
+	for i, link := range links {
+		if !processed[i] {
+			results[i] = LinkResult{Link: link, Err: ctx.Err()}
+		}
+	}
+	return results, next, nil
+}
+
+// renderVariant применяет цепочку преобразований к обложке и возвращает производный вариант.
+// Варианты кэшируются в отдельной таблице по ключу преобразований, исходное изображение не изменяется.
+// При ошибке преобразования возвращается исходное изображение.
+func (bl *BusinessLogic) renderVariant(link string, photo []byte) []byte {
+	if len(bl.Transforms) == 0 {
+		return photo
+	}
+	variantKey := imaging.VariantKey(bl.Transforms)
+
+	variant, err := bl.Sqlite.GetVariant(link, variantKey)
+	if err != nil {
+		bl.Logger.Error("Error checking variant in the database", zap.String("Link", link), zap.Error(err))
+	} else if variant != nil {
+		return variant
+	}
+
+	img, err := imaging.Decode(photo)
+	if err != nil {
+		bl.Logger.Warn("Failed to decode thumbnail for transforms, returning original", zap.String("Link", link), zap.Error(err))
+		return photo
+	}
+	variant, err = imaging.Encode(imaging.ApplyTransforms(img, bl.Transforms), imaging.FormatJPEG)
+	if err != nil {
+		bl.Logger.Warn("Failed to encode transformed thumbnail, returning original", zap.String("Link", link), zap.Error(err))
+		return photo
+	}
+
+	if err := bl.Sqlite.InsertVariant(link, variantKey, variant); err != nil {
+		bl.Logger.Error("Error saving variant to the database", zap.String("Link", link), zap.Error(err))
+	}
+	return variant
+}
+
+// newLinkResult формирует успешный результат обработки ссылки из ресурса.
+func newLinkResult(link string, resource *database.Resource) LinkResult {
+	return LinkResult{
+		Link:          link,
+		Photo:         resource.Photo,
+		PHash:         resource.PHash,
+		DominantColor: resource.DominantColor,
+		BlurHash:      resource.BlurHash,
+		ContentHash:   resource.ContentHash,
+	}
+}
+
+// contentHash вычисляет SHA-256 содержимого в шестнадцатеричном виде.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
 /*
@@ -156,14 +523,71 @@ NewBusinessLogic создает новый экземпляр BusinessLogic с 
 logger: экземпляр интерфейса logger.Logger для логирования действий.
 sqlite: экземпляр интерфейса database.Database для работы с базой данных.
 youTubeService: экземпляр интерфейса youtubeclient.YouTubeClient для взаимодействия с YouTube API.
+transforms: преобразования, применяемые к обложкам перед возвратом.
+fetchWorkers: количество параллельных загрузок в конвейере и слотов загрузки с YouTube.
 
 ProcessData управляет обработкой списка ссылок. Если флаг "flag" установлен, данные обрабатываются асинхронно.
-Возвращает массив фотографий или ошибку.
+ctx: контекст запроса; после его отмены возвращаются готовые результаты.
+options: приоритет загрузок и продолжение в фоне после отмены ctx.
+Возвращает результаты обработки в порядке переданных ссылок или ошибку.
+
+processLinks обрабатывает ссылки последовательно или параллельно и передаёт результаты в record.
+ctx: контекст; после отмены новые ссылки не начинаются.
+async: параллельная обработка через конвейер.
+links: ссылки на видео.
+priority: приоритет загрузок.
+record: получатель результата ссылки по её индексу.
 
-processAsync обрабатывает ссылки в асинхронном режиме с использованием горутин и каналов.
+FindSimilar ищет закэшированные обложки, похожие на обложку указанной ссылки.
+Если ссылка пуста, в качестве образца используется переданный перцептивный хеш.
 
-process обрабатывает ссылки в синхронном режиме.
+GetPlaceholders возвращает заглушки для уже закэшированных обложек.
+
+BuildContactSheet собирает обложки указанных видео в одну мозаику.
+Ссылки, которые не удалось обработать или декодировать, пропускаются и возвращаются в списке Failed.
+Повторяющиеся видео отклоняются с ErrDuplicateVideo.
 
 getPhotoOrFetch проверяет наличие фотографии в базе данных и возвращает её.
-Если фото отсутствует, обращается к YouTubeService и сохраняет результат в базе.
+Если фото отсутствует, обращается к YouTubeService, вычисляет характеристики изображения
+и сохраняет результат в базе.
+Учитывает обращение в счётчиках попаданий, промахов и ошибок.
+
+fetchAndStore загружает обложку через YouTubeService, вычисляет характеристики изображения
+и сохраняет результат в базе, заменяя прежнюю запись.
+ctx: контекст ожидания слота загрузки.
+link: ссылка на видео.
+priority: приоритет загрузки.
+
+warmLink загружает обложку в кэш, если её там ещё нет.
+ctx: контекст ожидания слота загрузки.
+link: ссылка на видео.
+priority: приоритет загрузки.
+Возвращает true, если обложка уже была в кэше.
+
+GetCacheStats возвращает статистику кэша и счётчики обращений с момента запуска.
+topN: количество самых запрашиваемых видео в ответе.
+
+ListEntries возвращает страницу записей кэша для администрирования.
+filter: условия отбора записей.
+after: URL последней записи предыдущей страницы.
+limit: размер страницы.
+
+GetEntry возвращает метаданные записи кэша по ссылке.
+link: ссылка на видео.
+
+PurgeEntries удаляет записи кэша, подходящие под фильтр, и возвращает их ссылки.
+filter: условия отбора записей.
+
+RefreshEntries принудительно загружает заново обложки по ссылкам из фильтра
+или по закэшированным записям, подходящим под фильтр, порциями не более maxRefreshEntries.
+ctx: контекст запроса; после его отмены новые загрузки не начинаются.
+filter: условия отбора записей.
+after: ссылка последней записи предыдущей порции (пусто — с начала).
+
+renderVariant применяет цепочку преобразований к обложке и возвращает производный вариант.
+Варианты кэшируются по ключу преобразований, исходное изображение не изменяется.
+
+newLinkResult формирует успешный результат обработки ссылки из ресурса.
+
+contentHash вычисляет SHA-256 содержимого в шестнадцатеричном виде.
 */
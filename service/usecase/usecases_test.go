@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"shelon_server/imaging"
+	database "shelon_server/integrations/SQLLite"
+)
+
+// TestBuildContactSheet проверяет карту плиток и отказ от повторяющихся видео,
+// которые перезаписали бы плитки друг друга.
+func TestBuildContactSheet(t *testing.T) {
+	logic, youtube := newTestLogic(t, "test_contact_sheet.db")
+	for _, link := range []string{"https://youtu.be/first", "https://youtu.be/second"} {
+		if err := logic.Sqlite.InsertResource(&database.Resource{URL: link, Photo: testJPEG(t, false)}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+	options := ContactSheetOptions{Layout: imaging.GridLayout{Columns: 2, TileWidth: 32, TileHeight: 18}, Format: imaging.FormatPNG}
+
+	sheet, err := logic.BuildContactSheet([]string{"https://youtu.be/first", "https://youtu.be/second", "https://youtu.be/broken"}, options)
+	if err != nil {
+		t.Fatalf("Failed to build contact sheet: %v", err)
+	}
+	if len(sheet.Tiles) != 2 || sheet.Tiles["second"].Min.X != 32 || len(sheet.Failed) != 1 {
+		t.Errorf("Unexpected contact sheet: tiles %v, failed %v", sheet.Tiles, sheet.Failed)
+	}
+
+	fetches := youtube.fetches.Load()
+	_, err = logic.BuildContactSheet([]string{"https://youtu.be/first", "https://www.youtube.com/watch?v=first"}, options)
+	if !errors.Is(err, ErrDuplicateVideo) {
+		t.Errorf("Expected ErrDuplicateVideo, got %v", err)
+	}
+	if youtube.fetches.Load() != fetches {
+		t.Error("Expected duplicates to be rejected before fetching thumbnails")
+	}
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+	"shelon_server/integrations/webhook"
+
+	"go.uber.org/zap"
+)
+
+// JobFinishedEvent — тип уведомления о завершении задания (заголовок X-Webhook-Event).
+const JobFinishedEvent = "job.finished"
+
+// jobWebhookPayload — тело уведомления о завершении задания.
+type jobWebhookPayload struct {
+	Event      string           `json:"event"`
+	JobID      string           `json:"job_id"`
+	Kind       string           `json:"kind"`
+	State      string           `json:"state"`
+	Total      int              `json:"total"`
+	Fetched    int              `json:"fetched"`
+	Cached     int              `json:"cached"`
+	Failed     int              `json:"failed"`
+	CreatedAt  time.Time        `json:"created_at"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Links      []jobWebhookLink `json:"links"`
+}
+
+// jobWebhookLink — результат обработки одной ссылки в уведомлении.
+type jobWebhookLink struct {
+	Link   string `json:"link"`
+	Status string `json:"status"` // pending (задание отменено до обработки), fetched, cached или failed
+	Size   int64  `json:"size,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// deliverWebhook отправляет уведомление о завершении задания в фоне, если у задания
+// задан адрес уведомления. Каждая попытка доставки сохраняется в хранилище заданий.
+func (jm *JobManager) deliverWebhook(job Job) {
+	if job.CallbackURL == "" || jm.Webhooks == nil {
+		return
+	}
+	go func() {
+		body, err := jm.webhookPayload(job)
+		if err != nil {
+			jm.Logger.Error("Failed to build job webhook", zap.String("JobID", job.ID), zap.Error(err))
+			return
+		}
+		err = jm.Webhooks.Send(context.Background(), job.CallbackURL, JobFinishedEvent, body, func(attempt webhook.Attempt) {
+			delivery := database.WebhookDelivery{
+				JobID:       job.ID,
+				Attempt:     attempt.Number,
+				AttemptedAt: attempt.Time,
+				StatusCode:  attempt.StatusCode,
+			}
+			if attempt.Err != nil {
+				delivery.Error = attempt.Err.Error()
+			}
+			if err := jm.Store.RecordWebhookDelivery(delivery); err != nil {
+				jm.Logger.Warn("Failed to record webhook delivery", zap.String("JobID", job.ID), zap.Error(err))
+			}
+		})
+		if err != nil {
+			jm.Logger.Error("Job webhook was not delivered", zap.String("JobID", job.ID), zap.String("URL", job.CallbackURL), zap.Error(err))
+		}
+	}()
+}
+
+// webhookPayload собирает JSON-тело уведомления: итог задания и результат каждой ссылки.
+func (jm *JobManager) webhookPayload(job Job) ([]byte, error) {
+	links, err := jm.Store.JobLinks(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	payload := jobWebhookPayload{
+		Event:      JobFinishedEvent,
+		JobID:      job.ID,
+		Kind:       job.Kind,
+		State:      job.State,
+		Total:      job.Total,
+		Fetched:    job.Fetched,
+		Cached:     job.Cached,
+		Failed:     job.Failed,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		Links:      make([]jobWebhookLink, 0, len(links)),
+	}
+	if !job.StartedAt.IsZero() {
+		payload.StartedAt = &job.StartedAt
+	}
+	for _, link := range links {
+		payload.Links = append(payload.Links, jobWebhookLink{Link: link.URL, Status: link.Status, Size: link.Size, Error: link.Error})
+	}
+	return json.Marshal(payload)
+}
+
+/*
+deliverWebhook отправляет уведомление о завершении задания в фоне.
+job: снимок завершённого задания.
+
+webhookPayload собирает JSON-тело уведомления: итог задания и результат каждой ссылки.
+job: снимок завершённого задания.
+*/
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	database "shelon_server/integrations/SQLLite"
+	"shelon_server/integrations/webhook"
+)
+
+// TestJobWebhook проверяет уведомление о завершении задания: подпись, содержимое,
+// повтор после ошибки получателя и журнал попыток доставки.
+func TestJobWebhook(t *testing.T) {
+	logic, _ := newTestLogic(t, "test_job_webhook.db")
+	if err := logic.Sqlite.InsertResource(&database.Resource{URL: "https://youtu.be/cached", Photo: []byte("cached")}); err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+
+	var requests atomic.Int32
+	payloads := make(chan jobWebhookPayload, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify([]byte("secret"), body, r.Header.Get(webhook.TimestampHeader), r.Header.Get(webhook.SignatureHeader), webhook.DefaultTolerance) {
+			t.Errorf("Invalid webhook signature")
+		}
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload jobWebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Invalid webhook payload: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer receiver.Close()
+
+	manager := newTestJobManager(logic, 1)
+	if _, err := manager.SubmitJob([]string{"https://youtu.be/a"}, JobOptions{CallbackURL: receiver.URL}); !errors.Is(err, ErrWebhooksDisabled) {
+		t.Errorf("Expected ErrWebhooksDisabled, got %v", err)
+	}
+	manager.Webhooks = webhook.NewSender(&MockLogger{}, "secret", 3, time.Millisecond, time.Second, true)
+	if _, err := manager.SubmitJob([]string{"https://youtu.be/a"}, JobOptions{CallbackURL: "not a url"}); !errors.Is(err, webhook.ErrInvalidURL) {
+		t.Errorf("Expected ErrInvalidURL, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Run(ctx)
+	job, err := manager.SubmitJob([]string{"https://youtu.be/new", "https://youtu.be/cached", "https://youtu.be/broken"}, JobOptions{CallbackURL: receiver.URL})
+	if err != nil {
+		t.Fatalf("Failed to queue job: %v", err)
+	}
+
+	var payload jobWebhookPayload
+	select {
+	case payload = <-payloads:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Webhook was not delivered")
+	}
+	if payload.Event != JobFinishedEvent || payload.JobID != job.ID || payload.State != JobCompleted || payload.Fetched != 1 || payload.Cached != 1 || payload.Failed != 1 {
+		t.Errorf("Unexpected payload summary: %+v", payload)
+	}
+	expected := []jobWebhookLink{
+		{Link: "https://youtu.be/new", Status: database.JobLinkFetched, Size: int64(len("image of https://youtu.be/new"))},
+		{Link: "https://youtu.be/cached", Status: database.JobLinkCached, Size: int64(len("cached"))},
+		{Link: "https://youtu.be/broken", Status: database.JobLinkFailed, Error: "thumbnail not found"},
+	}
+	if len(payload.Links) != len(expected) {
+		t.Fatalf("Expected %d links, got %+v", len(expected), payload.Links)
+	}
+	for i := range expected {
+		if payload.Links[i] != expected[i] {
+			t.Errorf("Link %d: expected %+v, got %+v", i, expected[i], payload.Links[i])
+		}
+	}
+
+	// Обе попытки сохраняются в журнале доставки
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stored, err := manager.GetJob(job.ID)
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if len(stored.Deliveries) == 2 {
+			if stored.Deliveries[0].StatusCode != http.StatusInternalServerError || stored.Deliveries[1].StatusCode != http.StatusOK || stored.Deliveries[1].Error != "" {
+				t.Errorf("Unexpected deliveries: %+v", stored.Deliveries)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 recorded deliveries, got %+v", stored.Deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
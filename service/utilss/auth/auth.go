@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Области доступа клиентов.
+const (
+	ScopeRead  = "read"  // Загрузка обложек и чтение статистики (TransportService).
+	ScopeWarm  = "warm"  // Фоновые задания и предварительная загрузка (JobService).
+	ScopeAdmin = "admin" // Администрирование кэша (AdminService).
+)
+
+// Имена клиентов, не описанных API-ключами.
+const (
+	AnonymousClient = "anonymous" // Клиент без учётных данных, когда разрешены анонимные вызовы.
+	AdminClient     = "admin"     // Клиент с административным токеном.
+)
+
+// jwtLeeway — допустимое расхождение часов при проверке сроков действия токена JWT.
+const jwtLeeway = 30 * time.Second
+
+// Ошибки проверки учётных данных.
+var (
+	ErrMissingCredentials = errors.New("credentials are missing")
+	ErrInvalidCredentials = errors.New("credentials are invalid")
+	ErrPermissionDenied   = errors.New("permission denied")
+)
+
+// knownScopes перечисляет допустимые области доступа.
+var knownScopes = []string{ScopeRead, ScopeWarm, ScopeAdmin}
+
+// Identity описывает клиента, предъявившего учётные данные.
+type Identity struct {
+	Client string   // Имя клиента: из конфигурации ключа или claim "sub" токена JWT.
+	Scopes []string // Области доступа клиента.
+}
+
+// HasScope сообщает, есть ли у клиента область доступа scope.
+func (id *Identity) HasScope(scope string) bool {
+	return slices.Contains(id.Scopes, scope)
+}
+
+// APIKey описывает API-ключ клиента.
+type APIKey struct {
+	Key    string   // Значение ключа.
+	Client string   // Имя клиента, которому выдан ключ.
+	Scopes []string // Области доступа клиента.
+}
+
+// JWTOptions задает проверку токенов JWT, подписанных HMAC-SHA256.
+type JWTOptions struct {
+	Secret   string // Общий секрет подписи (пусто — токены JWT не принимаются).
+	Issuer   string // Ожидаемый claim "iss" (пусто — не проверяется).
+	Audience string // Ожидаемый claim "aud" (пусто — не проверяется).
+}
+
+// tokenClaims — claims токена JWT: области доступа передаются в "scope" через пробел.
+type tokenClaims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator проверяет API-ключи и токены JWT и решает, разрешён ли вызов с областью доступа.
+// По умолчанию учётные данные нужны для всех вызовов. Если анонимные вызовы явно разрешены,
+// вызовы без учётных данных получают области read и warm; для области admin учётные данные
+// нужны всегда.
+type Authenticator struct {
+	keys      map[[sha256.Size]byte]*Identity
+	jwtSecret []byte
+	parser    *jwt.Parser
+	required  bool
+}
+
+// NewAuthenticator создает проверку учётных данных.
+// keys: API-ключи клиентов.
+// jwtOptions: проверка токенов JWT.
+// adminToken: административный токен со всеми областями доступа (пусто — не принимается).
+// allowAnonymous: разрешить вызовы без учётных данных с областями read и warm.
+// Возвращает ошибку, если ключ или имя клиента пусты, ключи повторяются или указана неизвестная область доступа.
+func NewAuthenticator(keys []APIKey, jwtOptions JWTOptions, adminToken string, allowAnonymous bool) (*Authenticator, error) {
+	a := &Authenticator{
+		keys:     make(map[[sha256.Size]byte]*Identity, len(keys)+1),
+		required: !allowAnonymous,
+	}
+	if adminToken != "" {
+		keys = append([]APIKey{{Key: adminToken, Client: AdminClient, Scopes: knownScopes}}, keys...)
+	}
+	for _, key := range keys {
+		switch {
+		case key.Key == "":
+			return nil, fmt.Errorf("api key of client %q is empty", key.Client)
+		case key.Client == "":
+			return nil, errors.New("api key client must not be empty")
+		}
+		if err := validateScopes(key.Scopes); err != nil {
+			return nil, fmt.Errorf("api key of client %q: %w", key.Client, err)
+		}
+		// Ключи хранятся по хешу: поиск по нему не раскрывает значение ключа через время ответа
+		hash := sha256.Sum256([]byte(key.Key))
+		if other, found := a.keys[hash]; found {
+			return nil, fmt.Errorf("api key of client %q duplicates the key of client %q", key.Client, other.Client)
+		}
+		a.keys[hash] = &Identity{Client: key.Client, Scopes: key.Scopes}
+	}
+	if jwtOptions.Secret != "" {
+		a.jwtSecret = []byte(jwtOptions.Secret)
+		options := []jwt.ParserOption{
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(jwtLeeway),
+		}
+		if jwtOptions.Issuer != "" {
+			options = append(options, jwt.WithIssuer(jwtOptions.Issuer))
+		}
+		if jwtOptions.Audience != "" {
+			options = append(options, jwt.WithAudience(jwtOptions.Audience))
+		}
+		a.parser = jwt.NewParser(options...)
+	}
+	return a, nil
+}
+
+// Required сообщает, обязательны ли учётные данные для всех вызовов.
+func (a *Authenticator) Required() bool {
+	return a.required
+}
+
+// HasCredentials сообщает, задан ли хотя бы один способ предъявить учётные данные:
+// API-ключ, административный токен или секрет JWT.
+func (a *Authenticator) HasCredentials() bool {
+	return len(a.keys) > 0 || a.parser != nil
+}
+
+// Authorize проверяет учётные данные и наличие у клиента области доступа scope.
+// token: API-ключ или токен JWT (пусто — учётные данные не переданы).
+// scope: область доступа, требуемая вызовом.
+// Возвращает клиента или ошибку ErrMissingCredentials, ErrInvalidCredentials, ErrPermissionDenied.
+func (a *Authenticator) Authorize(token, scope string) (*Identity, error) {
+	if token == "" {
+		if a.Required() || scope == ScopeAdmin {
+			return nil, ErrMissingCredentials
+		}
+		return &Identity{Client: AnonymousClient, Scopes: []string{ScopeRead, ScopeWarm}}, nil
+	}
+	identity, err := a.authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.HasScope(scope) {
+		return nil, fmt.Errorf("%w: client %q lacks scope %q", ErrPermissionDenied, identity.Client, scope)
+	}
+	return identity, nil
+}
+
+// authenticate определяет клиента по API-ключу или токену JWT.
+func (a *Authenticator) authenticate(token string) (*Identity, error) {
+	if identity, found := a.keys[sha256.Sum256([]byte(token))]; found {
+		return identity, nil
+	}
+	if a.parser == nil || strings.Count(token, ".") != 2 {
+		return nil, ErrInvalidCredentials
+	}
+	var claims tokenClaims
+	_, err := a.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.jwtSecret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	return &Identity{Client: claims.Subject, Scopes: strings.Fields(claims.Scope)}, nil
+}
+
+// TokenFromHeaders извлекает учётные данные из значений заголовков (или метаданных gRPC)
+// "authorization" в виде "Bearer <token>" и "x-api-key". Пустая строка без ошибки означает,
+// что учётные данные не переданы; заголовок authorization с другой схемой считается ошибкой.
+func TokenFromHeaders(authorization, apiKey []string) (string, error) {
+	if len(authorization) > 0 {
+		token, found := strings.CutPrefix(authorization[0], "Bearer ")
+		if !found || token == "" {
+			return "", fmt.Errorf("%w: authorization must use the Bearer scheme", ErrInvalidCredentials)
+		}
+		return token, nil
+	}
+	if len(apiKey) > 0 {
+		if apiKey[0] == "" {
+			return "", fmt.Errorf("%w: empty api key", ErrInvalidCredentials)
+		}
+		return apiKey[0], nil
+	}
+	return "", nil
+}
+
+// validateScopes проверяет, что все области доступа известны.
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(knownScopes, scope) {
+			return fmt.Errorf("unknown scope %q (expected one of %s)", scope, strings.Join(knownScopes, ", "))
+		}
+	}
+	return nil
+}
+
+// identityKey — ключ клиента в контексте запроса.
+type identityKey struct{}
+
+// NewContext возвращает контекст, содержащий клиента вызова.
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext возвращает клиента вызова из контекста, если он там есть.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+/*
+HasScope сообщает, есть ли у клиента область доступа scope.
+scope: область доступа.
+
+NewAuthenticator создает проверку учётных данных.
+keys: API-ключи клиентов.
+jwtOptions: проверка токенов JWT.
+adminToken: административный токен со всеми областями доступа.
+allowAnonymous: разрешить вызовы без учётных данных.
+
+Required сообщает, обязательны ли учётные данные для всех вызовов.
+
+HasCredentials сообщает, задан ли хотя бы один способ предъявить учётные данные.
+
+Authorize проверяет учётные данные и наличие у клиента области доступа scope.
+token: API-ключ или токен JWT.
+scope: область доступа, требуемая вызовом.
+
+authenticate определяет клиента по API-ключу или токену JWT.
+token: API-ключ или токен JWT.
+
+TokenFromHeaders извлекает учётные данные из заголовков authorization и x-api-key.
+authorization: значения заголовка authorization.
+apiKey: значения заголовка x-api-key.
+
+validateScopes проверяет, что все области доступа известны.
+scopes: области доступа.
+
+NewContext возвращает контекст, содержащий клиента вызова.
+ctx: контекст запроса.
+identity: клиент вызова.
+
+FromContext возвращает клиента вызова из контекста.
+ctx: контекст запроса.
+*/
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signToken подписывает токен JWT с заданными claims секретом secret.
+func signToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+// TestAuthorizeAnonymous проверяет, что без явного разрешения вызовы без учётных данных отклоняются,
+// даже если не задано ни одного ключа.
+func TestAuthorizeAnonymous(t *testing.T) {
+	closed, err := NewAuthenticator(nil, JWTOptions{}, "", false)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if !closed.Required() || closed.HasCredentials() {
+		t.Errorf("Expected credentials to be required and none configured, got %v, %v", closed.Required(), closed.HasCredentials())
+	}
+	for _, scope := range []string{ScopeRead, ScopeWarm, ScopeAdmin} {
+		if _, err := closed.Authorize("", scope); !errors.Is(err, ErrMissingCredentials) {
+			t.Errorf("Expected %s call without credentials to be rejected, got %v", scope, err)
+		}
+	}
+
+	// Разрешённые анонимные вызовы не мешают проверке предъявленных ключей
+	open, err := NewAuthenticator([]APIKey{{Key: "reader-key", Client: "reader", Scopes: []string{ScopeRead}}}, JWTOptions{}, "", true)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if identity, err := open.Authorize("", ScopeRead); err != nil || identity.Client != AnonymousClient {
+		t.Errorf("Expected anonymous read call to pass, got %v, %v", identity, err)
+	}
+	if _, err := open.Authorize("stolen", ScopeRead); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected unknown key to be rejected, got %v", err)
+	}
+}
+
+// TestAuthorizeAPIKeys проверяет клиентов и области доступа API-ключей и вызовы без учётных данных.
+func TestAuthorizeAPIKeys(t *testing.T) {
+	open, err := NewAuthenticator(nil, JWTOptions{}, "secret", true)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if open.Required() || !open.HasCredentials() {
+		t.Error("Expected admin token with anonymous access not to require credentials")
+	}
+	if identity, err := open.Authorize("", ScopeWarm); err != nil || identity.Client != AnonymousClient {
+		t.Errorf("Expected anonymous warm call to pass, got %v, %v", identity, err)
+	}
+	if _, err := open.Authorize("", ScopeAdmin); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("Expected admin scope to require credentials, got %v", err)
+	}
+	if identity, err := open.Authorize("secret", ScopeAdmin); err != nil || identity.Client != AdminClient {
+		t.Errorf("Expected admin token to grant admin scope, got %v, %v", identity, err)
+	}
+
+	keyed, err := NewAuthenticator([]APIKey{{Key: "reader-key", Client: "reader", Scopes: []string{ScopeRead}}}, JWTOptions{}, "", false)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		scope string
+		err   error
+	}{
+		{"missing", "", ScopeRead, ErrMissingCredentials},
+		{"unknown key", "stolen", ScopeRead, ErrInvalidCredentials},
+		{"granted scope", "reader-key", ScopeRead, nil},
+		{"missing scope", "reader-key", ScopeWarm, ErrPermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := keyed.Authorize(tt.token, tt.scope); !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestAuthorizeJWT проверяет подпись, сроки, издателя, аудиторию и области доступа токенов JWT.
+func TestAuthorizeJWT(t *testing.T) {
+	authenticator, err := NewAuthenticator(nil, JWTOptions{Secret: "jwt-secret", Issuer: "issuer", Audience: "thumbnails"}, "", false)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if !authenticator.Required() || !authenticator.HasCredentials() {
+		t.Error("Expected JWT secret to be accepted as credentials")
+	}
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		base := jwt.MapClaims{
+			"sub":   "dashboard",
+			"scope": "read warm",
+			"iss":   "issuer",
+			"aud":   "thumbnails",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(base, name)
+			} else {
+				base[name] = value
+			}
+		}
+		return base
+	}
+
+	identity, err := authenticator.Authorize(signToken(t, "jwt-secret", claims(nil)), ScopeWarm)
+	if err != nil || identity.Client != "dashboard" {
+		t.Fatalf("Expected valid token to pass, got %v, %v", identity, err)
+	}
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("Failed to create unsigned token: %v", err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		scope string
+		err   error
+	}{
+		{"missing scope", signToken(t, "jwt-secret", claims(nil)), ScopeAdmin, ErrPermissionDenied},
+		{"wrong secret", signToken(t, "other-secret", claims(nil)), ScopeRead, ErrInvalidCredentials},
+		{"unsigned", unsigned, ScopeRead, ErrInvalidCredentials},
+		{"expired", signToken(t, "jwt-secret", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), ScopeRead, ErrInvalidCredentials},
+		{"without expiry", signToken(t, "jwt-secret", claims(jwt.MapClaims{"exp": nil})), ScopeRead, ErrInvalidCredentials},
+		{"wrong issuer", signToken(t, "jwt-secret", claims(jwt.MapClaims{"iss": "someone"})), ScopeRead, ErrInvalidCredentials},
+		{"wrong audience", signToken(t, "jwt-secret", claims(jwt.MapClaims{"aud": "billing"})), ScopeRead, ErrInvalidCredentials},
+		{"without subject", signToken(t, "jwt-secret", claims(jwt.MapClaims{"sub": nil})), ScopeRead, ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := authenticator.Authorize(tt.token, tt.scope); !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestNewAuthenticatorErrors проверяет отказ от некорректных API-ключей.
+func TestNewAuthenticatorErrors(t *testing.T) {
+	tests := map[string][]APIKey{
+		"empty key":     {{Client: "reader", Scopes: []string{ScopeRead}}},
+		"empty client":  {{Key: "key", Scopes: []string{ScopeRead}}},
+		"unknown scope": {{Key: "key", Client: "reader", Scopes: []string{"write"}}},
+		"duplicate key": {{Key: "key", Client: "a"}, {Key: "key", Client: "b"}},
+		"admin token":   {{Key: "secret", Client: "reader"}},
+	}
+	for name, keys := range tests {
+		if _, err := NewAuthenticator(keys, JWTOptions{}, "secret", false); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestTokenFromHeaders проверяет извлечение учётных данных из заголовков.
+func TestTokenFromHeaders(t *testing.T) {
+	if token, err := TokenFromHeaders([]string{"Bearer abc"}, nil); err != nil || token != "abc" {
+		t.Errorf("Expected bearer token, got %q, %v", token, err)
+	}
+	if token, err := TokenFromHeaders(nil, []string{"key"}); err != nil || token != "key" {
+		t.Errorf("Expected api key, got %q, %v", token, err)
+	}
+	if token, err := TokenFromHeaders(nil, nil); err != nil || token != "" {
+		t.Errorf("Expected no credentials, got %q, %v", token, err)
+	}
+	if _, err := TokenFromHeaders([]string{"Basic abc"}, nil); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected non-bearer scheme to be rejected, got %v", err)
+	}
+}
@@ -10,16 +10,175 @@ type Config struct {
 	Database          DatabaseConfig      `json:"database"`
 	YoutubeClient     YouTubeClientConfig `json:"youtubeClient"`
 	GRPCServerAddress string              `json:"grpcServerAddress"`
+	HTTP              HTTPConfig          `json:"http"`
+	GRPCWeb           GRPCWebConfig       `json:"grpcWeb"`
+	TLS               TLSConfig           `json:"tls"`
+	Overlay           OverlayConfig       `json:"overlay"`
+	Admin             AdminConfig         `json:"admin"`
+	Auth              AuthConfig          `json:"auth"`
+	Jobs              JobsConfig          `json:"jobs"`
+	Webhooks          WebhooksConfig      `json:"webhooks"`
+	Refresh           RefreshConfig       `json:"refresh"`
 }
 
 type DatabaseConfig struct {
-	DataSourceName string `json:"dataSourceName"`
+	Driver         string   `json:"driver"`         // Реляционная база: "sqlite" (по умолчанию) или "postgres"
+	DataSourceName string   `json:"dataSourceName"` // Имя файла SQLite или строка подключения PostgreSQL
+	Backend        string   `json:"backend"`        // Хранилище изображений: "sqlite"/"database" (по умолчанию), "filesystem" или "s3"
+	BlobDir        string   `json:"blobDir"`        // Каталог для изображений при backend = "filesystem"
+	S3             S3Config `json:"s3"`             // Бакет для изображений при backend = "s3"
+	// PreviousBackend — хранилище, из которого изображения переносятся после смены backend
+	// (пусто — переноса нет); параметры берутся из тех же blobDir и s3
+	PreviousBackend string `json:"previousBackend"`
+	// MemoryCacheBytes ограничивает объём LRU-кэша в памяти перед базой (0 — кэш отключён)
+	MemoryCacheBytes int64 `json:"memoryCacheBytes"`
+	// Параметры подключения; незаданные значения берутся по умолчанию (WAL, 5 с, NORMAL)
+	JournalMode            string `json:"journalMode"`            // Режим журнала SQLite
+	BusyTimeoutMs          int    `json:"busyTimeoutMs"`          // Ожидание снятия блокировки SQLite, мс
+	Synchronous            string `json:"synchronous"`            // Уровень синхронизации SQLite
+	MaxOpenConns           int    `json:"maxOpenConns"`           // Максимум открытых соединений
+	MaxIdleConns           int    `json:"maxIdleConns"`           // Максимум простаивающих соединений
+	ConnMaxLifetimeSeconds int    `json:"connMaxLifetimeSeconds"` // Время жизни соединения, с
+	PreparedStatements     *bool  `json:"preparedStatements"`     // Подготовленные запросы (по умолчанию включены)
+}
+
+// S3Config задает бакет S3-совместимого хранилища для изображений.
+type S3Config struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	Prefix          string `json:"prefix"`
 }
 
 type YouTubeClientConfig struct {
 	BaseURL      string `json:"baseUrl"`
 	ClientID     string `json:"clientId"`
 	ClientSecret string `json:"clientSecret"`
+	// RequestsPerSecond ограничивает среднюю частоту запросов к YouTube (0 — без ограничения)
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Burst — сколько запросов можно выполнить подряд без ожидания (по умолчанию 1)
+	Burst int `json:"burst"`
+}
+
+// HTTPConfig задает HTTP-шлюз для браузеров и инструментов без gRPC.
+// Если адрес не задан, шлюз не запускается.
+type HTTPConfig struct {
+	Address            string `json:"address"`            // Адрес HTTP-сервера, например ":8080"
+	CacheMaxAgeSeconds int    `json:"cacheMaxAgeSeconds"` // max-age в Cache-Control обложек, с (по умолчанию 86400)
+	// SignedURLs включает выдачу подписанных ссылок; если заданы ключи, обложки отдаются только по ним
+	SignedURLs SignedURLsConfig `json:"signedUrls"`
+}
+
+// SignedURLsConfig задает подпись ссылок на обложки в HTTP-шлюзе.
+// Новые ссылки подписываются первым ключом, принимаются ссылки любого из ключей.
+type SignedURLsConfig struct {
+	Keys              []SigningKeyConfig `json:"keys"`
+	PublicURL         string             `json:"publicUrl"`         // Внешний адрес шлюза для выдаваемых ссылок, например "https://cdn.example.com"
+	DefaultTTLSeconds int                `json:"defaultTtlSeconds"` // Срок действия ссылки по умолчанию, с (по умолчанию 3600)
+	MaxTTLSeconds     int                `json:"maxTtlSeconds"`     // Максимальный срок действия, с (по умолчанию 604800)
+}
+
+// SigningKeyConfig задает ключ подписи ссылок.
+type SigningKeyConfig struct {
+	ID     string `json:"id"`     // Идентификатор ключа, передаваемый в ссылке
+	Secret string `json:"secret"` // Секрет HMAC-SHA256
+}
+
+// TLSConfig задает TLS для gRPC-сервера. Если сертификат не задан, соединения не шифруются.
+// Изменённые файлы сертификатов подхватываются без перезапуска.
+type TLSConfig struct {
+	CertFile              string `json:"certFile"`              // Сертификат сервера (PEM), включая промежуточные
+	KeyFile               string `json:"keyFile"`               // Закрытый ключ сервера (PEM)
+	ClientCAFile          string `json:"clientCaFile"`          // Сертификаты УЦ клиентов; если задан, клиент обязан предъявить сертификат (mTLS)
+	ReloadIntervalSeconds int    `json:"reloadIntervalSeconds"` // Период проверки файлов на изменение, с (по умолчанию 30)
+}
+
+// GRPCWebConfig задает шлюз gRPC-Web для вызова сервисов из браузера по HTTP/1.1.
+// Если адрес не задан, шлюз не запускается.
+type GRPCWebConfig struct {
+	Address        string   `json:"address"`        // Адрес шлюза, например ":8081"
+	AllowedOrigins []string `json:"allowedOrigins"` // Источники страниц, которым разрешены вызовы ("*" — любые)
+}
+
+// OverlayConfig задает наложение логотипа или плашки на возвращаемые обложки.
+type OverlayConfig struct {
+	Enabled   bool     `json:"enabled"`
+	ImagePath string   `json:"imagePath"`
+	Position  string   `json:"position"` // top-left, top-right, bottom-left, bottom-right, center
+	Opacity   *float64 `json:"opacity"`  // Непрозрачность в диапазоне (0, 1] (по умолчанию 1)
+	Margin    int      `json:"margin"`
+}
+
+// AdminConfig задает доступ к сервису администрирования кэша.
+// Токен действует как API-ключ со всеми областями доступа. Если ни токен, ни учётные данные
+// клиентов (auth) не заданы, сервис администрирования не регистрируется.
+type AdminConfig struct {
+	Token string `json:"token"`
+}
+
+// AuthConfig задает учётные данные клиентов gRPC-сервера и HTTP-шлюза: API-ключи и токены JWT
+// с областями доступа read, warm и admin. Вызовы без учётных данных отклоняются, пока не
+// установлен AllowAnonymous; AdminService требует учётных данных всегда.
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `json:"apiKeys"`
+	JWT     JWTConfig      `json:"jwt"`
+	// AllowAnonymous разрешает вызовы без учётных данных с областями read и warm (по умолчанию выключено)
+	AllowAnonymous bool `json:"allowAnonymous"`
+}
+
+// APIKeyConfig описывает API-ключ клиента.
+type APIKeyConfig struct {
+	Key    string   `json:"key"`
+	Client string   `json:"client"` // Имя клиента в журналах
+	Scopes []string `json:"scopes"` // Области доступа: read, warm, admin
+}
+
+// JWTConfig задает проверку токенов JWT (HS256). Клиент берётся из claim "sub",
+// области доступа — из claim "scope" через пробел; claim "exp" обязателен.
+type JWTConfig struct {
+	Secret   string `json:"secret"`   // Секрет подписи (пусто — токены JWT не принимаются)
+	Issuer   string `json:"issuer"`   // Ожидаемый claim "iss" (пусто — не проверяется)
+	Audience string `json:"audience"` // Ожидаемый claim "aud" (пусто — не проверяется)
+}
+
+// JobsConfig задает конвейер загрузки обложек и очередь фоновых заданий.
+// Нулевые значения заменяются значениями по умолчанию.
+type JobsConfig struct {
+	FetchWorkers int `json:"fetchWorkers"` // Параллельные загрузки с YouTube (по умолчанию 8)
+	QueueSize    int `json:"queueSize"`    // Сколько заданий может ждать в очереди (по умолчанию 16)
+	MaxLinks     int `json:"maxLinks"`     // Максимум ссылок в одном задании (по умолчанию 100000)
+	// LaneWeights — веса очередей "interactive" и "bulk" при выдаче слотов загрузки (по умолчанию 8 и 1)
+	LaneWeights map[string]int `json:"laneWeights"`
+}
+
+// WebhooksConfig задает доставку уведомлений о завершении заданий.
+// Если секрет не задан, задания с адресом уведомления отклоняются.
+type WebhooksConfig struct {
+	Secret           string `json:"secret"`           // Ключ HMAC-SHA256 для подписи уведомлений
+	MaxAttempts      int    `json:"maxAttempts"`      // Максимум попыток доставки (по умолчанию 5)
+	InitialBackoffMs int    `json:"initialBackoffMs"` // Пауза перед второй попыткой, мс (по умолчанию 1000); далее удваивается
+	TimeoutSeconds   int    `json:"timeoutSeconds"`   // Ограничение времени одной попытки, с (по умолчанию 10)
+	// AllowPrivateNetworks разрешает уведомления на адреса loopback, частных и link-local сетей
+	// (например, для локальной разработки). По умолчанию такие адреса отклоняются.
+	AllowPrivateNetworks bool `json:"allowPrivateNetworks"`
+}
+
+// RefreshConfig задает плановое обновление закэшированных обложек.
+// Если Enabled не установлен, расписания не запускаются.
+type RefreshConfig struct {
+	Enabled   bool                    `json:"enabled"`
+	Schedules []RefreshScheduleConfig `json:"schedules"`
+}
+
+// RefreshScheduleConfig задает одно расписание обновления.
+type RefreshScheduleConfig struct {
+	Name        string `json:"name"`        // Имя для логов
+	Cron        string `json:"cron"`        // Cron-выражение из пяти полей или @hourly, @daily и т.п. (время сервера)
+	Source      string `json:"source"`      // "popular" — самые запрашиваемые видео, "stale" — самые давно загруженные записи
+	Limit       int    `json:"limit"`       // Максимум записей за запуск
+	MaxAgeHours int    `json:"maxAgeHours"` // Для "stale": обновляются записи старше этого возраста, ч
 }
 
 func LoadConfig(filePath string) (*Config, error) {
@@ -0,0 +1,53 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNext проверяет ближайшие срабатывания типичных расписаний.
+func TestNext(t *testing.T) {
+	// Среда, 15 января 2025, 10:07:30
+	from := time.Date(2025, time.January, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.January, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.January, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"30 3 * * mon-fri", time.Date(2025, time.January, 16, 3, 30, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2025, time.January, 19, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 mar *", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2025, time.January, 15, 10, 25, 0, 0, time.UTC)},
+		{"0 9 1,20 * *", time.Date(2025, time.January, 20, 9, 0, 0, 0, time.UTC)},
+		// День месяца или день недели: ближайшая пятница раньше 1-го числа
+		{"0 0 1 * fri", time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expression)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expression, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(tt.expected) {
+			t.Errorf("Next(%q) = %v, expected %v", tt.expression, next, tt.expected)
+		}
+	}
+
+	schedule, _ := Parse("0 0 30 2 *")
+	if next := schedule.Next(from); !next.IsZero() {
+		t.Errorf("Expected no run for February 30, got %v", next)
+	}
+}
+
+// TestParseErrors проверяет отклонение некорректных выражений.
+func TestParseErrors(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "* * * * funday", "@often"} {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("Expected error for %q", expression)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	pb "shelon_server/proto"
+	"shelon_server/utilss/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serviceScopes сопоставляет префиксы полных имён методов сервисов требуемым областям доступа.
+var serviceScopes = map[string]string{
+	"/" + pb.TransportService_ServiceDesc.ServiceName + "/": auth.ScopeRead,
+	"/" + pb.JobService_ServiceDesc.ServiceName + "/":       auth.ScopeWarm,
+	"/" + pb.AdminService_ServiceDesc.ServiceName + "/":     auth.ScopeAdmin,
+}
+
+// authInterceptor проверяет учётные данные вызова и область доступа, требуемую сервисом.
+// API-ключ или токен JWT передаётся в метаданных как "authorization: Bearer <token>"
+// или "x-api-key: <key>". Клиент вызова доступен обработчику через auth.FromContext.
+func authInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity, err := authorizeCall(ctx, authenticator, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(auth.NewContext(ctx, identity), req)
+	}
+}
+
+// authStreamInterceptor проверяет учётные данные и область доступа для потоковых вызовов.
+func authStreamInterceptor(authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		identity, err := authorizeCall(ss.Context(), authenticator, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityServerStream{ServerStream: ss, ctx: auth.NewContext(ss.Context(), identity)})
+	}
+}
+
+// identityServerStream — серверный поток, контекст которого содержит клиента вызова.
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает контекст потока с клиентом вызова.
+func (s *identityServerStream) Context() context.Context { return s.ctx }
+
+// authorizeCall определяет клиента вызова method по метаданным запроса и проверяет его область доступа.
+// Методы сервисов без заданной области доступа (например, служебных) пропускаются без проверки.
+// Возвращает ошибку со статусом Unauthenticated или PermissionDenied.
+func authorizeCall(ctx context.Context, authenticator *auth.Authenticator, method string) (*auth.Identity, error) {
+	scope, found := methodScope(method)
+	if !found {
+		return &auth.Identity{Client: auth.AnonymousClient}, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	token, err := auth.TokenFromHeaders(md.Get("authorization"), md.Get("x-api-key"))
+	if err == nil {
+		var identity *auth.Identity
+		if identity, err = authenticator.Authorize(token, scope); err == nil {
+			return identity, nil
+		}
+	}
+	if errors.Is(err, auth.ErrPermissionDenied) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil, status.Error(codes.Unauthenticated, err.Error())
+}
+
+// methodScope возвращает область доступа, требуемую методом.
+func methodScope(method string) (string, bool) {
+	for prefix, scope := range serviceScopes {
+		if strings.HasPrefix(method, prefix) {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+/*
+authInterceptor проверяет учётные данные вызова и область доступа, требуемую сервисом.
+authenticator: проверка API-ключей и токенов JWT.
+
+authStreamInterceptor проверяет учётные данные и область доступа для потоковых вызовов.
+authenticator: проверка API-ключей и токенов JWT.
+
+Context возвращает контекст потока с клиентом вызова.
+
+authorizeCall определяет клиента вызова по метаданным запроса и проверяет его область доступа.
+ctx: контекст запроса.
+authenticator: проверка API-ключей и токенов JWT.
+method: полное имя метода.
+
+methodScope возвращает область доступа, требуемую методом.
+method: полное имя метода.
+*/
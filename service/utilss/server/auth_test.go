@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"shelon_server/utilss/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newTestAuthenticator создает проверку учётных данных с API-ключами и административным токеном.
+// Без API-ключей анонимные вызовы разрешены.
+func newTestAuthenticator(t *testing.T, keys []auth.APIKey, adminToken string) *auth.Authenticator {
+	t.Helper()
+	authenticator, err := auth.NewAuthenticator(keys, auth.JWTOptions{}, adminToken, len(keys) == 0)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	return authenticator
+}
+
+// withMetadata возвращает контекст входящего вызова с метаданными.
+func withMetadata(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+// TestAdminAuthInterceptor проверяет, что без ключей клиентов токен требуется только для методов AdminService.
+func TestAdminAuthInterceptor(t *testing.T) {
+	interceptor := authInterceptor(newTestAuthenticator(t, nil, "secret"))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	withToken := func(value string) context.Context { return withMetadata("authorization", value) }
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+	}{
+		{"public method without token", context.Background(), "/transport.TransportService/SendData", codes.OK},
+		{"admin method without token", context.Background(), "/transport.AdminService/ListEntries", codes.Unauthenticated},
+		{"admin method with wrong token", withToken("Bearer wrong"), "/transport.AdminService/PurgeEntries", codes.Unauthenticated},
+		{"admin method without bearer scheme", withToken("secret"), "/transport.AdminService/PurgeEntries", codes.Unauthenticated},
+		{"admin method with token", withToken("Bearer secret"), "/transport.AdminService/GetEntry", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.code {
+				t.Errorf("Expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+
+	// Пустой токен в конфигурации не открывает доступ
+	_, err := authInterceptor(newTestAuthenticator(t, nil, ""))(withToken("Bearer "), nil, &grpc.UnaryServerInfo{FullMethod: "/transport.AdminService/GetEntry"}, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected empty token to be rejected, got %v", err)
+	}
+}
+
+// TestAuthInterceptorScopes проверяет, что при заданных API-ключах вызов любого сервиса требует
+// ключ с областью доступа этого сервиса, а обработчик получает клиента вызова.
+func TestAuthInterceptorScopes(t *testing.T) {
+	interceptor := authInterceptor(newTestAuthenticator(t, []auth.APIKey{
+		{Key: "reader-key", Client: "reader", Scopes: []string{auth.ScopeRead}},
+		{Key: "warmer-key", Client: "warmer", Scopes: []string{auth.ScopeRead, auth.ScopeWarm}},
+	}, "secret"))
+	var client string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		identity, _ := auth.FromContext(ctx)
+		client = identity.Client
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+		client string
+	}{
+		{"without credentials", context.Background(), "/transport.TransportService/SendData", codes.Unauthenticated, ""},
+		{"unknown key", withMetadata("x-api-key", "stolen"), "/transport.TransportService/SendData", codes.Unauthenticated, ""},
+		{"read scope", withMetadata("x-api-key", "reader-key"), "/transport.TransportService/SendData", codes.OK, "reader"},
+		{"read scope for jobs", withMetadata("x-api-key", "reader-key"), "/transport.JobService/SubmitJob", codes.PermissionDenied, ""},
+		{"warm scope for jobs", withMetadata("authorization", "Bearer warmer-key"), "/transport.JobService/SubmitJob", codes.OK, "warmer"},
+		{"warm scope for admin", withMetadata("authorization", "Bearer warmer-key"), "/transport.AdminService/GetEntry", codes.PermissionDenied, ""},
+		{"admin token", withMetadata("authorization", "Bearer secret"), "/transport.JobService/ListJobs", codes.OK, auth.AdminClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client = ""
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.code || client != tt.client {
+				t.Errorf("Expected %v for client %q, got %v for client %q", tt.code, tt.client, err, client)
+			}
+		})
+	}
+}
+
+// fakeServerStream — заглушка серверного потока с заданным контекстом.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context { return f.ctx }
+
+// TestAdminAuthStreamInterceptor проверяет, что токен требуется и для потоковых методов AdminService.
+func TestAdminAuthStreamInterceptor(t *testing.T) {
+	interceptor := authStreamInterceptor(newTestAuthenticator(t, nil, "secret"))
+	handler := func(srv interface{}, stream grpc.ServerStream) error { return nil }
+	withToken := withMetadata("authorization", "Bearer secret")
+
+	err := interceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/transport.AdminService/ExportCache"}, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected stream without token to be rejected, got %v", err)
+	}
+	err = interceptor(nil, &fakeServerStream{ctx: withToken}, &grpc.StreamServerInfo{FullMethod: "/transport.AdminService/ImportCache"}, handler)
+	if err != nil {
+		t.Errorf("Expected stream with token to pass, got %v", err)
+	}
+}
@@ -2,40 +2,66 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
 	"net"
 
 	pb "shelon_server/proto"
 	"shelon_server/transport"
+	"shelon_server/utilss/auth"
 	"shelon_server/utilss/logger"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // GRPCServer представляет реализацию gRPC сервера.
 type GRPCServer struct {
-	logger           logger.Logger
-	server           *grpc.Server
-	port             string
-	transportService *transport.TransportService
+	logger logger.Logger
+	server *grpc.Server
+	port   string
 }
 
-// NewGRPCServer создает новый экземпляр GRPC-сервера с предоставленными зависимостями.
+// NewGRPCServer создает новый экземпляр GRPC-сервера с предоставленными зависимостями и регистрирует сервисы.
 // port: порт, на котором будет слушать сервер.
 // logger: экземпляр интерфейса logger.Logger для логирования действий.
 // transportService: экземпляр транспортного сервиса для обработки данных.
-func NewGRPCServer(port string, logger logger.Logger, transportService *transport.TransportService) *GRPCServer {
-	logger.Info("Creating new instance of GRPC server")
+// adminService: сервис администрирования кэша (nil — не регистрируется).
+// jobService: сервис фоновых заданий (nil — не регистрируется).
+// authenticator: проверка учётных данных и областей доступа клиентов.
+// tlsConfig: конфигурация TLS (nil — соединения без шифрования).
+func NewGRPCServer(port string, logger logger.Logger, transportService *transport.TransportService, adminService *transport.AdminService, jobService *transport.JobService, authenticator *auth.Authenticator, tlsConfig *tls.Config) *GRPCServer {
+	logger.Info("Creating new instance of GRPC server", zap.Bool("tls", tlsConfig != nil))
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(authInterceptor(authenticator)),
+		grpc.ChainStreamInterceptor(authStreamInterceptor(authenticator)),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(options...)
+
+	// Сервисы регистрируются сразу, чтобы их видел и шлюз gRPC-Web, оборачивающий этот сервер
+	logger.Info("Registering TransportService in gRPC server")
+	pb.RegisterTransportServiceServer(server, transportService)
+	logger.Info("TransportService successfully registered")
+	if adminService != nil {
+		pb.RegisterAdminServiceServer(server, adminService)
+		logger.Info("AdminService successfully registered")
+	}
+	if jobService != nil {
+		pb.RegisterJobServiceServer(server, jobService)
+		logger.Info("JobService successfully registered")
+	}
 	return &GRPCServer{
-		logger:           logger,
-		server:           grpc.NewServer(),
-		port:             port,
-		transportService: transportService,
+		logger: logger,
+		server: server,
+		port:   port,
 	}
 }
 
-// Start запускает gRPC сервер и регистрирует сервисы.
+// Start запускает gRPC сервер.
 // ctx: контекст выполнения.
 func (gs *GRPCServer) Start(ctx context.Context) error {
 	gs.logger.Info("Initializing listener on port", zap.String("port", gs.port))
@@ -46,11 +72,6 @@ func (gs *GRPCServer) Start(ctx context.Context) error {
 	}
 	gs.logger.Info("Listener successfully created on port", zap.String("port", gs.port))
 
-	// Регистрируем сервис TransportService
-	gs.logger.Info("Registering TransportService in gRPC server")
-	pb.RegisterTransportServiceServer(gs.server, gs.transportService)
-	gs.logger.Info("TransportService successfully registered")
-
 	// Запускаем сервер в отдельной горутине
 	go func() {
 		gs.logger.Info("Starting gRPC server")
@@ -77,12 +98,16 @@ func (gs *GRPCServer) Stop(ctx context.Context) error {
 }
 
 /*
-NewGRPCServer создает новый экземпляр GRPC-сервера с предоставленными зависимостями.
+NewGRPCServer создает новый экземпляр GRPC-сервера с предоставленными зависимостями и регистрирует сервисы.
 port: порт, на котором будет слушать сервер.
 logger: экземпляр интерфейса logger.Logger для логирования действий.
 transportService: экземпляр транспортного сервиса для обработки данных.
+adminService: сервис администрирования кэша (nil — не регистрируется).
+jobService: сервис фоновых заданий (nil — не регистрируется).
+authenticator: проверка учётных данных и областей доступа клиентов.
+tlsConfig: конфигурация TLS (nil — соединения без шифрования).
 
-Start запускает gRPC сервер и регистрирует сервисы.
+Start запускает gRPC сервер.
 ctx: контекст выполнения.
 
 Stop останавливает gRPC сервер.
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"shelon_server/utilss/logger"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCWebServer представляет HTTP/1.1-сервер, принимающий вызовы gRPC-Web из браузера.
+// Вызовы передаются в тот же grpc.Server, что обслуживает обычных клиентов, поэтому для них
+// действуют те же сервисы и перехватчики. Запросы с Origin, не входящим в список разрешённых,
+// отклоняются вместе с предварительными запросами CORS.
+// Если задана конфигурация TLS, шлюз принимает только соединения TLS, как и сам gRPC-сервер.
+type GRPCWebServer struct {
+	logger         logger.Logger
+	server         *http.Server
+	wrapped        *grpcweb.WrappedGrpcServer
+	allowedOrigins map[string]bool
+	anyOrigin      bool
+}
+
+// NewGRPCWebServer создает новый экземпляр шлюза gRPC-Web.
+// port: адрес, на котором будет слушать сервер.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// grpcServer: gRPC-сервер с зарегистрированными сервисами.
+// allowedOrigins: источники (scheme://host[:port]), которым разрешены вызовы из браузера; "*" разрешает любой.
+// tlsConfig: конфигурация TLS, общая с gRPC-сервером (nil — соединения без шифрования).
+func NewGRPCWebServer(port string, logger logger.Logger, grpcServer *GRPCServer, allowedOrigins []string, tlsConfig *tls.Config) *GRPCWebServer {
+	logger.Info("Creating new instance of gRPC-Web server", zap.Strings("allowedOrigins", allowedOrigins))
+	ws := &GRPCWebServer{
+		logger:         logger,
+		allowedOrigins: make(map[string]bool, len(allowedOrigins)),
+	}
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			ws.anyOrigin = true
+		}
+		ws.allowedOrigins[origin] = true
+	}
+	ws.wrapped = grpcweb.WrapServer(grpcServer.server, grpcweb.WithOriginFunc(ws.originAllowed))
+	ws.server = &http.Server{Addr: port, Handler: ws.Handler(), ReadHeaderTimeout: 10 * time.Second, TLSConfig: tlsConfig}
+	return ws
+}
+
+// Handler возвращает обработчик запросов gRPC-Web и предварительных запросов CORS.
+// Остальные запросы получают 404.
+func (ws *GRPCWebServer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ws.wrapped.IsGrpcWebRequest(r) && !ws.wrapped.IsAcceptableGrpcCorsRequest(r) {
+			writeHTTPError(w, status.Errorf(codes.NotFound, "%s %s is not a gRPC-Web call", r.Method, r.URL.Path))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !ws.originAllowed(origin) {
+			ws.logger.Warn("Rejected gRPC-Web request from disallowed origin", zap.String("origin", origin), zap.String("path", r.URL.Path))
+			writeHTTPError(w, status.Errorf(codes.PermissionDenied, "origin %q is not allowed", origin))
+			return
+		}
+		ws.wrapped.ServeHTTP(w, r)
+	})
+}
+
+// Start запускает шлюз gRPC-Web и блокирует выполнение до завершения контекста,
+// после чего дожидается обработки текущих запросов.
+// ctx: контекст выполнения.
+func (ws *GRPCWebServer) Start(ctx context.Context) error {
+	return serveHTTP(ctx, ws.logger, ws.server)
+}
+
+// Stop останавливает шлюз gRPC-Web, дожидаясь завершения текущих запросов.
+// ctx: контекст ожидания.
+func (ws *GRPCWebServer) Stop(ctx context.Context) error {
+	ws.logger.Info("Stopping gRPC-Web server")
+	if err := ws.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop gRPC-Web server: %w", err)
+	}
+	ws.logger.Info("gRPC-Web server successfully stopped")
+	return nil
+}
+
+// originAllowed сообщает, разрешены ли вызовы со страниц источника origin.
+func (ws *GRPCWebServer) originAllowed(origin string) bool {
+	return ws.anyOrigin || ws.allowedOrigins[origin]
+}
+
+/*
+NewGRPCWebServer создает новый экземпляр шлюза gRPC-Web.
+port: адрес, на котором будет слушать сервер.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+grpcServer: gRPC-сервер с зарегистрированными сервисами.
+allowedOrigins: источники, которым разрешены вызовы из браузера.
+tlsConfig: конфигурация TLS (nil — соединения без шифрования).
+
+Handler возвращает обработчик запросов gRPC-Web и предварительных запросов CORS.
+
+Start запускает шлюз gRPC-Web и блокирует выполнение до завершения контекста.
+ctx: контекст выполнения.
+
+Stop останавливает шлюз gRPC-Web, дожидаясь завершения текущих запросов.
+ctx: контекст ожидания.
+
+originAllowed сообщает, разрешены ли вызовы со страниц источника origin.
+origin: значение заголовка Origin.
+*/
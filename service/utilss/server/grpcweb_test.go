@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shelon_server/handlers"
+	pb "shelon_server/proto"
+	"shelon_server/transport"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebFrame кодирует сообщение в кадр gRPC-Web: флаг, длина и содержимое.
+func grpcWebFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+// readGRPCWebResponse разбирает тело ответа gRPC-Web на сообщения и трейлеры.
+// Статус вызова берётся из трейлеров, а если их нет (ответ только с заголовками) — из заголовков ответа.
+func readGRPCWebResponse(t *testing.T, resp *http.Response) ([][]byte, string) {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	var messages [][]byte
+	grpcStatus := resp.Header.Get("Grpc-Status")
+	for len(body) >= 5 {
+		flag, size := body[0], binary.BigEndian.Uint32(body[1:5])
+		if int(size) > len(body)-5 {
+			t.Fatalf("Truncated gRPC-Web frame")
+		}
+		payload := body[5 : 5+size]
+		body = body[5+size:]
+		if flag&0x80 == 0 {
+			messages = append(messages, payload)
+			continue
+		}
+		for _, line := range strings.Split(string(payload), "\r\n") {
+			if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "grpc-status") {
+				grpcStatus = strings.TrimSpace(value)
+			}
+		}
+	}
+	return messages, grpcStatus
+}
+
+// TestGRPCWebEndToEnd проверяет вызов TransportService кадрами gRPC-Web по HTTP/1.1,
+// предварительные запросы CORS и работу перехватчиков для вызовов из браузера.
+func TestGRPCWebEndToEnd(t *testing.T) {
+	dataHandler := handlers.NewDataHandler(&MockLogger{}, &fakeDataProcessor{photo: []byte("image")})
+	adminService := transport.NewAdminService(handlers.NewAdminHandler(&MockLogger{}, nil))
+	grpcServer := NewGRPCServer(":0", &MockLogger{}, transport.NewTransportService(dataHandler), adminService, nil, newTestAuthenticator(t, nil, "secret"), nil)
+	web := httptest.NewServer(NewGRPCWebServer(":0", &MockLogger{}, grpcServer, []string{"https://dashboard.example.com"}, nil).Handler())
+	defer web.Close()
+
+	call := func(method string, message proto.Message, origin string) *http.Response {
+		t.Helper()
+		payload, err := proto.Marshal(message)
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodPost, web.URL+method, bytes.NewReader(grpcWebFrame(0, payload)))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("X-Grpc-Web", "1")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := web.Client().Do(req)
+		if err != nil {
+			t.Fatalf("gRPC-Web call failed: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := call("/transport.TransportService/SendData", &pb.SendDataRequest{Links: []string{"https://youtu.be/a", "https://youtu.be/broken"}}, "https://dashboard.example.com")
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 1 || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc-web") {
+		t.Fatalf("Unexpected response: %s %s %s", resp.Proto, resp.Status, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" {
+		t.Errorf("Expected CORS header for allowed origin, got %v", resp.Header)
+	}
+	messages, grpcStatus := readGRPCWebResponse(t, resp)
+	if grpcStatus != "0" || len(messages) != 1 {
+		t.Fatalf("Expected one message with status 0, got %d messages, status %q", len(messages), grpcStatus)
+	}
+	var reply pb.SendDataResponse
+	if err := proto.Unmarshal(messages[0], &reply); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(reply.Images) != 1 || string(reply.Images[0]) != "image" || len(reply.Results) != 2 || reply.Results[1].Status != "error" {
+		t.Errorf("Unexpected SendData response: %v", &reply)
+	}
+
+	// Перехватчик авторизации действует и для вызовов gRPC-Web
+	_, grpcStatus = readGRPCWebResponse(t, call("/transport.AdminService/GetEntry", &pb.GetEntryRequest{Link: "https://youtu.be/a"}, ""))
+	if grpcStatus != "16" {
+		t.Errorf("Expected UNAUTHENTICATED for admin call without token, got %q", grpcStatus)
+	}
+
+	if resp := call("/transport.TransportService/SendData", &pb.SendDataRequest{Links: []string{"https://youtu.be/a"}}, "https://evil.example.com"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for disallowed origin, got %s", resp.Status)
+	}
+
+	preflight := func(origin string) *http.Response {
+		req, _ := http.NewRequest(http.MethodOptions, web.URL+"/transport.TransportService/SendData", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,authorization")
+		resp, err := web.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Preflight failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp = preflight("https://dashboard.example.com")
+	if resp.StatusCode >= 300 || resp.Header.Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" ||
+		!strings.Contains(strings.ToLower(resp.Header.Get("Access-Control-Allow-Headers")), "authorization") {
+		t.Errorf("Unexpected preflight response: %s %v", resp.Status, resp.Header)
+	}
+	if resp := preflight("https://evil.example.com"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected preflight from disallowed origin to be rejected, got %s", resp.Status)
+	}
+
+	resp, err := web.Client().Get(web.URL + "/transport.TransportService/SendData")
+	if err != nil {
+		t.Fatalf("Plain request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a non gRPC-Web request, got %s", resp.Status)
+	}
+}
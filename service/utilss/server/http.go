@@ -0,0 +1,315 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shelon_server/handlers"
+	pb "shelon_server/proto"
+	"shelon_server/utilss/auth"
+	"shelon_server/utilss/logger"
+	"shelon_server/utilss/urlsign"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Ограничения HTTP-шлюза.
+const (
+	defaultCacheMaxAge = 24 * time.Hour
+	maxBatchBodyBytes  = 1 << 20
+	shutdownTimeout    = 10 * time.Second
+)
+
+// HTTPServer представляет HTTP-шлюз к обработчику данных для браузеров и инструментов без gRPC.
+// Обложка отдаётся как изображение по GET /v1/thumbnails/{videoID}, а POST /v1/thumbnails:batch
+// принимает и возвращает SendDataRequest и SendDataResponse в формате JSON.
+// Если задан подписчик ссылок, обложки отдаются только по подписанным ссылкам с неистёкшим сроком,
+// иначе, как и пакетные запросы, требуют учётных данных с областью доступа read.
+// Если задана конфигурация TLS, шлюз принимает только соединения TLS с теми же сертификатами
+// и той же проверкой клиентских сертификатов, что и gRPC-сервер.
+type HTTPServer struct {
+	logger        logger.Logger
+	server        *http.Server
+	dataHandler   *handlers.DataHandler
+	cacheMaxAge   time.Duration
+	signer        *urlsign.Signer
+	authenticator *auth.Authenticator
+}
+
+// NewHTTPServer создает новый экземпляр HTTP-шлюза.
+// port: адрес, на котором будет слушать сервер.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// dataHandler: обработчик данных, общий с gRPC-сервером.
+// cacheMaxAge: срок кэширования обложек клиентами (0 — по умолчанию).
+// signer: подписчик, проверяющий ссылки на обложки (nil — подпись не требуется).
+// authenticator: проверка учётных данных клиентов, общая с gRPC-сервером.
+// tlsConfig: конфигурация TLS, общая с gRPC-сервером (nil — соединения без шифрования).
+func NewHTTPServer(port string, logger logger.Logger, dataHandler *handlers.DataHandler, cacheMaxAge time.Duration, signer *urlsign.Signer, authenticator *auth.Authenticator, tlsConfig *tls.Config) *HTTPServer {
+	logger.Info("Creating new instance of HTTP server")
+	if cacheMaxAge <= 0 {
+		cacheMaxAge = defaultCacheMaxAge
+	}
+	hs := &HTTPServer{
+		logger:        logger,
+		dataHandler:   dataHandler,
+		cacheMaxAge:   cacheMaxAge,
+		signer:        signer,
+		authenticator: authenticator,
+	}
+	hs.server = &http.Server{Addr: port, Handler: hs.Handler(), ReadHeaderTimeout: 10 * time.Second, TLSConfig: tlsConfig}
+	return hs
+}
+
+// Handler возвращает маршрутизатор HTTP-шлюза.
+func (hs *HTTPServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	var thumbnail http.Handler = http.HandlerFunc(hs.handleThumbnail)
+	if hs.signer != nil {
+		thumbnail = hs.requireSignature(thumbnail)
+	} else {
+		thumbnail = hs.requireScope(auth.ScopeRead, thumbnail)
+	}
+	mux.Handle("GET "+handlers.ThumbnailPathPrefix+"{videoID}", thumbnail)
+	mux.Handle("POST /v1/thumbnails:batch", hs.requireScope(auth.ScopeRead, http.HandlerFunc(hs.handleBatch)))
+	return mux
+}
+
+// Start запускает HTTP-шлюз и блокирует выполнение до завершения контекста,
+// после чего дожидается обработки текущих запросов.
+// ctx: контекст выполнения.
+func (hs *HTTPServer) Start(ctx context.Context) error {
+	return serveHTTP(ctx, hs.logger, hs.server)
+}
+
+// Stop останавливает HTTP-шлюз, дожидаясь завершения текущих запросов.
+// ctx: контекст ожидания.
+func (hs *HTTPServer) Stop(ctx context.Context) error {
+	hs.logger.Info("Stopping HTTP server")
+	if err := hs.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop HTTP server: %w", err)
+	}
+	hs.logger.Info("HTTP server successfully stopped")
+	return nil
+}
+
+// serveHTTP принимает соединения HTTP-сервера на его адресе до завершения контекста,
+// после чего дожидается обработки текущих запросов не дольше shutdownTimeout.
+func serveHTTP(ctx context.Context, logger logger.Logger, server *http.Server) error {
+	logger.Info("Initializing HTTP listener on port", zap.String("port", server.Addr))
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		logger.Error("Failed to create HTTP listener", zap.Error(err))
+		return err
+	}
+	return serveListener(ctx, logger, server, listener)
+}
+
+// serveListener принимает соединения HTTP-сервера из listener до завершения контекста.
+// Если у сервера задана конфигурация TLS, соединения без успешного рукопожатия TLS
+// (в том числе без действующего клиентского сертификата при mTLS) отклоняются, а клиентам,
+// согласовавшим по ALPN протокол h2, отвечает HTTP/2-сервер net/http.
+func serveListener(ctx context.Context, logger logger.Logger, server *http.Server, listener net.Listener) error {
+	serve := server.Serve
+	if server.TLSConfig != nil {
+		// Сертификаты задаёт TLSConfig; ServeTLS дополнительно подключает обработку HTTP/2
+		serve = func(listener net.Listener) error { return server.ServeTLS(listener, "", "") }
+	}
+	go func() {
+		logger.Info("Starting HTTP server", zap.String("port", server.Addr), zap.Bool("tls", server.TLSConfig != nil))
+		if err := serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("HTTP server stopped with error", zap.Error(err))
+		}
+	}()
+	logger.Info("HTTP server started and ready to accept requests", zap.String("port", server.Addr))
+
+	<-ctx.Done()
+	logger.Info("Received shutdown signal, stopping HTTP server", zap.String("port", server.Addr))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to stop HTTP server: %w", err)
+	}
+	logger.Info("HTTP server successfully stopped", zap.String("port", server.Addr))
+	return nil
+}
+
+// requireSignature пропускает к next только запросы с действующей подписью: подделанные,
+// подписанные неизвестным ключом и истёкшие ссылки отклоняются с кодом 403.
+func (hs *HTTPServer) requireSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := hs.signer.Verify(r.URL.Path, r.URL.Query(), time.Now()); err != nil {
+			hs.logger.Warn("Rejected thumbnail request with invalid signature", zap.String("path", r.URL.Path), zap.Error(err))
+			writeHTTPError(w, status.Error(codes.PermissionDenied, err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope пропускает к next только запросы клиентов с областью доступа scope. Учётные данные
+// передаются, как и в gRPC, заголовком "Authorization: Bearer <token>" или "X-API-Key".
+func (hs *HTTPServer) requireScope(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.TokenFromHeaders(r.Header.Values("Authorization"), r.Header.Values("X-API-Key"))
+		if err == nil {
+			var identity *auth.Identity
+			if identity, err = hs.authenticator.Authorize(token, scope); err == nil {
+				next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), identity)))
+				return
+			}
+		}
+		hs.logger.Warn("Rejected HTTP request with invalid credentials", zap.String("path", r.URL.Path), zap.Error(err))
+		if errors.Is(err, auth.ErrPermissionDenied) {
+			writeHTTPError(w, status.Error(codes.PermissionDenied, err.Error()))
+			return
+		}
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeHTTPError(w, status.Error(codes.Unauthenticated, err.Error()))
+	})
+}
+
+// handleThumbnail отдаёт обложку видео как изображение. Параметры запроса quality, w и format
+// задают качество, ширину и формат. ETag — хеш содержимого, поэтому запрос с совпадающим
+// If-None-Match получает 304 без тела.
+func (hs *HTTPServer) handleThumbnail(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	options := handlers.ThumbnailOptions{Quality: query.Get("quality"), Format: query.Get("format")}
+	if value := query.Get("w"); value != "" {
+		width, err := strconv.Atoi(value)
+		if err != nil || width <= 0 {
+			writeHTTPError(w, status.Errorf(codes.InvalidArgument, "invalid width %q", value))
+			return
+		}
+		options.Width = width
+	}
+
+	thumbnail, err := hs.dataHandler.HandleGetThumbnail(r.Context(), r.PathValue("videoID"), options)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", thumbnail.ContentType)
+	w.Header().Set("ETag", strconv.Quote(thumbnail.ContentHash))
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(hs.cacheMaxAge.Seconds())))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(thumbnail.Image))
+}
+
+// handleBatch обрабатывает JSON-версию SendData: тело запроса и ответа — SendDataRequest
+// и SendDataResponse в каноническом JSON-представлении protobuf (изображения в base64).
+func (hs *HTTPServer) handleBatch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+	if err != nil {
+		writeHTTPError(w, status.Errorf(codes.InvalidArgument, "failed to read request body: %v", err))
+		return
+	}
+	var req pb.SendDataRequest
+	if err := protojson.Unmarshal(body, &req); err != nil {
+		writeHTTPError(w, status.Errorf(codes.InvalidArgument, "invalid request body: %v", err))
+		return
+	}
+	if len(req.Links) == 0 {
+		writeHTTPError(w, status.Error(codes.InvalidArgument, "links must not be empty"))
+		return
+	}
+
+	resp, err := hs.dataHandler.HandleSendData(r.Context(), &req)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		writeHTTPError(w, fmt.Errorf("failed to encode response: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// httpStatusCodes сопоставляет статусы gRPC, которые возвращают обработчики, кодам HTTP.
+var httpStatusCodes = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.NotFound:           http.StatusNotFound,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusPreconditionFailed,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+}
+
+// writeHTTPError отправляет ошибку обработчика в виде JSON {"error": "..."} с кодом HTTP,
+// соответствующим её статусу gRPC. Ошибки без статуса считаются внутренними.
+func writeHTTPError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	message := err.Error()
+	if st, ok := status.FromError(err); ok {
+		message = st.Message()
+		if mapped, found := httpStatusCodes[st.Code()]; found {
+			code = mapped
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+/*
+NewHTTPServer создает новый экземпляр HTTP-шлюза.
+port: адрес, на котором будет слушать сервер.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+dataHandler: обработчик данных, общий с gRPC-сервером.
+cacheMaxAge: срок кэширования обложек клиентами.
+signer: подписчик, проверяющий ссылки на обложки.
+authenticator: проверка учётных данных клиентов.
+tlsConfig: конфигурация TLS (nil — соединения без шифрования).
+
+Handler возвращает маршрутизатор HTTP-шлюза.
+
+Start запускает HTTP-шлюз и блокирует выполнение до завершения контекста.
+ctx: контекст выполнения.
+
+Stop останавливает HTTP-шлюз, дожидаясь завершения текущих запросов.
+ctx: контекст ожидания.
+
+serveHTTP принимает соединения HTTP-сервера до завершения контекста.
+ctx: контекст выполнения.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+server: HTTP-сервер.
+
+serveListener принимает соединения HTTP-сервера из listener до завершения контекста.
+ctx: контекст выполнения.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+server: HTTP-сервер.
+listener: принимающий соединения сокет.
+
+requireSignature пропускает к next только запросы с действующей подписью.
+next: обработчик обложек.
+
+requireScope пропускает к next только запросы клиентов с областью доступа scope.
+scope: требуемая область доступа.
+next: обработчик запроса.
+
+handleThumbnail отдаёт обложку видео как изображение.
+w: ответ HTTP.
+r: запрос HTTP.
+
+handleBatch обрабатывает JSON-версию SendData.
+w: ответ HTTP.
+r: запрос HTTP.
+
+writeHTTPError отправляет ошибку обработчика в виде JSON.
+w: ответ HTTP.
+err: ошибка обработчика.
+*/
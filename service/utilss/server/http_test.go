@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shelon_server/handlers"
+	pb "shelon_server/proto"
+	"shelon_server/usecase"
+	"shelon_server/utilss/auth"
+	"shelon_server/utilss/urlsign"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// MockLogger заглушка для логирования в тестах
+type MockLogger struct{}
+
+func (m *MockLogger) Info(message string, fields ...interface{})  {}
+func (m *MockLogger) Warn(message string, fields ...interface{})  {}
+func (m *MockLogger) Error(message string, fields ...interface{}) {}
+
+// fakeDataProcessor возвращает JPEG 64x36 для каждой ссылки и ошибку для ссылок, содержащих "broken".
+type fakeDataProcessor struct {
+	usecase.DataProcessorUsecase
+	photo []byte
+}
+
+func (f *fakeDataProcessor) ProcessData(ctx context.Context, flag bool, links []string, options usecase.ProcessOptions) ([]usecase.LinkResult, error) {
+	results := make([]usecase.LinkResult, 0, len(links))
+	for _, link := range links {
+		if strings.Contains(link, "broken") {
+			results = append(results, usecase.LinkResult{Link: link, Err: errors.New("thumbnail not found")})
+			continue
+		}
+		results = append(results, usecase.LinkResult{Link: link, Photo: f.photo, ContentHash: "abc"})
+	}
+	return results, nil
+}
+
+// newTestHTTPServer создает HTTP-шлюз поверх обработчика с поддельной бизнес-логикой.
+// signer: подписчик ссылок (nil — подпись не требуется).
+func newTestHTTPServer(t *testing.T, signer *urlsign.Signer, authenticator *auth.Authenticator) http.Handler {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 36))
+	for x := 0; x < 64; x++ {
+		img.Set(x, x%36, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	dataHandler := handlers.NewDataHandler(&MockLogger{}, &fakeDataProcessor{photo: buf.Bytes()})
+	if authenticator == nil {
+		authenticator, _ = auth.NewAuthenticator(nil, auth.JWTOptions{}, "", true)
+	}
+	return NewHTTPServer(":0", &MockLogger{}, dataHandler, 0, signer, authenticator, nil).Handler()
+}
+
+// TestHTTPThumbnail проверяет отдачу обложки, её преобразование, ETag и ответ 304.
+func TestHTTPThumbnail(t *testing.T) {
+	handler := newTestHTTPServer(t, nil, nil)
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/v1/thumbnails/dQw4w9WgXcQ", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("Unexpected response: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec.Header().Get("ETag") != `"abc"` || rec.Header().Get("Cache-Control") != "public, max-age=86400" {
+		t.Errorf("Unexpected caching headers: %v", rec.Header())
+	}
+
+	rec = get("/v1/thumbnails/dQw4w9WgXcQ", http.Header{"If-None-Match": {`"abc"`}})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected 304 without body, got %d (%d bytes)", rec.Code, rec.Body.Len())
+	}
+
+	rec = get("/v1/thumbnails/dQw4w9WgXcQ?w=32&format=png&quality=maxresdefault", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Unexpected resized response: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	resized, _, err := image.Decode(rec.Body)
+	if err != nil || resized.Bounds().Dx() != 32 || resized.Bounds().Dy() != 18 {
+		t.Errorf("Expected 32x18 png, got %v (%v)", resized, err)
+	}
+	if etag := rec.Header().Get("ETag"); etag == `"abc"` || etag == "" {
+		t.Errorf("Expected ETag of the resized image, got %q", etag)
+	}
+
+	tests := []struct {
+		target string
+		code   int
+	}{
+		{"/v1/thumbnails/short", http.StatusBadRequest},
+		{"/v1/thumbnails/dQw4w9WgXcQ?w=0", http.StatusBadRequest},
+		{"/v1/thumbnails/dQw4w9WgXcQ?quality=hqdefault", http.StatusBadRequest},
+		{"/v1/thumbnails/dQw4w9WgXcQ?format=webp", http.StatusBadRequest},
+		{"/v1/thumbnails/brokenbroke", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := get(tt.target, nil); rec.Code != tt.code || !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Errorf("%s: expected %d with JSON error, got %d %s", tt.target, tt.code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestHTTPBatch проверяет, что пакетный запрос повторяет SendData в формате JSON.
+func TestHTTPBatch(t *testing.T) {
+	handler := newTestHTTPServer(t, nil, nil)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/thumbnails:batch", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"links": ["https://youtu.be/a", "https://youtu.be/broken"], "knownHashes": {}}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp pb.SendDataResponse
+	if err := protojson.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Images) != 1 || len(resp.Results) != 2 || resp.Results[0].Status != "ok" || resp.Results[1].Status != "error" {
+		t.Errorf("Unexpected batch response: %v", &resp)
+	}
+
+	// Известный хеш относится только к своей ссылке, даже если у другой ссылки то же содержимое
+	rec = post(`{"links": ["https://youtu.be/a", "https://youtu.be/c"], "knownHashes": {"https://youtu.be/a": "abc", "https://youtu.be/d": "abc"}}`)
+	resp.Reset()
+	if err := protojson.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Images) != 1 || resp.Results[0].Status != "not_modified" || resp.Results[1].Status != "ok" {
+		t.Errorf("Expected only the link with a known hash to be not modified, got %v", &resp)
+	}
+
+	for _, body := range []string{`{"links": []}`, `not json`, `{"unknown": 1}`} {
+		rec := post(body)
+		var payload map[string]string
+		if rec.Code != http.StatusBadRequest || json.Unmarshal(rec.Body.Bytes(), &payload) != nil || payload["error"] == "" {
+			t.Errorf("%s: expected 400 with JSON error, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestHTTPSignedThumbnail проверяет выдачу подписанной ссылки и отклонение подделанных,
+// неподписанных и истёкших ссылок.
+func TestHTTPSignedThumbnail(t *testing.T) {
+	signer, err := urlsign.NewSigner([]urlsign.Key{{ID: "k1", Secret: "secret"}})
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	handler := newTestHTTPServer(t, signer, nil)
+	get := func(target string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Code
+	}
+
+	admin := handlers.NewAdminHandler(&MockLogger{}, nil)
+	req := &pb.SignThumbnailURLRequest{VideoId: "dQw4w9WgXcQ", Width: 32, Format: "png", TtlSeconds: 60}
+	if _, err := admin.HandleSignThumbnailURL(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without signing keys, got %v", err)
+	}
+	admin.SignedURLs = &handlers.SignedURLs{Signer: signer, PublicURL: "https://cdn.example.com/", MaxTTL: time.Hour}
+	resp, err := admin.HandleSignThumbnailURL(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to sign url: %v", err)
+	}
+	if !strings.HasPrefix(resp.Url, "https://cdn.example.com/v1/thumbnails/dQw4w9WgXcQ?") || resp.ExpiresAt <= time.Now().Unix() {
+		t.Fatalf("Unexpected signed url: %+v", resp)
+	}
+	signed := strings.TrimPrefix(resp.Url, "https://cdn.example.com")
+	if code := get(signed); code != http.StatusOK {
+		t.Errorf("Expected signed url to be served, got %d", code)
+	}
+
+	for _, ttl := range []int64{-1, 7200, 9_300_000_000, 1<<63 - 1} {
+		if _, err := admin.HandleSignThumbnailURL(context.Background(), &pb.SignThumbnailURLRequest{VideoId: "dQw4w9WgXcQ", TtlSeconds: ttl}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ttl %d: expected InvalidArgument, got %v", ttl, err)
+		}
+	}
+
+	expired := signer.Sign("/v1/thumbnails/dQw4w9WgXcQ", nil, time.Now().Add(-time.Second))
+	for name, target := range map[string]string{
+		"tampered width": strings.Replace(signed, "w=32", "w=64", 1),
+		"unsigned":       "/v1/thumbnails/dQw4w9WgXcQ?w=32&format=png",
+		"expired":        expired,
+	} {
+		if code := get(target); code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", name, code)
+		}
+	}
+}
+
+// TestHTTPAuth проверяет, что при заданных API-ключах HTTP-шлюз требует ключ с областью read.
+func TestHTTPAuth(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator([]auth.APIKey{
+		{Key: "reader-key", Client: "reader", Scopes: []string{auth.ScopeRead}},
+		{Key: "warmer-key", Client: "warmer", Scopes: []string{auth.ScopeWarm}},
+	}, auth.JWTOptions{}, "", false)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	handler := newTestHTTPServer(t, nil, authenticator)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		code   int
+	}{
+		{"without credentials", "", "", http.StatusUnauthorized},
+		{"unknown key", "X-API-Key", "stolen", http.StatusUnauthorized},
+		{"key without read scope", "Authorization", "Bearer warmer-key", http.StatusForbidden},
+		{"api key header", "X-API-Key", "reader-key", http.StatusOK},
+		{"bearer key", "Authorization", "Bearer reader-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, req := range []*http.Request{
+				httptest.NewRequest(http.MethodGet, "/v1/thumbnails/dQw4w9WgXcQ", nil),
+				httptest.NewRequest(http.MethodPost, "/v1/thumbnails:batch", strings.NewReader(`{"links":["https://youtu.be/a"]}`)),
+			} {
+				if tt.header != "" {
+					req.Header.Set(tt.header, tt.value)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code != tt.code {
+					t.Errorf("%s %s: expected %d, got %d: %s", req.Method, req.URL.Path, tt.code, rec.Code, rec.Body)
+				}
+			}
+		})
+	}
+}
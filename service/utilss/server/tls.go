@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"shelon_server/utilss/logger"
+
+	"go.uber.org/zap"
+)
+
+// defaultReloadInterval — период проверки файлов сертификатов по умолчанию.
+const defaultReloadInterval = 30 * time.Second
+
+// CertReloader хранит сертификат сервера и пул сертификатов УЦ клиентов и перечитывает их,
+// когда файлы на диске меняются. Новые соединения используют актуальные сертификаты,
+// уже установленные соединения не разрываются. Если изменённые файлы не удаётся загрузить
+// (например, сертификат уже записан, а ключ ещё нет), остаются прежние сертификаты.
+type CertReloader struct {
+	logger       logger.Logger
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    map[string]fileStamp
+}
+
+// fileStamp описывает состояние файла, по которому определяется его изменение.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertReloader создает хранилище сертификатов и загружает их.
+// logger: экземпляр интерфейса logger.Logger для логирования действий.
+// certFile, keyFile: сертификат и закрытый ключ сервера в формате PEM.
+// clientCAFile: сертификаты УЦ, которыми должны быть подписаны сертификаты клиентов (пусто — без mTLS).
+// Возвращает ошибку, если сертификаты не удалось загрузить.
+func NewCertReloader(logger logger.Logger, certFile, keyFile, clientCAFile string) (*CertReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both certificate and key files are required for TLS")
+	}
+	cr := &CertReloader{
+		logger:       logger,
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+	if _, err := cr.Reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// TLSConfig возвращает конфигурацию TLS gRPC-сервера: по ALPN согласуется только HTTP/2.
+// Сертификаты выбираются при каждом новом соединении, поэтому перезагрузка применяется
+// без перезапуска сервера. Если задан пул УЦ клиентов, клиент обязан предъявить подписанный
+// им сертификат.
+func (cr *CertReloader) TLSConfig() *tls.Config {
+	return cr.config("h2")
+}
+
+// HTTPTLSConfig возвращает конфигурацию TLS шлюзов gRPC-Web и HTTP с теми же сертификатами,
+// что и TLSConfig. По ALPN согласуются HTTP/2 и HTTP/1.1: браузеры предлагают оба протокола,
+// а клиенты без ALPN получают HTTP/1.1.
+func (cr *CertReloader) HTTPTLSConfig() *tls.Config {
+	return cr.config("h2", "http/1.1")
+}
+
+// config возвращает конфигурацию TLS с актуальными сертификатами и протоколами ALPN nextProtos.
+func (cr *CertReloader) config(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cr.mu.RLock()
+			defer cr.mu.RUnlock()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cr.cert},
+				NextProtos:   nextProtos,
+			}
+			if cr.clientCAs != nil {
+				config.ClientCAs = cr.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
+
+// Reload перечитывает сертификаты, если их файлы изменились с прошлой загрузки.
+// Возвращает true, если сертификаты заменены, и ошибку, если изменённые файлы не удалось загрузить.
+func (cr *CertReloader) Reload() (bool, error) {
+	stamps, err := cr.currentStamps()
+	if err != nil {
+		return false, err
+	}
+	cr.mu.RLock()
+	unchanged := cr.cert != nil && sameStamps(stamps, cr.stamps)
+	cr.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if cr.clientCAFile != "" {
+		data, err := os.ReadFile(cr.clientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return false, fmt.Errorf("client CA bundle %s contains no certificates", cr.clientCAFile)
+		}
+	}
+
+	cr.mu.Lock()
+	cr.cert, cr.clientCAs, cr.stamps = &cert, clientCAs, stamps
+	cr.mu.Unlock()
+	return true, nil
+}
+
+// Watch проверяет файлы сертификатов с периодом interval и перезагружает их при изменении
+// до отмены ctx.
+// ctx: контекст работы.
+// interval: период проверки (0 — по умолчанию).
+func (cr *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := cr.Reload()
+		if err != nil {
+			cr.logger.Error("Failed to reload TLS certificates, keeping the previous ones", zap.Error(err))
+			continue
+		}
+		if reloaded {
+			cr.logger.Info("TLS certificates reloaded", zap.String("cert", cr.certFile), zap.String("clientCA", cr.clientCAFile))
+		}
+	}
+}
+
+// currentStamps возвращает состояние файлов сертификатов.
+func (cr *CertReloader) currentStamps() (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp, 3)
+	for _, path := range []string{cr.certFile, cr.keyFile, cr.clientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// sameStamps сообщает, совпадают ли состояния файлов.
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+NewCertReloader создает хранилище сертификатов и загружает их.
+logger: экземпляр интерфейса logger.Logger для логирования действий.
+certFile, keyFile: сертификат и закрытый ключ сервера.
+clientCAFile: сертификаты УЦ клиентов (пусто — без mTLS).
+
+TLSConfig возвращает конфигурацию TLS gRPC-сервера с актуальными сертификатами.
+
+HTTPTLSConfig возвращает конфигурацию TLS шлюзов с протоколами HTTP/2 и HTTP/1.1.
+
+config возвращает конфигурацию TLS с актуальными сертификатами.
+nextProtos: протоколы ALPN.
+
+Reload перечитывает сертификаты, если их файлы изменились.
+
+Watch перезагружает сертификаты при изменении файлов до отмены ctx.
+ctx: контекст работы.
+interval: период проверки.
+
+currentStamps возвращает состояние файлов сертификатов.
+
+sameStamps сообщает, совпадают ли состояния файлов.
+a, b: состояния файлов.
+*/
@@ -0,0 +1,352 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shelon_server/handlers"
+	pb "shelon_server/proto"
+	"shelon_server/transport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+)
+
+// testCA — удостоверяющий центр, выпускающий сертификаты для тестов.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCA создает самоподписанный удостоверяющий центр.
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// writeCert сохраняет сертификат УЦ в файл PEM и возвращает путь к нему.
+func (ca *testCA) writeCert(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatalf("Failed to write CA certificate: %v", err)
+	}
+	return path
+}
+
+// issue выпускает сертификат сервера (для localhost) или клиента с заданным серийным номером
+// и сохраняет его и ключ в файлы PEM name.crt и name.key.
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64, server bool) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// startTLSServer запускает gRPC-сервер с TLS на свободном порту и возвращает его адрес.
+func startTLSServer(t *testing.T, reloader *CertReloader) string {
+	t.Helper()
+	dataHandler := handlers.NewDataHandler(&MockLogger{}, &fakeDataProcessor{photo: []byte("image")})
+	grpcServer := NewGRPCServer(":0", &MockLogger{}, transport.NewTransportService(dataHandler), nil, nil, newTestAuthenticator(t, nil, ""), reloader.TLSConfig())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go grpcServer.server.Serve(listener)
+	t.Cleanup(grpcServer.server.Stop)
+	return listener.Addr().String()
+}
+
+// clientTLS формирует конфигурацию TLS клиента, доверяющего ca, с сертификатом клиента, если он задан.
+func clientTLS(t *testing.T, ca *testCA, certFile, keyFile string) *tls.Config {
+	t.Helper()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	config := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			t.Fatalf("Failed to load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config
+}
+
+// sendOverTLS вызывает SendData по TLS и возвращает ошибку вызова.
+func sendOverTLS(t *testing.T, address string, config *tls.Config) error {
+	t.Helper()
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = pb.NewTransportServiceClient(conn).SendData(ctx, &pb.SendDataRequest{Links: []string{"https://youtu.be/a"}})
+	return err
+}
+
+// TestTLS проверяет вызов по TLS и отказ клиенту, не доверяющему сертификату сервера.
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test ca")
+	certFile, keyFile := ca.issue(t, dir, "server", 2, true)
+	reloader, err := NewCertReloader(&MockLogger{}, certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+	address := startTLSServer(t, reloader)
+
+	if err := sendOverTLS(t, address, clientTLS(t, ca, "", "")); err != nil {
+		t.Errorf("Expected TLS call to succeed, got %v", err)
+	}
+	if err := sendOverTLS(t, address, clientTLS(t, newTestCA(t, "other ca"), "", "")); err == nil {
+		t.Error("Expected call to fail for a client that does not trust the server certificate")
+	}
+}
+
+// TestMutualTLS проверяет, что при заданном пуле УЦ клиентов сервер принимает только клиентов
+// с сертификатом, подписанным этим УЦ.
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test ca")
+	certFile, keyFile := ca.issue(t, dir, "server", 2, true)
+	clientCert, clientKey := ca.issue(t, dir, "client", 3, false)
+	strangerCert, strangerKey := newTestCA(t, "other ca").issue(t, dir, "stranger", 4, false)
+	reloader, err := NewCertReloader(&MockLogger{}, certFile, keyFile, ca.writeCert(t, dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+	address := startTLSServer(t, reloader)
+
+	if err := sendOverTLS(t, address, clientTLS(t, ca, clientCert, clientKey)); err != nil {
+		t.Errorf("Expected call with a trusted client certificate to succeed, got %v", err)
+	}
+	if err := sendOverTLS(t, address, clientTLS(t, ca, "", "")); err == nil {
+		t.Error("Expected call without a client certificate to fail")
+	}
+	if err := sendOverTLS(t, address, clientTLS(t, ca, strangerCert, strangerKey)); err == nil {
+		t.Error("Expected call with an untrusted client certificate to fail")
+	}
+}
+
+// TestCertReload проверяет, что новые соединения получают перевыпущенный сертификат
+// и что при повреждённых файлах остаётся прежний.
+func TestCertReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test ca")
+	certFile, keyFile := ca.issue(t, dir, "server", 2, true)
+	reloader, err := NewCertReloader(&MockLogger{}, certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+	address := startTLSServer(t, reloader)
+
+	servedSerial := func() int64 {
+		t.Helper()
+		config := clientTLS(t, ca, "", "")
+		config.NextProtos = []string{"h2"}
+		conn, err := tls.Dial("tcp", address, config)
+		if err != nil {
+			t.Fatalf("TLS handshake failed: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	// touch сдвигает время изменения файлов, чтобы перезапись была заметна и при грубой точности ФС
+	touch := func(offset time.Duration) {
+		for _, path := range []string{certFile, keyFile} {
+			if err := os.Chtimes(path, time.Now().Add(offset), time.Now().Add(offset)); err != nil {
+				t.Fatalf("Failed to touch %s: %v", path, err)
+			}
+		}
+	}
+
+	if reloaded, err := reloader.Reload(); err != nil || reloaded {
+		t.Fatalf("Expected no reload for unchanged files, got %v, %v", reloaded, err)
+	}
+	if serial := servedSerial(); serial != 2 {
+		t.Fatalf("Expected initial certificate, got serial %d", serial)
+	}
+
+	ca.issue(t, dir, "server", 5, true)
+	touch(time.Minute)
+	if reloaded, err := reloader.Reload(); err != nil || !reloaded {
+		t.Fatalf("Expected certificates to be reloaded, got %v, %v", reloaded, err)
+	}
+	if serial := servedSerial(); serial != 5 {
+		t.Errorf("Expected reloaded certificate, got serial %d", serial)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("Failed to corrupt key: %v", err)
+	}
+	touch(2 * time.Minute)
+	if _, err := reloader.Reload(); err == nil {
+		t.Error("Expected reload of a corrupted key to fail")
+	}
+	if serial := servedSerial(); serial != 5 {
+		t.Errorf("Expected previous certificate to be kept, got serial %d", serial)
+	}
+}
+
+// startHTTPServer запускает HTTP-сервер шлюза на свободном порту и возвращает его адрес.
+func startHTTPServer(t *testing.T, server *http.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveListener(ctx, &MockLogger{}, server, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return listener.Addr().String()
+}
+
+// TestGatewaysMutualTLS проверяет, что шлюзы gRPC-Web и HTTP с сертификатами gRPC-сервера
+// принимают только соединения TLS с клиентским сертификатом, подписанным УЦ клиентов,
+// и отвечают как клиентам без ALPN, так и браузерным клиентам, предлагающим h2 и http/1.1.
+func TestGatewaysMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test ca")
+	certFile, keyFile := ca.issue(t, dir, "server", 2, true)
+	clientCert, clientKey := ca.issue(t, dir, "client", 3, false)
+	reloader, err := NewCertReloader(&MockLogger{}, certFile, keyFile, ca.writeCert(t, dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+	dataHandler := handlers.NewDataHandler(&MockLogger{}, &fakeDataProcessor{photo: []byte("image")})
+	grpcServer := NewGRPCServer(":0", &MockLogger{}, transport.NewTransportService(dataHandler), nil, nil, newTestAuthenticator(t, nil, ""), reloader.TLSConfig())
+	web := startHTTPServer(t, NewGRPCWebServer(":0", &MockLogger{}, grpcServer, []string{"*"}, reloader.HTTPTLSConfig()).server)
+	gateway := startHTTPServer(t, NewHTTPServer(":0", &MockLogger{}, dataHandler, 0, nil, newTestAuthenticator(t, nil, ""), reloader.HTTPTLSConfig()).server)
+
+	payload, err := proto.Marshal(&pb.SendDataRequest{Links: []string{"https://youtu.be/a"}})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	// С ForceAttemptHTTP2 клиент, как браузер, предлагает по ALPN h2 и http/1.1
+	newClient := func(config *tls.Config, h2 bool) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: config, ForceAttemptHTTP2: h2}, Timeout: 5 * time.Second}
+	}
+	sendData := func(scheme string, config *tls.Config, h2 bool) (*http.Response, error) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, scheme+"://"+web+"/transport.TransportService/SendData", bytes.NewReader(grpcWebFrame(0, payload)))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("X-Grpc-Web", "1")
+		resp, err := newClient(config, h2).Do(req)
+		if err == nil {
+			t.Cleanup(func() { resp.Body.Close() })
+		}
+		return resp, err
+	}
+
+	for _, h2 := range []bool{false, true} {
+		resp, err := sendData("https", clientTLS(t, ca, clientCert, clientKey), h2)
+		if err != nil {
+			t.Fatalf("Expected gRPC-Web call with a client certificate to succeed (h2 offered: %v), got %v", h2, err)
+		}
+		if (resp.ProtoMajor == 2) != h2 {
+			t.Errorf("Expected HTTP/2 only when h2 is offered (%v), got %s", h2, resp.Proto)
+		}
+		if messages, grpcStatus := readGRPCWebResponse(t, resp); grpcStatus != "0" || len(messages) != 1 {
+			t.Errorf("Expected one message with status 0, got %d messages, status %q", len(messages), grpcStatus)
+		}
+	}
+	if _, err := sendData("https", clientTLS(t, ca, "", ""), true); err == nil {
+		t.Error("Expected gRPC-Web call without a client certificate to fail")
+	}
+	if resp, err := sendData("http", nil, false); err == nil && resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected plain HTTP gRPC-Web call to be rejected, got %s", resp.Status)
+	}
+
+	get := func(config *tls.Config, h2 bool) (*http.Response, error) {
+		resp, err := newClient(config, h2).Get("https://" + gateway + handlers.ThumbnailPathPrefix + "dQw4w9WgXcQ")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+	for _, h2 := range []bool{false, true} {
+		resp, err := get(clientTLS(t, ca, clientCert, clientKey), h2)
+		if err != nil {
+			t.Fatalf("Expected HTTP gateway request with a client certificate to succeed (h2 offered: %v), got %v", h2, err)
+		}
+		if (resp.ProtoMajor == 2) != h2 {
+			t.Errorf("Expected HTTP/2 only when h2 is offered (%v), got %s", h2, resp.Proto)
+		}
+	}
+	if _, err := get(clientTLS(t, ca, "", ""), true); err == nil {
+		t.Error("Expected HTTP gateway request without a client certificate to fail")
+	}
+}
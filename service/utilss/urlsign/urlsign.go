@@ -0,0 +1,160 @@
+package urlsign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Параметры подписи в строке запроса.
+const (
+	ParamExpires   = "expires" // Время истечения ссылки (Unix, с).
+	ParamKeyID     = "kid"     // Идентификатор ключа, которым подписана ссылка.
+	ParamSignature = "sig"     // Подпись HMAC-SHA256 в base64url без выравнивания.
+)
+
+// Ошибки проверки подписанной ссылки.
+var (
+	ErrMissingSignature = errors.New("url signature is missing")
+	ErrInvalidSignature = errors.New("url signature is invalid")
+	ErrUnknownKey       = errors.New("url signing key is unknown")
+	ErrExpired          = errors.New("signed url has expired")
+)
+
+// Key описывает ключ подписи ссылок.
+type Key struct {
+	ID     string // Идентификатор ключа, передаваемый в ссылке.
+	Secret string // Секрет HMAC-SHA256.
+}
+
+// Signer подписывает пути со строкой запроса и проверяет подписи. Подпись покрывает путь и все
+// параметры запроса, поэтому изменение, добавление или удаление любого параметра (в том числе
+// срока действия) делает ссылку недействительной.
+// Новые ссылки подписываются первым ключом, а проверяются всеми: для ротации новый ключ
+// добавляется в начало списка, а старый удаляется после истечения выданных им ссылок.
+type Signer struct {
+	keys []Key
+}
+
+// NewSigner создает подписчик ссылок.
+// keys: ключи подписи; первый используется для новых ссылок.
+// Возвращает ошибку, если ключей нет, у ключа пустой идентификатор или секрет, или идентификаторы повторяются.
+func NewSigner(keys []Key) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		switch {
+		case key.ID == "":
+			return nil, errors.New("signing key id must not be empty")
+		case key.Secret == "":
+			return nil, fmt.Errorf("signing key %q has an empty secret", key.ID)
+		case seen[key.ID]:
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		seen[key.ID] = true
+	}
+	return &Signer{keys: keys}, nil
+}
+
+// Sign подписывает путь с параметрами запроса и возвращает путь со строкой запроса,
+// дополненной сроком действия, идентификатором ключа и подписью.
+// path: путь без строки запроса.
+// params: подписываемые параметры запроса (не изменяются).
+// expires: время истечения ссылки.
+func (s *Signer) Sign(path string, params url.Values, expires time.Time) string {
+	key := s.keys[0]
+	query := make(url.Values, len(params)+3)
+	for name, values := range params {
+		query[name] = append([]string(nil), values...)
+	}
+	query.Set(ParamExpires, strconv.FormatInt(expires.Unix(), 10))
+	query.Set(ParamKeyID, key.ID)
+	query.Set(ParamSignature, signature(key.Secret, path, query))
+	return path + "?" + query.Encode()
+}
+
+// Verify проверяет подпись пути со строкой запроса и срок действия ссылки.
+// path: путь без строки запроса.
+// query: параметры запроса вместе с параметрами подписи.
+// now: текущее время.
+// Возвращает ErrMissingSignature, ErrUnknownKey, ErrInvalidSignature или ErrExpired.
+func (s *Signer) Verify(path string, query url.Values, now time.Time) error {
+	signatures := query[ParamSignature]
+	if len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+	if len(signatures) > 1 || len(query[ParamKeyID]) != 1 || len(query[ParamExpires]) != 1 {
+		return ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(query.Get(ParamExpires), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	key, ok := s.key(query.Get(ParamKeyID))
+	if !ok {
+		return ErrUnknownKey
+	}
+
+	signed := make(url.Values, len(query))
+	for name, values := range query {
+		if name != ParamSignature {
+			signed[name] = values
+		}
+	}
+	if !hmac.Equal([]byte(signatures[0]), []byte(signature(key.Secret, path, signed))) {
+		return ErrInvalidSignature
+	}
+	// Срок проверяется после подписи: изменённый срок даёт ErrInvalidSignature, а не ErrExpired
+	if !now.Before(time.Unix(expires, 0)) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// key возвращает ключ по идентификатору.
+func (s *Signer) key(id string) (Key, bool) {
+	for _, key := range s.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// signature вычисляет HMAC-SHA256 канонического представления ссылки: пути и параметров,
+// отсортированных по имени (url.Values.Encode).
+func signature(secret, path string, query url.Values) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path + "?" + query.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+/*
+NewSigner создает подписчик ссылок.
+keys: ключи подписи; первый используется для новых ссылок.
+
+Sign подписывает путь с параметрами запроса.
+path: путь без строки запроса.
+params: подписываемые параметры запроса.
+expires: время истечения ссылки.
+
+Verify проверяет подпись и срок действия ссылки.
+path: путь без строки запроса.
+query: параметры запроса вместе с параметрами подписи.
+now: текущее время.
+
+key возвращает ключ по идентификатору.
+id: идентификатор ключа.
+
+signature вычисляет HMAC-SHA256 канонического представления ссылки.
+secret: секрет ключа.
+path: путь без строки запроса.
+query: параметры запроса без подписи.
+*/
@@ -0,0 +1,98 @@
+package urlsign
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parse разделяет подписанную ссылку на путь и параметры запроса.
+func parse(t *testing.T, signed string) (string, url.Values) {
+	t.Helper()
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Failed to parse signed url %q: %v", signed, err)
+	}
+	return parsed.Path, parsed.Query()
+}
+
+// TestSignVerify проверяет подписанную ссылку, её подделку и истечение срока.
+func TestSignVerify(t *testing.T) {
+	signer, err := NewSigner([]Key{{ID: "k1", Secret: "secret"}})
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	signed := signer.Sign("/v1/thumbnails/dQw4w9WgXcQ", url.Values{"w": {"320"}}, now.Add(time.Hour))
+	path, query := parse(t, signed)
+	if err := signer.Verify(path, query, now); err != nil {
+		t.Fatalf("Expected valid signature, got %v", err)
+	}
+
+	tampered := func(change func(url.Values)) url.Values {
+		copied := url.Values{}
+		for name, values := range query {
+			copied[name] = append([]string(nil), values...)
+		}
+		change(copied)
+		return copied
+	}
+	tests := []struct {
+		name  string
+		path  string
+		query url.Values
+		err   error
+	}{
+		{"other video", "/v1/thumbnails/aaaaaaaaaaa", query, ErrInvalidSignature},
+		{"changed width", path, tampered(func(q url.Values) { q.Set("w", "1920") }), ErrInvalidSignature},
+		{"added format", path, tampered(func(q url.Values) { q.Set("format", "png") }), ErrInvalidSignature},
+		{"extended expiry", path, tampered(func(q url.Values) { q.Set(ParamExpires, "9999999999") }), ErrInvalidSignature},
+		{"unknown key", path, tampered(func(q url.Values) { q.Set(ParamKeyID, "k0") }), ErrUnknownKey},
+		{"no signature", path, tampered(func(q url.Values) { q.Del(ParamSignature) }), ErrMissingSignature},
+		{"two signatures", path, tampered(func(q url.Values) { q.Add(ParamSignature, "x") }), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		if err := signer.Verify(tt.path, tt.query, now); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+	if err := signer.Verify(path, query, now.Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected expired signature, got %v", err)
+	}
+}
+
+// TestKeyRotation проверяет, что новые ссылки подписываются первым ключом,
+// а ссылки старого ключа принимаются, пока он остаётся в списке.
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	old, _ := NewSigner([]Key{{ID: "old", Secret: "old secret"}})
+	rotated, _ := NewSigner([]Key{{ID: "new", Secret: "new secret"}, {ID: "old", Secret: "old secret"}})
+	retired, _ := NewSigner([]Key{{ID: "new", Secret: "new secret"}})
+
+	path, query := parse(t, old.Sign("/v1/thumbnails/dQw4w9WgXcQ", nil, now.Add(time.Minute)))
+	if err := rotated.Verify(path, query, now); err != nil {
+		t.Errorf("Expected old key to be accepted after rotation, got %v", err)
+	}
+	if err := retired.Verify(path, query, now); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected retired key to be rejected, got %v", err)
+	}
+	if signed := rotated.Sign("/v1/thumbnails/dQw4w9WgXcQ", nil, now.Add(time.Minute)); !strings.Contains(signed, "kid=new") {
+		t.Errorf("Expected new links to use the first key, got %s", signed)
+	}
+}
+
+// TestNewSignerErrors проверяет отклонение некорректных наборов ключей.
+func TestNewSignerErrors(t *testing.T) {
+	for name, keys := range map[string][]Key{
+		"no keys":      nil,
+		"empty id":     {{Secret: "secret"}},
+		"empty secret": {{ID: "k1"}},
+		"duplicate id": {{ID: "k1", Secret: "a"}, {ID: "k1", Secret: "b"}},
+	} {
+		if _, err := NewSigner(keys); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}